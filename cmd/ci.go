@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/gate"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/scan"
+	"os"
+	"strings"
+)
+
+// CI 扫描后按策略评估发现项，输出摘要，违反策略或扫描覆盖不完整时以状态码1退出
+// 参数或策略错误、无法读取绑定等全局资源(扫描结果不可信)时以状态码2退出
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func CI(args []string) {
+	flags := flag.NewFlagSet("ci", flag.ExitOnError)
+	policyFile := flags.String("policy", "", "策略文件(YAML/JSON)，默认: 集群范围的提权失败，其余提权和劫持警告")
+	printPolicy := flags.Bool("print-policy", false, "输出默认策略后退出，可作为策略文件模板")
+	baseline := flags.String("baseline", "", "基线扫描(latest 或扫描记录ID)，指定后只评估基线中不存在的发现项")
+	historyFile := flags.String("history", historyDBPath, "扫描历史数据库")
+	junit := flags.String("junit", "", "将评估结果以JUnit XML写入该文件")
+	node := flags.String("node", conf.Config.SSH.Nodename, "受控节点名称")
+	save := flags.Bool("save", false, "将扫描记录保存到扫描历史数据库")
+	requireComplete := flags.Bool("require-complete", true, "有资源无法读取(扫描覆盖不完整)时同样视为未通过，为false时只在无法读取绑定等全局资源时失败")
+	flags.Parse(args)
+
+	policy := gate.DefaultPolicy
+	if *printPolicy {
+		out, _ := policy.Marshal()
+		fmt.Print(string(out))
+		return
+	}
+	if *policyFile != "" {
+		var err error
+		if policy, err = gate.LoadPolicy(*policyFile); err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(2)
+		}
+	}
+	var baselineFindings []models.Finding
+	if *baseline != "" {
+		record, err := loadBaseline(*historyFile, *baseline)
+		if err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(2)
+		}
+		fmt.Println("[msg] 基线扫描:", record.ID)
+		baselineFindings = record.Findings
+		if baselineFindings == nil {
+			baselineFindings = []models.Finding{}
+		}
+	}
+
+	_, criticalSAs, coverage, err := scan.ScanCluster(*node)
+	if err != nil {
+		// 无法读取绑定时没有可评估的发现项，门禁不能放行
+		fmt.Println("[X] 扫描失败:", err.Error())
+		os.Exit(2)
+	}
+	record := scan.NewScanRecord(criticalSAs, coverage, request.Cluster(), *node)
+	if *save {
+		saveScan(record)
+	}
+	result := gate.Evaluate(policy, record.Findings, baselineFindings)
+
+	for _, decision := range result.Decisions {
+		if decision.Action == gate.ActionIgnore {
+			continue
+		}
+		label := "[WARN]"
+		if decision.Action == gate.ActionFail {
+			label = "[FAIL]"
+		}
+		finding := decision.Finding
+		fmt.Printf("%s %s %s (%s, %s) 规则: %s\n", label, finding.SA, finding.Type, finding.Severity, finding.Level, decision.Rule)
+	}
+	if *junit != "" {
+		if err := writeJUnit(*junit, record.Cluster, result); err != nil {
+			fmt.Println("[X] 写入JUnit报告失败:", err.Error())
+			os.Exit(2)
+		}
+	}
+	printCoverage(record.Coverage)
+	if globals := scan.GlobalGaps(record.Coverage); len(globals) > 0 {
+		// 无法读取绑定或Pod时扫描结果为空也会通过策略，门禁不能因此放行
+		fmt.Printf("[X] 无法读取 %s，扫描结果不可信\n", globalResources(globals))
+		os.Exit(2)
+	}
+	summary := fmt.Sprintf("发现项 %d 个: 失败 %d，警告 %d，忽略 %d，抑制 %d", len(result.Decisions), result.Failed, result.Warned, result.Ignored, result.Suppressed)
+	if *requireComplete && !scan.Complete(record.Coverage) {
+		fmt.Println("[X] 扫描覆盖不完整,", summary)
+		os.Exit(1)
+	}
+	if !result.Passed() {
+		fmt.Println("[X] 策略检查未通过,", summary)
+		os.Exit(1)
+	}
+	fmt.Println("[√] 策略检查通过,", summary)
+}
+
+// globalResources 全局覆盖缺口的资源列表，如 "clusterrolebindings, rolebindings(default)"
+func globalResources(gaps []models.CoverageGap) string {
+	resources := make([]string, 0, len(gaps))
+	for _, gap := range gaps {
+		resource := gap.Resource
+		if gap.Namespace != "" {
+			resource += "(" + gap.Namespace + ")"
+		}
+		resources = append(resources, resource)
+	}
+	return strings.Join(resources, ", ")
+}
+
+// loadBaseline 从扫描历史中读取基线扫描记录
+// 参数:
+//   - path: 扫描历史数据库
+//   - id: latest 表示当前集群最近一次扫描，否则为扫描记录ID
+func loadBaseline(path string, id string) (models.ScanRecord, error) {
+	db, err := openHistory(path)
+	if err != nil {
+		return models.ScanRecord{}, err
+	}
+	defer db.Close()
+	records, err := db.ListScans(request.Cluster())
+	if err != nil {
+		return models.ScanRecord{}, err
+	}
+	if id == "latest" && len(records) > 0 {
+		return records[len(records)-1], nil
+	}
+	for _, record := range records {
+		if record.ID == id {
+			return record, nil
+		}
+	}
+	return models.ScanRecord{}, fmt.Errorf("未找到基线扫描记录: %s", id)
+}
+
+// writeJUnit 将评估结果写入JUnit XML文件
+func writeJUnit(path string, cluster string, result gate.Result) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gate.WriteJUnit(file, cluster, result); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/multicluster"
+	"k8sEPDS/pkg/report"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// scanClusters 同时扫描多个集群并输出合并报告，有集群扫描失败时以状态码1退出
+// 参数:
+//   - allClusters: 扫描配置文件中的全部集群和命名配置，否则只扫描当前集群
+//   - allContexts: 展开kubeconfig中的全部上下文
+//   - parallel: 同时扫描的集群数量
+//   - node: 当前集群的受控节点名称
+//   - save: 是否将各集群的扫描记录保存到扫描历史数据库
+//   - filter: 发现项过滤条件
+//   - output: 输出格式(text 或报告格式)
+//   - out: 报告写入的文件，为空时输出到标准输出
+func scanClusters(allClusters bool, allContexts bool, parallel int, node string, save bool,
+	filter func(models.ScanRecord) models.ScanRecord, output string, out string) {
+	current := models.Profile{Name: conf.ProfileName(), K8s: conf.Config.K8s, SSH: conf.Config.SSH}
+	profiles := []models.Profile{current}
+	if allClusters {
+		profiles = conf.AllProfiles()
+	}
+	for i := range profiles {
+		if reflect.DeepEqual(profiles[i].K8s, current.K8s) {
+			profiles[i].SSH.Nodename = node
+		}
+	}
+	targets, err := multicluster.Targets(profiles, allContexts)
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(2)
+	}
+	fmt.Fprintf(os.Stderr, "[msg] 正在扫描 %d 个集群\n", len(targets))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	records := []models.ScanRecord{}
+	failed := map[string]string{}
+	for _, result := range multicluster.Scan(ctx, targets, node, parallel) {
+		if result.Err != nil {
+			failed[result.Target.Name] = result.Err.Error()
+			continue
+		}
+		if save {
+			saveScan(result.Record)
+		}
+		records = append(records, filter(result.Record))
+	}
+	combined := report.BuildMulti(records, failed)
+
+	if output != "text" {
+		if err := writeReport(out, output, combined); err != nil {
+			fmt.Println("[X] 写入报告失败:", err.Error())
+			os.Exit(1)
+		}
+		if out != "" {
+			fmt.Println("[√] 报告已写入:", out)
+		}
+	} else {
+		printCombined(combined)
+	}
+	if len(failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// printCombined 以文本输出多集群合并报告
+func printCombined(combined report.Report) {
+	for _, cluster := range combined.Clusters {
+		if cluster.Error != "" {
+			fmt.Printf("[X] 集群 %s 扫描失败: %s\n", cluster.Name, cluster.Error)
+			continue
+		}
+		fmt.Printf("[msg] 集群 %s: 关键SA %d 个，发现项 %d 个，风险分 %d\n", cluster.Name, cluster.ServiceAccounts, cluster.Findings, cluster.Score)
+	}
+	if len(combined.Findings) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "集群\tSA\t权限类型\t分类\t范围\t严重程度\t风险分\tPod\t节点")
+		for _, finding := range combined.Findings {
+			sa := finding.ServiceAccount
+			if finding.Incomplete {
+				sa += " (?)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", finding.Cluster, sa, finding.Permission,
+				finding.Kind, finding.Scope, finding.Severity, finding.Score, finding.Pod, finding.Node)
+		}
+		w.Flush()
+	}
+	if len(combined.Coverage) > 0 {
+		fmt.Printf("\n[!] 扫描覆盖不完整，%d 项资源无法读取，标记 (?) 的SA权限可能不完整:\n", len(combined.Coverage))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  集群\t资源\t命名空间\t原因\t受影响的SA\t错误")
+		for _, gap := range combined.Coverage {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", gap.Cluster, gap.Resource, gap.Namespace, gap.Reason,
+				strings.Join(gap.Affected(), ","), gap.Message)
+		}
+		w.Flush()
+	}
+	fmt.Println("\n[msg] 跨集群差异:")
+	if !combined.Compared() {
+		fmt.Println("  扫描成功的集群少于两个，无法比较")
+	} else if len(combined.Comparisons) == 0 {
+		fmt.Println("  各集群的关键权限一致")
+	}
+	for _, comparison := range combined.Comparisons {
+		fmt.Println("  -", comparison.Describe())
+	}
+	fmt.Printf("\n[msg] 共 %d 个集群，发现项 %d 个，风险分 %d\n", len(combined.Clusters), combined.Summary.Findings, combined.Summary.Score)
+}
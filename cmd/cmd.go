@@ -1,16 +1,29 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"k8sEPDS/conf"
 	"k8sEPDS/models"
+	"k8sEPDS/pkg/diff"
 	exp "k8sEPDS/pkg/exploit"
+	"k8sEPDS/pkg/harvest"
+	"k8sEPDS/pkg/history"
+	"k8sEPDS/pkg/report"
+	"k8sEPDS/pkg/request"
 	"k8sEPDS/pkg/scan"
-	"reflect"
+	"k8sEPDS/pkg/watch"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 )
 
+// historyDBPath 扫描历史数据库文件
+const historyDBPath = "history.db"
+
 var (
 	ssh          models.SSHConfig
 	criticalSAs  []models.CriticalSA
@@ -24,7 +37,13 @@ func Main() {
 		fmt.Println("\n可用命令:")
 		fmt.Println("  scan        - 扫描权限")
 		fmt.Println("  exp         - 利用漏洞")
-		fmt.Println("  resetconfig - 重置配置")
+		fmt.Println("  harvest     - 收集节点Token")
+		fmt.Println("  loot        - 查询获取的Token")
+		fmt.Println("  diff        - 对比两次扫描")
+		fmt.Println("  history     - 扫描历史统计")
+		fmt.Println("  report      - 导出扫描报告")
+		fmt.Println("  watch       - 持续监控")
+		fmt.Println("  resetconfig - 修改配置")
 		fmt.Println("  help        - 显示帮助")
 		fmt.Println("  exit        - 退出程序")
 		fmt.Print("请输入命令:")
@@ -32,8 +51,12 @@ func Main() {
 		switch operation {
 		case "scan":
 			{
-				saBindingMap = scan.GetSaBinding()
-				criticalSAs = scan.GetCriticalSA(scan.GetSA(saBindingMap), ssh.Nodename)
+				sas, scanned, coverage, err := scan.ScanCluster(ssh.Nodename)
+				if err != nil {
+					fmt.Println("[X] 扫描失败:", err.Error())
+					break
+				}
+				saBindingMap, criticalSAs = sas, scanned
 
 				fmt.Println()
 				for _, criticalSA := range criticalSAs {
@@ -50,17 +73,53 @@ func Main() {
 					fmt.Println("-------------------------------------------")
 					fmt.Println()
 				}
-
+				printCoverage(coverage)
+				saveScan(scan.NewScanRecord(criticalSAs, coverage, request.Cluster(), ssh.Nodename))
+			}
+		case "diff":
+			{
+				diffScans()
+			}
+		case "history":
+			{
+				showHistory()
+			}
+		case "watch":
+			{
+				watchCluster()
+			}
+		case "report":
+			{
+				exportReport()
 			}
 		case "exp":
 			{
 				exploit(classify(), ssh.Nodename, false)
 			}
+		case "harvest":
+			{
+				result, err := harvest.Node(context.Background(), ssh)
+				if err != nil {
+					fmt.Println("[X]", err.Error())
+					continue
+				}
+				printHarvest(result, false)
+				lootHarvest(result, os.Stdout)
+			}
+		case "loot":
+			{
+				lootShell()
+			}
 		case "resetconfig":
 			{
 				conf.GetConfig()
 				conf.UpdateConfig()
 				conf.GetConfig()
+				if err := conf.Save(); err != nil {
+					fmt.Println("[X]", err.Error())
+				} else {
+					fmt.Println("[√] 配置已保存:", conf.ConfigFile())
+				}
 				ssh = conf.Config.SSH
 			}
 		case "help":
@@ -77,11 +136,271 @@ func showHelp(){
 	fmt.Println("\n可用命令:")
     fmt.Println("  scan        - 扫描关键ServiceAccount")
     fmt.Println("  exp         - 利用关键SA的关键权限进行攻击")
-    fmt.Println("  resetconfig - 重新加载配置")
+    fmt.Println("  harvest     - 收集受控节点上全部Pod挂载的Token，与关键SA关联后按风险排序")
+    fmt.Println("  loot        - 查询利用模块和harvest获取的Token，如选出能在kube-system中create pods的最佳Token")
+    fmt.Println("  diff        - 对比两次扫描记录，显示关键SA的变化")
+    fmt.Println("  history     - 查询发现项存续时间、平均修复时间和命名空间趋势")
+    fmt.Println("  watch       - 持续监控RBAC/Pod/SA变化，出现新的关键SA时告警(Ctrl+C 退出)")
+    fmt.Println("  report      - 将保存的扫描记录导出为 json/sarif/csv/markdown/html 报告")
+    fmt.Println("  resetconfig - 修改配置")
     fmt.Println("  help        - 显示帮助信息")
     fmt.Println("  exit        - 退出程序")
 }
 
+// saveScan 将扫描记录保存到扫描历史数据库
+func saveScan(record models.ScanRecord) {
+	db, err := openHistory(historyDBPath)
+	if err != nil {
+		fmt.Println("[X] 保存扫描记录失败:", err.Error())
+		return
+	}
+	defer db.Close()
+	if err := db.SaveScan(record); err != nil {
+		fmt.Println("[X] 保存扫描记录失败:", err.Error())
+		return
+	}
+	fmt.Println("[msg] 扫描记录已保存:", record.ID)
+}
+
+// openHistory 打开扫描历史数据库，同目录下有旧版本保存的JSON扫描记录(scans/)时先导入
+// 旧版本不记录集群，导入的记录归入当前集群
+// 参数:
+//   - path: 扫描历史数据库文件
+func openHistory(path string) (*history.DB, error) {
+	db, err := history.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	count, err := db.ImportLegacy(filepath.Join(filepath.Dir(path), history.LegacyDir), request.Cluster())
+	if err != nil {
+		fmt.Println("[!]", err.Error())
+	} else if count > 0 {
+		fmt.Printf("[msg] 已将 %d 条旧扫描记录导入扫描历史数据库 %s\n", count, path)
+	}
+	return db, nil
+}
+
+// diffScans 选择两次保存的扫描记录并打印其差异
+func diffScans() {
+	db, err := openHistory(historyDBPath)
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		return
+	}
+	defer db.Close()
+	records, err := db.ListScans(request.Cluster())
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		return
+	}
+	if len(records) < 2 {
+		fmt.Println("[X] 至少需要两次扫描记录才能对比，请先执行 scan")
+		return
+	}
+	fmt.Println("[msg] 已保存的扫描记录:")
+	fmt.Println("---------------------------")
+	for i, record := range records {
+		fmt.Println(i, record.ID, "发现项:", len(record.Findings), "风险分:", record.Score)
+	}
+	fmt.Println("---------------------------")
+	var from, to int
+	fmt.Print("[输入] 选择旧扫描和新扫描的编号(旧 新): ")
+	fmt.Scan(&from)
+	fmt.Scan(&to)
+	if from < 0 || from >= len(records) || to < 0 || to >= len(records) {
+		fmt.Println("[X] 无效的扫描记录编号")
+		return
+	}
+	printDiff(records[from], records[to], diff.Compare(records[from].CriticalSAs, records[to].CriticalSAs))
+}
+
+// exportReport 选择一次保存的扫描记录并按指定格式写入报告文件
+func exportReport() {
+	db, err := openHistory(historyDBPath)
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		return
+	}
+	defer db.Close()
+	records, err := db.ListScans(request.Cluster())
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		return
+	}
+	if len(records) == 0 {
+		fmt.Println("[X] 没有扫描记录，请先执行 scan")
+		return
+	}
+	fmt.Println("[msg] 已保存的扫描记录:")
+	fmt.Println("---------------------------")
+	for i, record := range records {
+		fmt.Println(i, record.ID, "发现项:", len(record.Findings), "风险分:", record.Score)
+	}
+	fmt.Println("---------------------------")
+	var index int
+	fmt.Print("[输入] 选择扫描记录编号: ")
+	fmt.Scan(&index)
+	if index < 0 || index >= len(records) {
+		fmt.Println("[X] 无效的扫描记录编号")
+		return
+	}
+	format := ""
+	fmt.Printf("[输入] 报告格式(%s): ", strings.Join(report.Formats(), "/"))
+	fmt.Scan(&format)
+	if _, ok := report.Writers[format]; !ok {
+		fmt.Println("[X] 不支持的报告格式:", format)
+		return
+	}
+	path := ""
+	fmt.Print("[输入] 报告文件路径: ")
+	fmt.Scan(&path)
+	if err := writeReport(path, format, report.Build(records[index])); err != nil {
+		fmt.Println("[X] 导出报告失败:", err.Error())
+		return
+	}
+	fmt.Println("[√] 报告已写入:", path)
+}
+
+// writeReport 将报告以指定格式写入文件，文件路径为空时写到标准输出
+func writeReport(path string, format string, result report.Report) error {
+	if path == "" {
+		return report.WriteReport(os.Stdout, format, result)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteReport(file, format, result); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// showHistory 查询扫描历史统计
+func showHistory() {
+	db, err := openHistory(historyDBPath)
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		return
+	}
+	defer db.Close()
+	cluster := request.Cluster()
+	fmt.Println("0 发现项存续时间")
+	fmt.Println("1 平均修复时间")
+	fmt.Println("2 命名空间趋势")
+	fmt.Print("[输入] 选择查询类型: ")
+	var choice int
+	fmt.Scan(&choice)
+	switch choice {
+	case 0:
+		ages, err := db.FindingAges(cluster)
+		if err != nil {
+			fmt.Println("[X]", err.Error())
+			return
+		}
+		for _, age := range ages {
+			state := "已修复"
+			if age.Open {
+				state = "未修复"
+			}
+			fmt.Printf("[%s] %s %s (%s)\n", state, age.SA, age.Type, age.Severity)
+			fmt.Println("    [first seen]:", age.FirstSeen.Format(time.DateTime))
+			fmt.Println("    [last seen]:", age.LastSeen.Format(time.DateTime))
+		}
+	case 1:
+		mttr, count, err := db.MeanTimeToRemediation(cluster)
+		if err != nil {
+			fmt.Println("[X]", err.Error())
+			return
+		}
+		if count == 0 {
+			fmt.Println("[msg] 暂无已修复的发现项")
+			return
+		}
+		fmt.Printf("[msg] 已修复发现项 %d 次，平均修复时间 %s\n", count, mttr.Round(time.Second))
+	case 2:
+		trends, err := db.NamespaceTrends(cluster)
+		if err != nil {
+			fmt.Println("[X]", err.Error())
+			return
+		}
+		for _, trend := range trends {
+			fmt.Println("[namespace]:", trend.Namespace)
+			for _, point := range trend.Points {
+				fmt.Printf("    %s 发现项: %d 风险分: %d\n", point.ScanID, point.Findings, point.Score)
+			}
+		}
+	default:
+		fmt.Println("[X] 无效的查询类型")
+	}
+}
+
+// printDiff 打印两次扫描之间的差异
+func printDiff(oldRecord models.ScanRecord, newRecord models.ScanRecord, result diff.Result) {
+	fmt.Printf("\n=== 扫描对比 %s -> %s ===\n", oldRecord.ID, newRecord.ID)
+	if result.Empty() {
+		fmt.Println("[√] 两次扫描之间关键SA没有变化")
+		return
+	}
+	for _, change := range result.NewSAs {
+		fmt.Println("[+] 新增关键SA:", change.SA)
+		fmt.Println("    [permission]:", change.Types)
+		fmt.Println("    [level]:", change.ToLevel)
+		fmt.Println("    [roleBindings]:", change.NewBindings)
+	}
+	for _, change := range result.RemovedSAs {
+		fmt.Println("[-] 移除关键SA:", change.SA)
+		fmt.Println("    [permission]:", change.Types)
+	}
+	for _, change := range result.NewTypes {
+		fmt.Println("[*] 新增权限类型:", change.SA)
+		fmt.Println("    [permission]:", change.Types)
+		fmt.Println("    [new roleBindings]:", change.NewBindings)
+		fmt.Println("    [new roles/clusterRoles]:", change.NewRoles)
+	}
+	for _, change := range result.ScopeChanges {
+		fmt.Println("[!] 权限范围扩大:", change.SA)
+		fmt.Println("    [level]:", change.FromLevel, "->", change.ToLevel)
+		fmt.Println("    [new roleBindings]:", change.NewBindings)
+		fmt.Println("    [new roles/clusterRoles]:", change.NewRoles)
+	}
+}
+
+// watchCluster 持续监控集群，直到收到中断信号
+func watchCluster() {
+	clientset, err := request.GetClientSet("")
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		return
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Println("[msg] 开始持续监控，按 Ctrl+C 退出")
+	watcher := watch.NewWatcher(clientset, ssh.Nodename, conf.Config.K8s.SensitiveNodes, printEvent)
+	if err := watcher.Run(ctx); err != nil {
+		fmt.Println("[X] 持续监控失败:", err.Error())
+	}
+	fmt.Println("[msg] 持续监控已停止")
+}
+
+// printEvent 打印持续监控产生的事件
+func printEvent(event watch.Event) {
+	fmt.Printf("[event] %s %s\n", event.Time.Format(time.DateTime), event.Type)
+	fmt.Println("    [SA]:", event.SA)
+	if len(event.Types) != 0 {
+		fmt.Println("    [permission]:", event.Types)
+	}
+	fmt.Println("    [level]:", event.Level)
+	if event.Pod != "" {
+		fmt.Println("    [pod]:", event.Pod)
+		fmt.Println("    [node]:", event.Node)
+	}
+	if len(event.Cause) != 0 {
+		fmt.Println("    [roleBindings]:", event.Cause)
+	}
+}
+
 func classify() map[string][]SA_sort {
 	/*
 		{
@@ -90,48 +409,16 @@ func classify() map[string][]SA_sort {
 		}
 	*/
 	result := make(map[string][]SA_sort, 0)
-	kind := map[string]string{
-		//createrolebinding*2、patchrolebinding*2、patchrole*2
-		"impersonate":               "anyescalate",
-		"createclusterrolebindings": "anyescalate",
-		"patchclusterroles":         "anyescalate",
-		"createtokens":              "anyescalate",
-		"createpods":                "anyescalate",
-		"createpodcontrollers":      "anyescalate",
-		"patchpodcontrollers":       "anyescalate",
-		"createwebhookconfig":       "anyescalate",
-		"patchwebhookconfig":        "anyescalate",
-		"createrolebindings":        "restrictescalate",
-		"patchclusterrolebindings":  "restrictescalate",
-		"patchrolebindings":         "restrictescalate",
-		"patchroles":                "restrictescalate",
-		"createsecrets":             "restrictescalate",
-		"getsecrets":                "restrictescalate",
-		"execpods":                  "restrictescalate",
-		"execpods2":                 "restrictescalate",
-		"patchpods":                 "restrictescalate",
-		"watchsecrets":              "restrictescalate",
-		"patchnodes":                "anyhijack",
-		"deletenodes":               "anyhijack",
-		"deletepods":                "restricthijack",
-		"createpodeviction":         "restricthijack",
-	}
-	replacements := map[string]string{
-		"daemonsets":                      "podcontrollers",
-		"deployments":                     "podcontrollers",
-		"statefulsets":                    "podcontrollers",
-		"replicasets":                     "podcontrollers",
-		"jobs":                            "podcontrollers",
-		"cronjobs":                        "podcontrollers",
-		"replicationcontrollers":          "podcontrollers",
-		"mutatingwebhookconfigurations":   "webhookconfig",
-		"validatingwebhookconfigurations": "webhookconfig",
-	}
 	if len(saBindingMap) == 0 {
-		saBindingMap = scan.GetSaBinding()
+		sas, _, _, err := scan.ScanCluster(ssh.Nodename)
+		if err != nil {
+			fmt.Println("[X] 扫描失败:", err.Error())
+			return result
+		}
+		saBindingMap = sas
 	}
 	if len(criticalSAs) == 0 {
-		criticalSAs = scan.GetCriticalSA(scan.GetSA(saBindingMap), ssh.Nodename)
+		criticalSAs = scan.GetCriticalSA(saBindingMap, ssh.Nodename)
 	}
 	criticalSAsWrappers := []models.CriticalSAWrapper{}
 	for _, criticalSA := range criticalSAs {
@@ -148,28 +435,11 @@ func classify() map[string][]SA_sort {
 		if !criticalSA.Crisa.InNode || !criticalSA.Crisa.SA0.IsMounted {
 			continue
 		}
-		kindType := criticalSA.Type //reduce the rawType
-		if strings.Contains(criticalSA.Type, "(") {
-			kindType = kindType[:strings.Index(kindType, "(")]
-		} else if strings.Contains(criticalSA.Type, "[") {
-			kindType = kindType[:strings.Index(kindType, "[")]
-		}
-		dispatchfunc := kindType
-		for old, new := range replacements {
-			dispatchfunc = strings.Replace(dispatchfunc, old, new, -1)
-		}
-		newResult := SA_sort{Level: kind[dispatchfunc] + "-" + criticalSA.Type, SA: criticalSA, dispatchFunc: dispatchfunc}
-		tmpType := ""
-		if strings.Contains(kind[dispatchfunc], "escalate") {
-			tmpType = "escalate"
-		} else if strings.Contains(kind[dispatchfunc], "hijack") {
-			tmpType = "hijack"
-		} else if strings.Contains(kind[dispatchfunc], "dos") {
-			tmpType = "dos"
-		}
-		if criticalSA.Crisa.Level == "namespace" && !strings.Contains(criticalSA.Type, "kube-system") {
-			newResult = SA_sort{Level: "restrict" + tmpType + "-" + criticalSA.Type, SA: criticalSA, dispatchFunc: dispatchfunc}
-		}
+		dispatchfunc := scan.DispatchName(criticalSA.Type)
+		// 按权限本身的范围分类，与发现项一致(见 scan.Scope)
+		kind := scan.Kind(scan.Scope(criticalSA.Type), criticalSA.Type)
+		tmpType := scan.Category(kind)
+		newResult := SA_sort{Level: kind + "-" + criticalSA.Type, SA: criticalSA, dispatchFunc: dispatchfunc}
 		result[tmpType] = append(result[tmpType], newResult)
 	}
 	for k := range result {
@@ -286,15 +556,12 @@ func hijack(payloads map[string][]SA_sort, ) bool {
 }
 
 func dispatch(sa models.CriticalSA, dispatchFunc string) {
-	funcMap := map[string]interface{}{
-		"impersonate": exp.Impersonate, "createclusterrolebindings": exp.Createclusterrolebindings, "patchclusterroles": exp.Patchclusterroles, "createtokens": exp.Createtokens, "createpods": exp.Createpods, "createpodcontrollers": exp.Createpodcontrollers, "patchpodcontrollers": exp.Patchpodcontrollers,
-		"createrolebindings": exp.Createrolebindings, "patchclusterrolebindings": exp.Patchclusterrolebindings, "patchrolebindings": exp.Patchrolebindings, "patchroles": exp.Patchroles, "createsecrets": exp.Createsecrets, "getsecrets": exp.Getsecrets, "execpods": exp.Execpods, "execpods2": exp.Execpods2, "patchpods": exp.Patchpods,
-		"patchnodes": exp.Patchnodes, "deletepods": exp.Deletepods, "createpodeviction": exp.Createpodeviction, "deletenodes": exp.Deletenodes, "watchsecrets": exp.WatchSecrets, "patchwebhookconfig": exp.Patchwebhookconfig, "createwebhookconfig": exp.Createwebhookconfig,
-	}
-	funcValue := reflect.ValueOf(funcMap[dispatchFunc])
-	args := []reflect.Value{reflect.ValueOf([]models.CriticalSA{sa}), reflect.ValueOf(ssh)}
-	//fmt.Println("[msg] About to be called:", strings.Title(sa.Type))
-	funcValue.Call(args)
+	module, ok := exp.Modules[dispatchFunc]
+	if !ok {
+		fmt.Println("[X] 未找到利用模块:", dispatchFunc)
+		return
+	}
+	module([]models.CriticalSA{sa}, ssh, exp.ConsolePrompter{})
 }
 
 type SA_sort struct {
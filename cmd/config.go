@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/models"
+	"os"
+	"strings"
+)
+
+// Config 查看或修改配置和命名配置
+// 用法: k8sEPDS config show|set|profiles|use|create|validate|save
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Config(args []string) {
+	if len(args) == 0 {
+		configUsage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "show":
+		conf.GetConfig()
+	case "set":
+		if len(args) == 1 {
+			configUsage()
+			os.Exit(2)
+		}
+		setFields(args[1:])
+		saveConfig()
+	case "profiles":
+		listProfiles()
+	case "use":
+		if len(args) != 2 {
+			configUsage()
+			os.Exit(2)
+		}
+		if err := conf.UseProfile(args[1]); err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		saveConfig()
+		fmt.Println("[√] 已切换到命名配置:", conf.ProfileName())
+	case "create":
+		if len(args) < 2 {
+			configUsage()
+			os.Exit(2)
+		}
+		createProfile(args[1], args[2:])
+	case "validate":
+		if len(args) > 2 {
+			configUsage()
+			os.Exit(2)
+		}
+		validateProfiles(args[1:])
+	case "save":
+		saveConfig()
+	default:
+		fmt.Println("[X] 未知的操作:", args[0])
+		configUsage()
+		os.Exit(2)
+	}
+}
+
+// setFields 修改当前配置的配置项
+// 参数:
+//   - pairs: 键=值 形式的配置项
+func setFields(pairs []string) {
+	fields := map[string]conf.Field{}
+	for _, field := range conf.Fields() {
+		fields[strings.ToLower(field.Key)] = field
+	}
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		field, exists := fields[strings.ToLower(key)]
+		if !found || !exists {
+			fmt.Println("[X] 无效的配置项:", pair)
+			configUsage()
+			os.Exit(2)
+		}
+		if err := field.Set(value); err != nil {
+			fmt.Printf("[X] %s: %s\n", field.Key, err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
+// saveConfig 验证当前配置，通过后写回配置文件
+func saveConfig() {
+	if err := conf.ValidateConfig(conf.Config); err != nil {
+		fmt.Println("[X] 配置验证失败:", err.Error())
+		os.Exit(1)
+	}
+	if err := conf.Save(); err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("[√] 配置已保存:", conf.ConfigFile())
+}
+
+// createProfile 以当前配置为模板新建命名配置并切换到该配置，再按 键=值 修改配置项后保存
+// 参数:
+//   - name: 配置名称
+//   - pairs: 新配置中需要修改的配置项
+func createProfile(name string, pairs []string) {
+	if err := conf.CreateProfile(name); err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	if err := conf.UseProfile(name); err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	setFields(pairs)
+	saveConfig()
+	fmt.Println("[√] 已创建并切换到命名配置:", name)
+}
+
+// listProfiles 列出默认配置和全部命名配置，* 标记当前使用的配置
+func listProfiles() {
+	current := conf.ProfileName()
+	fmt.Println("[msg] 配置文件:", conf.ConfigFile())
+	printProfile(current == conf.DefaultProfile, conf.DefaultProfile, conf.Default())
+	for _, profile := range conf.Profiles {
+		if profile.Name == current {
+			profile.K8s, profile.SSH = conf.Config.K8s, conf.Config.SSH
+		}
+		printProfile(profile.Name == current, profile.Name, profile)
+	}
+}
+
+// printProfile 打印一个命名配置的集群和受控节点
+func printProfile(current bool, name string, profile models.Profile) {
+	mark := " "
+	if current {
+		mark = "*"
+	}
+	cluster := profile.K8s.ApiServer
+	if profile.K8s.Kubeconfig != "" {
+		cluster = profile.K8s.Kubeconfig
+		if profile.K8s.Context != "" {
+			cluster += " (" + profile.K8s.Context + ")"
+		}
+	}
+	fmt.Printf("%s %-15s 集群: %-30s 受控节点: %s (%s)\n", mark, name, cluster, profile.SSH.Nodename, profile.SSH.Host)
+}
+
+// validateProfiles 验证指定的命名配置，未指定时验证全部配置，有配置无效时以状态码1退出
+// 参数:
+//   - names: 需要验证的配置名称
+func validateProfiles(names []string) {
+	profiles := map[string]models.Profile{conf.DefaultProfile: conf.Default()}
+	order := []string{conf.DefaultProfile}
+	for _, profile := range conf.Profiles {
+		if profile.Name == conf.Profile {
+			profile.K8s, profile.SSH = conf.Config.K8s, conf.Config.SSH
+		}
+		profiles[profile.Name] = profile
+		order = append(order, profile.Name)
+	}
+	if len(names) == 0 {
+		names = order
+	}
+	invalid := false
+	for _, name := range names {
+		profile, exists := profiles[name]
+		if !exists {
+			fmt.Println("[X] 未找到命名配置:", name)
+			invalid = true
+			continue
+		}
+		if err := conf.ValidateConfig(models.K8sEPDSConfig{K8s: profile.K8s, SSH: profile.SSH}); err != nil {
+			fmt.Printf("[X] %s: %s\n", name, err.Error())
+			invalid = true
+			continue
+		}
+		fmt.Printf("[√] %s: 配置有效\n", name)
+	}
+	if invalid {
+		os.Exit(1)
+	}
+}
+
+// configUsage 打印 config 子命令的用法和可修改的配置项
+func configUsage() {
+	fmt.Println("用法: k8sEPDS config show")
+	fmt.Println("      k8sEPDS config set 键=值 [键=值...]")
+	fmt.Println("      k8sEPDS config profiles                        列出命名配置")
+	fmt.Println("      k8sEPDS config use 名称                        切换命名配置(default 为顶层 k8s/ssh)")
+	fmt.Println("      k8sEPDS config create 名称 [键=值...]          以当前配置为模板新建命名配置并切换")
+	fmt.Println("      k8sEPDS config validate [名称]                 验证命名配置，不指定名称时验证全部")
+	fmt.Println("      k8sEPDS config save                            将当前配置写回配置文件")
+	fmt.Println("\n配置文件:", conf.ConfigFile())
+	fmt.Println("命名配置:", conf.ProfileName())
+	fmt.Println("\n配置项(括号内为覆盖该项的环境变量，覆盖的值不会写回配置文件):")
+	for _, field := range conf.Fields() {
+		fmt.Printf("  %-20s %s (%s)\n", field.Key, field.Label, conf.EnvName(field.Key))
+	}
+}
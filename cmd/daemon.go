@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/pkg/metrics"
+	"k8sEPDS/pkg/scan"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Daemon 以守护进程模式定时扫描，并通过 /metrics 导出过度权限态势指标
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Daemon(args []string) {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	addr := flags.String("addr", ":9090", "指标服务监听地址")
+	interval := flags.Duration("interval", 5*time.Minute, "扫描周期")
+	node := flags.String("node", conf.Config.SSH.Nodename, "受控节点名称")
+	flags.Parse(args)
+	if *interval <= 0 {
+		fmt.Println("[X] 扫描周期必须大于0")
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	exporter := metrics.NewExporter()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter.Handler())
+	server := &http.Server{Addr: *addr, Handler: mux}
+	go func() {
+		fmt.Println("[msg] 指标服务监听", *addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Println("[X] 指标服务运行失败:", err.Error())
+			stop()
+		}
+	}()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		_, criticalSAs, coverage, err := scan.ScanCluster(*node)
+		if err != nil {
+			// 保留上一次扫描的指标，last_scan_timestamp_seconds 不更新，可以据此告警
+			fmt.Printf("[X] %s 扫描失败: %s\n", start.Format(time.DateTime), err.Error())
+		} else {
+			exporter.Update(criticalSAs, coverage, time.Since(start))
+			fmt.Printf("[√] %s 扫描完成，关键SA %d 个，耗时 %s\n", start.Format(time.DateTime), len(criticalSAs), time.Since(start).Round(time.Millisecond))
+			if len(coverage) > 0 {
+				fmt.Printf("[msg] %d 项资源无法读取，结果可能不完整\n", len(coverage))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			server.Close()
+			return
+		case <-ticker.C:
+		}
+	}
+}
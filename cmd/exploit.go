@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/models"
+	exp "k8sEPDS/pkg/exploit"
+	"k8sEPDS/pkg/scan"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Exploit 非交互式执行利用模块，模块中的每个交互输入都对应一个同名参数
+// 用法: k8sEPDS exploit <模块> [--sa namespace/name] [--confirm] [模块参数]
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Exploit(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		exploitUsage()
+		return
+	}
+	name := args[0]
+	module, ok := exp.Modules[name]
+	if !ok {
+		fmt.Println("[X] 未知的利用模块:", name)
+		exploitUsage()
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("exploit "+name, flag.ExitOnError)
+	saName := flags.String("sa", "", "使用的关键SA(格式:namespace/name)，默认使用第一个可用的SA")
+	node := flags.String("node", conf.Config.SSH.Nodename, "受控节点名称")
+	confirms := map[string]*bool{}
+	inputs := map[string]*string{}
+	for _, param := range exp.Params[name] {
+		if param.Confirm {
+			confirms[param.Name] = flags.Bool(param.Name, false, param.Label)
+		} else {
+			inputs[param.Name] = flags.String(param.Name, "", param.Label)
+		}
+	}
+	flags.Parse(args[1:])
+
+	_, criticalSAs, _, err := scan.ScanCluster(*node)
+	if err != nil {
+		fmt.Println("[X] 扫描失败:", err.Error())
+		os.Exit(1)
+	}
+	candidates := exp.Candidates(criticalSAs, name)
+	var target *models.CriticalSA
+	for i := range candidates {
+		if *saName == "" || candidates[i].Crisa.SA0.Name == *saName {
+			target = &candidates[i].Crisa
+			break
+		}
+	}
+	if target == nil {
+		fmt.Printf("[X] 没有可用于 %s 的关键SA %s\n", name, *saName)
+		os.Exit(1)
+	}
+
+	values := exp.Values{}
+	for key, value := range inputs {
+		values[key] = *value
+	}
+	for key, value := range confirms {
+		values[key] = "N"
+		if *value {
+			values[key] = "Y"
+		}
+	}
+	sshConfig := conf.NodeConfig(*node)
+	fmt.Printf("[msg] 即将使用账户%s执行%s\n", target.SA0.Name, name)
+	if _, err := module([]models.CriticalSA{*target}, sshConfig, values); err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+}
+
+// exploitUsage 打印利用模块及其参数
+func exploitUsage() {
+	fmt.Println("用法: k8sEPDS exploit <模块> [--sa namespace/name] [--node 节点] [模块参数]")
+	fmt.Println("\n利用模块:")
+	names := make([]string, 0, len(exp.Modules))
+	for name := range exp.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println("  " + name)
+		for _, param := range exp.Params[name] {
+			if param.Confirm {
+				fmt.Printf("      --%-20s %s\n", param.Name, param.Label)
+			} else {
+				fmt.Printf("      --%-20s %s\n", param.Name+" 值", param.Label)
+			}
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build gui
+
+package cmd
+
+import (
+	"k8sEPDS/pkg/gui"
+
+	"fyne.io/fyne/v2/app"
+)
+
+// GUI 启动桌面前端
+// 桌面驱动依赖cgo和OpenGL/X11开发库，需要使用 -tags gui 编译
+func GUI(args []string) {
+	gui.New(app.NewWithID("k8sEPDS"), nil).ShowAndRun()
+}
@@ -0,0 +1,14 @@
+//go:build !gui
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+// GUI 未使用 -tags gui 编译时，桌面前端不可用
+func GUI(args []string) {
+	fmt.Println("[X] 当前程序未包含桌面前端，请使用 go build -tags gui 重新编译")
+	os.Exit(1)
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/pkg/harvest"
+	"k8sEPDS/pkg/report"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Harvest 收集受控节点上全部Pod挂载的Token，与关键SA关联后按风险排序输出
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Harvest(args []string) {
+	flags := flag.NewFlagSet("harvest", flag.ExitOnError)
+	node := flags.String("node", conf.Config.SSH.Nodename, "受控节点名称，按节点名称使用配置文件中对应的SSH及Token读取配置")
+	output := flags.String("output", "text", "输出格式: text、json")
+	out := flags.String("out", "", "结果写入的文件，默认输出到标准输出")
+	showTokens := flags.Bool("show-tokens", false, "输出Token原文(默认隐藏)")
+	noLoot := flags.Bool("no-loot", false, "不验证和保存收集到的Token(默认保存到Token库)")
+	flags.Parse(args)
+	if *output != "text" && *output != "json" {
+		fmt.Println("[X] 不支持的输出格式:", *output)
+		os.Exit(2)
+	}
+
+	result, err := harvest.Node(context.Background(), conf.NodeConfig(*node))
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	if !*noLoot {
+		progress := os.Stdout
+		if *output == "json" && *out == "" {
+			progress = os.Stderr
+		}
+		lootHarvest(result, progress)
+	}
+	if !*showTokens {
+		for i := range result.Credentials {
+			result.Credentials[i].Token = ""
+		}
+	}
+
+	if *output == "json" {
+		if err := writeHarvest(*out, result); err != nil {
+			fmt.Println("[X] 写入结果失败:", err.Error())
+			os.Exit(1)
+		}
+		if *out != "" {
+			fmt.Println("[√] 结果已写入:", *out)
+		}
+		return
+	}
+	printHarvest(result, *showTokens)
+}
+
+// writeHarvest 以JSON输出收集结果，覆盖缺口与扫描报告的格式一致
+func writeHarvest(path string, result harvest.Result) error {
+	data, err := json.MarshalIndent(struct {
+		harvest.Result
+		Coverage []report.Gap `json:"coverage"`
+	}{result, report.Gaps("", result.Coverage)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// printHarvest 以表格输出收集结果
+func printHarvest(result harvest.Result, showTokens bool) {
+	if len(result.Credentials) == 0 {
+		fmt.Printf("[!] 节点 %s 上没有找到可用的ServiceAccount Token\n", result.Node)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tSA\t风险分\t严重程度\t权限类型\tPod\t过期时间\t状态")
+		for i, credential := range result.Credentials {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", i+1, credential.SA, credential.Score, credential.Severity,
+				strings.Join(credential.Types, ","), credential.Pod, expiry(credential.Claims.Expiry), credentialState(credential))
+		}
+		w.Flush()
+		if showTokens {
+			fmt.Println()
+			for i, credential := range result.Credentials {
+				fmt.Printf("#%d %s\n%s\n", i+1, credential.Path, credential.Token)
+			}
+		}
+	}
+	unlisted, usable := 0, 0
+	for _, credential := range result.Credentials {
+		if !credential.Listed {
+			unlisted++
+		}
+		if !credential.Expired {
+			usable++
+		}
+	}
+	fmt.Printf("\n[msg] 节点 %s (%s) 上共 %d 个Token，未过期 %d 个，其中 %d 个来自API未列出的Pod\n",
+		result.Node, result.Source, len(result.Credentials), usable, unlisted)
+	if len(result.Skipped) > 0 {
+		fmt.Printf("[!] %d 个Token文件无法读取或不是ServiceAccount Token:\n", len(result.Skipped))
+		for _, skipped := range result.Skipped {
+			fmt.Printf("  %s: %s\n", skipped.Path, skipped.Reason)
+		}
+	}
+	printCoverage(result.Coverage)
+}
+
+// expiry Token过期时间的显示，未设置过期时间时为 "-"
+func expiry(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// credentialState Token状态的显示
+func credentialState(credential harvest.Credential) string {
+	states := []string{}
+	if credential.Expired {
+		states = append(states, "已过期")
+	}
+	if !credential.Listed {
+		states = append(states, "API未列出")
+	}
+	if credential.Claims.Legacy {
+		states = append(states, "旧版Token")
+	}
+	if len(states) == 0 {
+		return "可用"
+	}
+	return strings.Join(states, ",")
+}
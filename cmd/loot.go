@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"k8sEPDS/pkg/harvest"
+	"k8sEPDS/pkg/introspect"
+	"k8sEPDS/pkg/loot"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/vault"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Loot 管理利用模块和 harvest 获取的Token
+// 用法: k8sEPDS loot list|show|use|prune|delete
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Loot(args []string) {
+	if len(args) == 0 {
+		lootUsage()
+		os.Exit(2)
+	}
+	store, err := loot.Unlock()
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("loot list", flag.ExitOnError)
+		all := flags.Bool("all-clusters", false, "列出全部集群的Token，默认只列出当前集群")
+		flags.Parse(args[1:])
+		printLoot(store, *all)
+	case "show":
+		if len(args) != 2 {
+			lootUsage()
+			os.Exit(2)
+		}
+		entry, err := store.Get(args[1])
+		if err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		showLoot(entry)
+	case "use":
+		flags := flag.NewFlagSet("loot use", flag.ExitOnError)
+		verb := flags.String("verb", "", "动词，如 create、get、patch")
+		resource := flags.String("resource", "", "资源，可以带API组和子资源，如 pods、pods/exec、deployments.apps")
+		name := flags.String("name", "", "资源名称，为空时只匹配不限制资源名称的权限")
+		namespace := flags.String("n", "default", "命名空间")
+		raw := flags.Bool("raw", false, "只输出Token原文，便于 kubectl --token=$(k8sEPDS loot use ... --raw)")
+		flags.Parse(args[1:])
+		if *verb == "" || *resource == "" {
+			fmt.Println("[X] 需要指定 --verb 和 --resource")
+			os.Exit(2)
+		}
+		entry, ok := bestLoot(store, loot.Query{Verb: *verb, Resource: *resource, Name: *name, Namespace: *namespace}, !*raw)
+		if !ok {
+			os.Exit(1)
+		}
+		if *raw {
+			fmt.Println(entry.Token)
+		}
+	case "prune":
+		pruneLoot(store)
+	case "delete":
+		if len(args) != 2 {
+			lootUsage()
+			os.Exit(2)
+		}
+		if err := store.Delete(args[1]); err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		if err := store.Save(); err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("[√] 已删除Token:", args[1])
+	default:
+		fmt.Println("[X] 未知的操作:", args[0])
+		lootUsage()
+		os.Exit(2)
+	}
+}
+
+// lootShell 交互式命令行中的Token库操作
+func lootShell() {
+	store, err := loot.Unlock()
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		return
+	}
+	operation := ""
+	fmt.Print("[输入] 操作(list/show/use/prune/delete): ")
+	fmt.Scan(&operation)
+	switch operation {
+	case "list":
+		printLoot(store, false)
+	case "show", "delete":
+		id := ""
+		fmt.Print("[输入] Token ID(可以只输入前几位): ")
+		fmt.Scan(&id)
+		if operation == "show" {
+			entry, err := store.Get(id)
+			if err != nil {
+				fmt.Println("[X]", err.Error())
+				return
+			}
+			showLoot(entry)
+			return
+		}
+		if err := store.Delete(id); err != nil {
+			fmt.Println("[X]", err.Error())
+			return
+		}
+		if err := store.Save(); err != nil {
+			fmt.Println("[X]", err.Error())
+			return
+		}
+		fmt.Println("[√] 已删除Token:", id)
+	case "use":
+		var verb, resource, namespace string
+		fmt.Print("[输入] 动词 资源 命名空间(如 create pods kube-system): ")
+		fmt.Scan(&verb, &resource, &namespace)
+		if entry, ok := bestLoot(store, loot.Query{Verb: verb, Resource: resource, Namespace: namespace}, true); ok {
+			fmt.Println("[result]", entry.Token)
+		}
+	case "prune":
+		pruneLoot(store)
+	default:
+		fmt.Println("[X] 未知的操作:", operation)
+	}
+}
+
+// bestLoot 查找能执行该操作的最佳Token，只在当前集群的Token中查找
+// 参数:
+//   - verbose: 是否输出候选Token
+func bestLoot(store *loot.Store, query loot.Query, verbose bool) (loot.Entry, bool) {
+	query.Cluster = lootCluster()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	best, candidates, err := store.Best(ctx, query)
+	// 查询过程中可能在新的命名空间中验证了权限
+	if saveErr := store.Save(); saveErr != nil {
+		fmt.Println("[!] 保存验证结果失败:", saveErr.Error())
+	}
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		return loot.Entry{}, false
+	}
+	if verbose {
+		fmt.Printf("[√] 能执行 %s 的Token %d 个，使用:\n", query, len(candidates))
+		for i, entry := range candidates {
+			mark := " "
+			if i == 0 {
+				mark = "*"
+			}
+			fmt.Printf("  %s %s  %s  %s  过期时间 %s\n", mark, entry.ID, entry.SA, entry.Technique, expiry(entry.Expiry))
+		}
+	}
+	return best, true
+}
+
+// lootCluster 当前集群的API服务器，与保存Token时记录的集群对应
+func lootCluster() string {
+	return request.Cluster()
+}
+
+// printLoot 以表格输出Token库中的Token
+// 参数:
+//   - all: 是否输出全部集群的Token
+func printLoot(store *loot.Store, all bool) {
+	cluster := lootCluster()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSA\t获取方式\t来源\t获取时间\t过期时间\t状态\t已验证命名空间")
+	count := 0
+	for _, entry := range store.Entries() {
+		if !all && entry.Cluster != cluster {
+			continue
+		}
+		state := "可用"
+		if !entry.Valid {
+			state = "不可用"
+		}
+		if all {
+			state += " " + entry.Cluster
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.ID, or(entry.SA), entry.Technique, or(entry.Source),
+			entry.Obtained.Local().Format("2006-01-02 15:04"), expiry(entry.Expiry), state, strings.Join(namespaces(entry), ","))
+		count++
+	}
+	w.Flush()
+	fmt.Printf("\n[msg] Token库 %s 中共 %d 个Token\n", store.Path(), count)
+}
+
+// showLoot 输出Token的详细信息、已验证的权限和Token原文
+func showLoot(entry loot.Entry) {
+	fmt.Println("[ID]:", entry.ID)
+	fmt.Println("[SA]:", or(entry.SA))
+	fmt.Println("[technique]:", entry.Technique, or(entry.Source))
+	fmt.Println("[cluster]:", entry.Cluster)
+	fmt.Println("[obtained]:", entry.Obtained.Local().Format(time.DateTime))
+	fmt.Println("[expiry]:", expiry(entry.Expiry))
+	fmt.Println("[valid]:", entry.Valid, entry.Username)
+	for _, namespace := range namespaces(entry) {
+		permissions := entry.Permissions[namespace]
+		fmt.Println("[checked]:", permissions.Checked.Local().Format(time.DateTime))
+		introspect.PrintRules(namespace, permissions.Rules, permissions.Incomplete)
+	}
+	fmt.Println("[token]:", entry.Token)
+}
+
+// pruneLoot 删除已过期的Token
+func pruneLoot(store *loot.Store) {
+	pruned := store.Prune(time.Now())
+	if err := store.Save(); err != nil {
+		fmt.Println("[X]", err.Error())
+		return
+	}
+	for _, entry := range pruned {
+		fmt.Printf("[-] %s %s (过期时间 %s)\n", entry.ID, entry.SA, expiry(entry.Expiry))
+	}
+	fmt.Printf("[√] 已删除 %d 个过期Token\n", len(pruned))
+}
+
+// namespaces Token已验证权限的命名空间(已排序)
+func namespaces(entry loot.Entry) []string {
+	result := make([]string, 0, len(entry.Permissions))
+	for namespace := range entry.Permissions {
+		result = append(result, namespace)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// or 为空时输出 "-"
+func or(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// lootHarvest 验证收集到的未过期Token并保存到Token库
+// 验证结果输出到 out，输出JSON到标准输出时使用标准错误，避免混入结果
+func lootHarvest(result harvest.Result, out *os.File) {
+	saved, added := 0, 0
+	for _, credential := range result.Credentials {
+		if credential.Expired || credential.Token == "" {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		report := introspect.Inspect(ctx, credential.Token, credential.Claims.Namespace)
+		cancel()
+		_, isNew, err := loot.Record(credential.Token, "harvest", result.Node+":"+credential.Path, report)
+		if err != nil {
+			fmt.Fprintln(out, "[!] Token未保存到Token库:", err.Error())
+			return
+		}
+		saved++
+		if isNew {
+			added++
+		}
+	}
+	if saved > 0 {
+		fmt.Fprintf(out, "[√] %d 个Token已验证并保存到Token库，其中新增 %d 个\n", saved, added)
+	}
+}
+
+// lootUsage 打印 loot 子命令的用法
+func lootUsage() {
+	fmt.Println("用法: k8sEPDS loot list [--all-clusters]                                  列出Token库中当前集群的Token")
+	fmt.Println("      k8sEPDS loot show ID                                                输出Token的详细信息、已验证的权限和原文")
+	fmt.Println("      k8sEPDS loot use --verb 动词 --resource 资源 [-n 命名空间] [--raw]  使用能执行该操作的最佳Token")
+	fmt.Println("      k8sEPDS loot prune                                                  删除已过期的Token")
+	fmt.Println("      k8sEPDS loot delete ID                                              删除Token")
+	fmt.Println("\n示例: k8sEPDS loot use --verb create --resource pods -n kube-system")
+	fmt.Println("\nToken库:", loot.DefaultFile(), "(环境变量", loot.EnvFile+")，利用模块和 harvest 获取的Token会自动验证后保存")
+	fmt.Println("口令: 首次保存Token时设置，也可以通过环境变量", vault.EnvPassphrase, "提供")
+}
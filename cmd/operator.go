@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/pkg/operator"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Operator 以集群内控制器模式运行，将扫描结果写入PolicyReport/ClusterPolicyReport
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Operator(args []string) {
+	flags := flag.NewFlagSet("operator", flag.ExitOnError)
+	mode := flags.String("mode", operator.ModeSchedule, "运行模式: schedule(定时扫描) 或 watch(基于informer)")
+	interval := flags.Duration("interval", 10*time.Minute, "schedule模式下的扫描周期")
+	node := flags.String("node", conf.Config.SSH.Nodename, "受控节点名称")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err := operator.Run(ctx, operator.Options{
+		Mode:           *mode,
+		Interval:       *interval,
+		ControlledNode: *node,
+		SensitiveNodes: conf.Config.K8s.SensitiveNodes,
+	})
+	if err != nil {
+		fmt.Println("[X] 控制器运行失败:", err.Error())
+		os.Exit(1)
+	}
+}
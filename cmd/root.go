@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/pkg/sshclient"
+	"os"
+)
+
+// subcommands 可用的子命令及说明
+var subcommands = []struct {
+	Name  string
+	Usage string
+	Run   func(args []string)
+}{
+	{"shell", "交互式命令行(不带子命令时默认进入)", func([]string) { Main() }},
+	{"scan", "扫描关键ServiceAccount并输出发现项", Scan},
+	{"ci", "CI门禁: 按策略评估发现项，违反策略时以非零状态退出", CI},
+	{"exploit", "使用关键SA执行指定的利用模块", Exploit},
+	{"harvest", "收集受控节点上全部Pod挂载的Token并按风险排序", Harvest},
+	{"loot", "查询和管理获取的Token(list|show|use|prune|delete)", Loot},
+	{"config", "查看或修改配置和命名配置(show|set|profiles|use|create|validate|save)", Config},
+	{"vault", "管理加密凭据库(init|list|set|import|delete|passwd|migrate)", Vault},
+	{"serve", "以HTTP JSON API提供扫描和查询", Serve},
+	{"daemon", "定时扫描并导出Prometheus指标", Daemon},
+	{"operator", "以控制器模式运行并写入PolicyReport", Operator},
+	{"webhook", "RBAC变更的准入控制服务", Webhook},
+	{"gui", "桌面前端", GUI},
+}
+
+// Execute 读取配置后根据命令行参数执行子命令，没有子命令时进入交互式命令行
+// 参数:
+//   - args: 命令行参数(不含程序名)，子命令前可以指定 --config 和 --profile
+func Execute(args []string) {
+	flags := flag.NewFlagSet("k8sEPDS", flag.ContinueOnError)
+	flags.Usage = usage
+	file := flags.String("config", "", "配置文件路径 (默认为环境变量 "+conf.EnvConfigFile+" 或 conf/conf.yaml)")
+	profile := flags.String("profile", "", "使用的命名配置 (默认为环境变量 "+conf.EnvProfile+" 或配置文件中的 profile)")
+	dryRun := flags.Bool("dry-run", false, "演练模式，变更请求只由API服务器(含准入控制)验证，不修改集群")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+	args = flags.Args()
+	if *dryRun {
+		// 与环境变量覆盖相同，不会写回配置文件
+		os.Setenv(conf.EnvName("k8s.dryRun"), "true")
+	}
+	defer sshclient.CloseAll()
+	if err := conf.Load(*file, *profile); err != nil {
+		fmt.Println("[X]", err.Error())
+		if *file != "" || *profile != "" {
+			os.Exit(2)
+		}
+	}
+
+	if len(args) == 0 {
+		Main()
+		return
+	}
+	switch args[0] {
+	case "help":
+		usage()
+		return
+	}
+	for _, subcommand := range subcommands {
+		if subcommand.Name == args[0] {
+			subcommand.Run(args[1:])
+			return
+		}
+	}
+	fmt.Println("[X] 未知的子命令:", args[0])
+	usage()
+	os.Exit(2)
+}
+
+// usage 打印子命令列表
+func usage() {
+	fmt.Println("用法: k8sEPDS [--config 配置文件] [--profile 命名配置] [--dry-run] <子命令> [参数]")
+	fmt.Println("\n子命令:")
+	for _, subcommand := range subcommands {
+		fmt.Printf("  %-10s - %s\n", subcommand.Name, subcommand.Usage)
+	}
+	fmt.Println("\n全局参数:")
+	fmt.Printf("  %-10s - 配置文件路径，默认为环境变量 %s 或 conf/conf.yaml\n", "--config", conf.EnvConfigFile)
+	fmt.Printf("  %-10s - 使用的命名配置，默认为环境变量 %s 或配置文件中的 profile\n", "--profile", conf.EnvProfile)
+	fmt.Printf("  %-10s - 演练模式，变更请求以 dryRun=All 发送，输出API服务器(含准入控制)是否接受以及变更后的对象，不修改集群\n", "--dry-run")
+	fmt.Printf("\n配置项可以通过环境变量覆盖，如 %s，覆盖的值不会写回配置文件\n", conf.EnvName("k8s.apiServer"))
+	fmt.Println("\n使用 k8sEPDS <子命令> -h 查看子命令的参数")
+}
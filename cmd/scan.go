@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/report"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/scan"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Scan 非交互式扫描，按条件过滤发现项后以文本或报告格式(json/sarif/csv/markdown/html)输出
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Scan(args []string) {
+	flags := flag.NewFlagSet("scan", flag.ExitOnError)
+	namespace := flags.String("namespace", "", "只输出该命名空间中SA的发现项")
+	permType := flags.String("type", "", "只输出该权限类型的发现项(如 createpods 或 createpods[default])")
+	severity := flags.String("severity", "", "只输出该严重程度的发现项(critical/high/medium/low)")
+	output := flags.String("output", "text", "输出格式: text、"+strings.Join(report.Formats(), "、"))
+	out := flags.String("out", "", "报告写入的文件，默认输出到标准输出")
+	node := flags.String("node", conf.Config.SSH.Nodename, "受控节点名称")
+	save := flags.Bool("save", false, "将扫描记录保存到扫描历史数据库")
+	allClusters := flags.Bool("all-clusters", false, "同时扫描配置文件中的全部集群，输出合并报告和跨集群差异")
+	allContexts := flags.Bool("all-contexts", false, "同时扫描kubeconfig中的全部上下文(可与 --all-clusters 同时使用)")
+	parallel := flags.Int("parallel", 8, "多集群扫描时同时扫描的集群数量")
+	concurrency := flags.Int("concurrency", scan.Concurrency, "每个集群同时发出的API请求数量")
+	pageSize := flags.Int64("page-size", request.PageSize, "分页列出资源时每页的数量，0表示不分页")
+	flags.Parse(args)
+	scan.Concurrency = *concurrency
+	request.PageSize = *pageSize
+	if _, ok := report.Writers[*output]; !ok && *output != "text" {
+		fmt.Println("[X] 不支持的输出格式:", *output)
+		os.Exit(2)
+	}
+	filter := func(record models.ScanRecord) models.ScanRecord {
+		return filterRecord(record, *namespace, *permType, *severity)
+	}
+	if *allClusters || *allContexts {
+		scanClusters(*allClusters, *allContexts, *parallel, *node, *save, filter, *output, *out)
+		return
+	}
+
+	_, criticalSAs, coverage, err := scan.ScanCluster(*node)
+	if err != nil {
+		fmt.Println("[X] 扫描失败:", err.Error())
+		os.Exit(1)
+	}
+	record := scan.NewScanRecord(criticalSAs, coverage, request.Cluster(), *node)
+	if *save {
+		saveScan(record)
+	}
+	record = filter(record)
+
+	if *output != "text" {
+		if err := writeReport(*out, *output, report.Build(record)); err != nil {
+			fmt.Println("[X] 写入报告失败:", err.Error())
+			os.Exit(1)
+		}
+		if *out != "" {
+			fmt.Println("[√] 报告已写入:", *out)
+		}
+		return
+	}
+	if len(record.Findings) == 0 {
+		fmt.Println("[√] 未发现关键ServiceAccount")
+		printCoverage(record.Coverage)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SA\t权限类型\t分类\t范围\t严重程度\t风险分\tPod\t节点")
+	for _, finding := range record.Findings {
+		sa := finding.SA
+		if finding.Incomplete {
+			sa += " (?)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", sa, finding.Type, finding.Kind, finding.Level, finding.Severity, finding.Score, finding.Pod, finding.Node)
+	}
+	w.Flush()
+	fmt.Printf("\n[msg] 关键SA %d 个，发现项 %d 个，风险分 %d\n", len(record.CriticalSAs), len(record.Findings), record.Score)
+	printCoverage(record.Coverage)
+}
+
+// printCoverage 输出扫描时无法读取的资源，扫描完整时不输出
+func printCoverage(coverage []models.CoverageGap) {
+	if len(coverage) == 0 {
+		return
+	}
+	fmt.Printf("\n[!] 扫描覆盖不完整，%d 项资源无法读取，标记 (?) 的SA权限可能不完整:\n", len(coverage))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  资源\t命名空间\t原因\t受影响的SA\t错误")
+	for _, gap := range coverage {
+		affected := strings.Join(gap.ServiceAccounts, ",")
+		if gap.Global {
+			affected = "全部"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", gap.Resource, gap.Namespace, gap.Reason, affected, gap.Message)
+	}
+	w.Flush()
+}
+
+// filterRecord 按条件过滤扫描记录的发现项，并重新计算关键SA和风险分
+func filterRecord(record models.ScanRecord, namespace string, permType string, severity string) models.ScanRecord {
+	record.Findings = scan.FilterFindings(record.Findings, namespace, permType, severity)
+	record.CriticalSAs = filterCriticalSAs(record.CriticalSAs, record.Findings)
+	record.Score = 0
+	for _, finding := range record.Findings {
+		record.Score += finding.Score
+	}
+	return record
+}
+
+// filterCriticalSAs 只保留仍有发现项的关键SA
+func filterCriticalSAs(criticalSAs []models.CriticalSA, findings []models.Finding) []models.CriticalSA {
+	names := map[string]bool{}
+	for _, finding := range findings {
+		names[finding.SA] = true
+	}
+	result := []models.CriticalSA{}
+	for _, criticalSA := range criticalSAs {
+		if names[criticalSA.SA0.Name] {
+			result = append(result, criticalSA)
+		}
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/server"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Serve 以HTTP JSON API的形式提供扫描、发现项查询和权限查询
+// 未指定TLS证书时只允许监听回环地址，避免访问令牌以明文传输，需要明文监听其他地址时使用 --insecure
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "127.0.0.1:8080", "API监听地址")
+	token := flags.String("token", os.Getenv("K8SEPDS_API_TOKEN"), "访问令牌，默认读取环境变量 K8SEPDS_API_TOKEN，均为空时随机生成")
+	certFile := flags.String("tls-cert", "", "TLS证书路径，为空时使用HTTP(只允许监听回环地址)")
+	keyFile := flags.String("tls-key", "", "TLS私钥路径")
+	insecure := flags.Bool("insecure", false, "允许不使用TLS监听非回环地址，访问令牌将以明文传输")
+	node := flags.String("node", conf.Config.SSH.Nodename, "受控节点名称")
+	historyPath := flags.String("history", historyDBPath, "扫描历史数据库路径，为空时不保存扫描记录")
+	flags.Parse(args)
+
+	if (*certFile == "") != (*keyFile == "") {
+		fmt.Println("[X] --tls-cert 和 --tls-key 需要同时指定")
+		os.Exit(2)
+	}
+	if *certFile == "" && !*insecure && !isLoopback(*addr) {
+		fmt.Printf("[X] 未指定TLS证书时只允许监听回环地址，%s 会以明文传输访问令牌，请指定 --tls-cert/--tls-key 或使用 --insecure\n", *addr)
+		os.Exit(2)
+	}
+	if *certFile == "" && !isLoopback(*addr) {
+		fmt.Println("[!] 未使用TLS，访问令牌将以明文传输")
+	}
+
+	if *token == "" {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			fmt.Println("[X] 生成访问令牌失败:", err.Error())
+			os.Exit(1)
+		}
+		*token = hex.EncodeToString(buf)
+		fmt.Println("[msg] 未指定访问令牌，已随机生成:", *token)
+	}
+
+	if *historyPath != "" {
+		// 服务每次保存扫描记录时才打开数据库，启动时先导入旧版本的扫描记录
+		if db, err := openHistory(*historyPath); err != nil {
+			fmt.Println("[X]", err.Error())
+		} else {
+			db.Close()
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	api := server.NewServer(server.Options{
+		Token:          *token,
+		Cluster:        request.Cluster(),
+		ControlledNode: *node,
+		HistoryPath:    *historyPath,
+	}, nil)
+	go func() {
+		record, err := api.Scan()
+		if err != nil {
+			fmt.Println("[X] 初始扫描失败:", err.Error())
+			return
+		}
+		fmt.Printf("[√] 初始扫描完成，关键SA %d 个\n", len(record.CriticalSAs))
+	}()
+
+	httpServer := &http.Server{Addr: *addr, Handler: api.Handler()}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+	fmt.Println("[msg] API服务监听", *addr)
+	var err error
+	if *certFile != "" {
+		err = httpServer.ListenAndServeTLS(*certFile, *keyFile)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		fmt.Println("[X] API服务运行失败:", err.Error())
+		os.Exit(1)
+	}
+}
+
+// isLoopback 判断监听地址是否只在回环地址上监听，主机为空(监听全部地址)时返回 false
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
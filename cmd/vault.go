@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/pkg/vault"
+	"os"
+	"strings"
+)
+
+// Vault 管理加密凭据库，配置项中以 vault:名称 引用凭据库中的凭据
+// 用法: k8sEPDS vault init|list|set|import|delete|passwd|migrate
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Vault(args []string) {
+	if len(args) == 0 {
+		vaultUsage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "init":
+		initVault()
+	case "list":
+		store := unlockVault()
+		fmt.Println("[msg] 凭据库:", store.Path())
+		for _, name := range store.Names() {
+			fmt.Printf("  %-30s 更新于 %s\n", vault.Ref(name), store.Updated(name).Format("2006-01-02 15:04:05"))
+		}
+	case "set":
+		if len(args) != 2 {
+			vaultUsage()
+			os.Exit(2)
+		}
+		secret, err := vault.ReadSecret("[输入] " + args[1] + ": ")
+		if err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		storeSecret(unlockVault(), args[1], secret)
+	case "import":
+		if len(args) != 3 {
+			vaultUsage()
+			os.Exit(2)
+		}
+		data, err := os.ReadFile(args[2])
+		if err != nil {
+			fmt.Println("[X] 读取文件失败:", err.Error())
+			os.Exit(1)
+		}
+		storeSecret(unlockVault(), args[1], strings.TrimSpace(string(data)))
+		fmt.Println("[msg] 请删除明文文件:", args[2])
+	case "delete":
+		if len(args) != 2 {
+			vaultUsage()
+			os.Exit(2)
+		}
+		store := unlockVault()
+		if err := store.Delete(args[1]); err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		saveVault(store)
+		fmt.Println("[√] 已删除凭据:", args[1])
+	case "passwd":
+		store := unlockVault()
+		passphrase := newPassphrase()
+		if err := store.ChangePassphrase(passphrase); err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("[√] 凭据库口令已更换")
+	case "migrate":
+		migrateSecrets(unlockVault())
+	default:
+		fmt.Println("[X] 未知的操作:", args[0])
+		vaultUsage()
+		os.Exit(2)
+	}
+}
+
+// initVault 新建凭据库
+func initVault() {
+	store, err := vault.Create(vault.DefaultFile(), newPassphrase())
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	vault.SetSession(store)
+	fmt.Println("[√] 凭据库已创建:", store.Path())
+}
+
+// newPassphrase 读取新的凭据库口令，从终端输入时需要输入两次确认
+func newPassphrase() string {
+	if passphrase, ok := os.LookupEnv(vault.EnvPassphrase); ok {
+		return passphrase
+	}
+	passphrase, err := vault.Passphrase("[输入] 新的凭据库口令: ")
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	confirm, err := vault.Passphrase("[输入] 再次输入口令: ")
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	if passphrase != confirm {
+		fmt.Println("[X] 两次输入的口令不一致")
+		os.Exit(1)
+	}
+	return passphrase
+}
+
+// unlockVault 解锁凭据库，失败时退出
+func unlockVault() *vault.Store {
+	store, err := vault.Unlock()
+	if err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	return store
+}
+
+// storeSecret 保存凭据并输出配置中引用该凭据的写法
+func storeSecret(store *vault.Store, name string, secret string) {
+	if err := store.Set(name, secret); err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	saveVault(store)
+	fmt.Printf("[√] 已保存凭据 %s，在配置中使用 %s 引用\n", name, vault.Ref(name))
+}
+
+// saveVault 将凭据库写回文件，失败时退出
+func saveVault(store *vault.Store) {
+	if err := store.Save(); err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+}
+
+// migrateSecrets 将当前配置中的明文SSH密码、SSH私钥和Token文件移入凭据库，并将配置项改为凭据库引用
+// 凭据名称为 配置名称-ssh-password、配置名称-ssh-key、配置名称-token
+func migrateSecrets(store *vault.Store) {
+	fields := map[string]conf.Field{}
+	for _, field := range conf.Fields() {
+		fields[field.Key] = field
+	}
+	profile := conf.ProfileName()
+	migrations := []struct {
+		key  string
+		name string
+		file bool // 配置项是文件路径，凭据为文件内容
+	}{
+		{"ssh.password", profile + "-ssh-password", false},
+		{"ssh.passphrase", profile + "-ssh-passphrase", false},
+		{"ssh.privateKeyFile", profile + "-ssh-key", true},
+		{"k8s.tokenFile", profile + "-token", true},
+	}
+	files := []string{}
+	for _, migration := range migrations {
+		field := fields[migration.key]
+		value := field.Get()
+		if value == "" || vault.IsRef(value) {
+			continue
+		}
+		if migration.file {
+			data, err := os.ReadFile(value)
+			if err != nil {
+				fmt.Printf("[X] %s: %s\n", migration.key, err.Error())
+				continue
+			}
+			files = append(files, value)
+			value = string(data)
+			if migration.key == "k8s.tokenFile" {
+				value = strings.TrimSpace(value)
+			}
+		}
+		if err := store.Set(migration.name, value); err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		field.Set(vault.Ref(migration.name))
+		fmt.Printf("[√] %s -> %s\n", migration.key, vault.Ref(migration.name))
+	}
+	saveVault(store)
+	if err := conf.Save(); err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("[√] 配置已保存:", conf.ConfigFile())
+	for _, file := range files {
+		fmt.Println("[msg] 请删除明文文件:", file)
+	}
+}
+
+// vaultUsage 打印 vault 子命令的用法
+func vaultUsage() {
+	fmt.Println("用法: k8sEPDS vault init                      新建凭据库")
+	fmt.Println("      k8sEPDS vault list                      列出凭据名称(不显示凭据的值)")
+	fmt.Println("      k8sEPDS vault set 名称                  保存凭据，从终端输入或从标准输入读取")
+	fmt.Println("      k8sEPDS vault import 名称 文件          将文件内容(如Token、私钥)保存为凭据")
+	fmt.Println("      k8sEPDS vault delete 名称               删除凭据")
+	fmt.Println("      k8sEPDS vault passwd                    更换凭据库口令")
+	fmt.Println("      k8sEPDS vault migrate                   将当前配置中的明文密码、私钥和Token移入凭据库")
+	fmt.Println("\n凭据库:", vault.DefaultFile(), "(环境变量", vault.EnvFile+")")
+	fmt.Println("口令: 每次运行首次使用凭据时输入，也可以通过环境变量", vault.EnvPassphrase, "提供")
+	fmt.Println("配置中引用凭据: ssh.password、ssh.privateKeyFile、k8s.tokenFile 设置为", vault.Ref("名称"))
+}
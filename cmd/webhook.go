@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"k8sEPDS/pkg/admission"
+	"k8sEPDS/pkg/request"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	admissionV1 "k8s.io/api/admission/v1"
+)
+
+// Webhook 运行RBAC变更的准入控制服务
+// 指定 --review 时不启动服务，只评估AdmissionReview文件并输出响应，便于离线验证
+// 参数:
+//   - args: 命令行参数(不含子命令名称)
+func Webhook(args []string) {
+	flags := flag.NewFlagSet("webhook", flag.ExitOnError)
+	addr := flags.String("addr", ":8443", "HTTPS监听地址")
+	certFile := flags.String("tls-cert", "", "TLS证书路径")
+	keyFile := flags.String("tls-key", "", "TLS私钥路径")
+	mode := flags.String("mode", admission.ModeEnforce, "执行模式: enforce、warn 或 dryrun")
+	failurePolicy := flags.String("failure-policy", admission.FailurePolicyFail, "enforce模式下评估失败时: fail 拒绝变更，ignore 放行并返回警告")
+	exempt := flags.String("exempt-namespaces", "kube-system", "豁免的命名空间，多个用逗号分隔，这些命名空间中的变更不做检查")
+	exemptSAs := flags.Bool("exempt-sa-namespaces", false, "同时豁免豁免命名空间中的SA，其他命名空间和集群范围的绑定授予这些SA的权限也不做检查")
+	stateFile := flags.String("state", "", "离线评估时使用的RBAC状态文件(kubectl get -o json 导出的List)")
+	reviewFile := flags.String("review", "", "离线评估的AdmissionReview文件，需要同时指定 --state")
+	flags.Parse(args)
+
+	config := admission.Config{Mode: *mode, FailurePolicy: *failurePolicy, ExemptSANamespaces: *exemptSAs}
+	for _, namespace := range strings.Split(*exempt, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			config.ExemptNamespaces = append(config.ExemptNamespaces, namespace)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		fmt.Println("[X]", err.Error())
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var source admission.StateSource
+	if *stateFile != "" {
+		state, err := admission.LoadState(*stateFile)
+		if err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		source = state
+	} else if *reviewFile != "" {
+		// 空的集群状态会把所有已有绑定都当作新增，评估结果没有意义
+		fmt.Println("[X] 离线评估需要使用 --state 指定集群的RBAC状态(kubectl get roles,clusterroles,rolebindings,clusterrolebindings -A -o json)")
+		os.Exit(2)
+	} else {
+		clientset, err := request.GetClientSet("")
+		if err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		state, err := admission.NewInformerState(ctx, clientset)
+		if err != nil {
+			fmt.Println("[X]", err.Error())
+			os.Exit(1)
+		}
+		source = state
+	}
+	webhook := admission.NewWebhook(config, source)
+
+	if *reviewFile != "" {
+		data, err := os.ReadFile(*reviewFile)
+		if err != nil {
+			fmt.Println("[X] 读取AdmissionReview失败:", err.Error())
+			os.Exit(1)
+		}
+		var review admissionV1.AdmissionReview
+		if err := json.Unmarshal(data, &review); err != nil || review.Request == nil {
+			fmt.Println("[X] 无效的AdmissionReview")
+			os.Exit(1)
+		}
+		out, _ := json.MarshalIndent(webhook.Review(review), "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/validate", webhook)
+	server := &http.Server{Addr: *addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	fmt.Println("[msg] 准入控制服务监听", *addr, "模式:", config.Mode, "评估失败时:", config.FailurePolicy)
+	if err := server.ListenAndServeTLS(*certFile, *keyFile); err != nil && err != http.ErrServerClosed {
+		fmt.Println("[X] 准入控制服务运行失败:", err.Error())
+		os.Exit(1)
+	}
+}
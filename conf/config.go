@@ -11,87 +11,365 @@ import (
 	"fmt"
 	"io"
 	"k8sEPDS/models"
+	"k8sEPDS/pkg/vault"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
 )
 
 var Config models.K8sEPDSConfig
 
-// updateStringField 更新字符串类型的配置字段
-// 参数:
-//   - prompt: 提示信息
-//   - currentValue: 当前值
-//
-// 返回:
-//   - string: 更新后的值，如果用户未输入则返回当前值
-func updateStringField(prompt string, currentValue string) string {
-	var input string
-	fmt.Printf("%s (当前值: %s): ", prompt, currentValue)
-	if _, err := fmt.Scanln(&input); err != nil && err != io.EOF {
-		return currentValue
-	}
-	if input != "" {
-		return input
-	}
-	return currentValue
+// TokenSources 支持的Token读取方式，未设置时使用ssh
+// ssh: 通过SSH登录受控节点读取kubelet目录中的Token
+// local: 在受控节点上运行时直接读取本地文件
+// exec: 使用当前配置的凭据通过 pods/exec 在Pod中读取
+// cri: 通过容器运行时(containerd)的CRI socket在容器中读取
+var TokenSources = []string{"ssh", "local", "exec", "cri"}
+
+// HostKeyPolicies 支持的SSH主机密钥校验方式，未设置时使用tofu
+// tofu: 首次连接时信任主机密钥并记录到 known_hosts，之后密钥变化时拒绝连接
+// strict: 只允许 known_hosts 中记录的主机
+// insecure: 不校验主机密钥
+var HostKeyPolicies = []string{"tofu", "strict", "insecure"}
+
+// Clusters 配置文件中的全部集群，第一项与 Config.K8s 相同，多集群扫描时使用
+var Clusters []models.K8SConfig
+
+// Field 可编辑的配置项
+type Field struct {
+	Key     string                   // 配置文件中的键(如 k8s.apiServer)，用于 config set
+	Section string                   // 所属配置(K8S/SSH)
+	Label   string                   // 配置项名称
+	Secret  bool                     // 是否为敏感信息(显示时掩码)
+	Get     func() string            // 读取当前值
+	Set     func(value string) error // 设置新值
 }
 
-// updateIntField 更新整数类型的配置字段
-// 参数:
-//   - prompt: 提示信息
-//   - currentValue: 当前值
-//
-// 返回:
-//   - int: 更新后的值，如果用户输入无效则返回当前值
-func updateIntField(prompt string, currentValue int) int {
-	var input string
-	fmt.Printf("%s (当前值: %d): ", prompt, currentValue)
-	if _, err := fmt.Scanln(&input); err != nil && err != io.EOF {
-		return currentValue
-	}
-	if input != "" {
-		if val, err := strconv.Atoi(input); err == nil {
-			return val
+// Fields 返回所有可编辑的配置项，控制台和GUI的配置编辑共用
+func Fields() []Field {
+	stringField := func(key string, label string, secret bool, value *string) Field {
+		section, _, _ := strings.Cut(key, ".")
+		return Field{
+			Key:     key,
+			Section: strings.ToUpper(section),
+			Label:   label,
+			Secret:  secret,
+			Get:     func() string { return *value },
+			Set: func(input string) error {
+				*value = input
+				return nil
+			},
 		}
-		fmt.Println("输入的不是有效的数字，保持原值")
 	}
-	return currentValue
+	return []Field{
+		stringField("k8s.name", "集群名称", false, &Config.K8s.Name),
+		stringField("k8s.apiServer", "Kubernetes API Server地址", false, &Config.K8s.ApiServer),
+		stringField("k8s.proxyAddress", "Kubernetes 代理地址", false, &Config.K8s.ProxyAddress),
+		stringField("k8s.tokenFile", "Token文件路径", false, &Config.K8s.TokenFile),
+		stringField("k8s.kubeconfig", "Kubeconfig文件路径", false, &Config.K8s.Kubeconfig),
+		stringField("k8s.context", "Kubeconfig上下文", false, &Config.K8s.Context),
+		stringField("k8s.crt", "管理员证书路径", false, &Config.K8s.AdminCert),
+		stringField("k8s.key", "管理员证书密钥路径", false, &Config.K8s.AdminCertKey),
+		stringField("k8s.caFile", "API Server CA证书路径", false, &Config.K8s.CAFile),
+		{
+			Key:     "k8s.insecure",
+			Section: "K8S",
+			Label:   "跳过证书验证(true/false)",
+			Get:     func() string { return strconv.FormatBool(Config.K8s.Insecure) },
+			Set: func(input string) error {
+				val, err := strconv.ParseBool(input)
+				if err != nil {
+					return fmt.Errorf("输入的不是有效的布尔值")
+				}
+				Config.K8s.Insecure = val
+				return nil
+			},
+		},
+		{
+			Key:     "k8s.protobuf",
+			Section: "K8S",
+			Label:   "使用protobuf编码(true/false)",
+			Get:     func() string { return strconv.FormatBool(Config.K8s.Protobuf) },
+			Set: func(input string) error {
+				val, err := strconv.ParseBool(input)
+				if err != nil {
+					return fmt.Errorf("输入的不是有效的布尔值")
+				}
+				Config.K8s.Protobuf = val
+				return nil
+			},
+		},
+		{
+			Key:     "k8s.dryRun",
+			Section: "K8S",
+			Label:   "演练模式，变更请求只验证不执行(true/false)",
+			Get:     func() string { return strconv.FormatBool(Config.K8s.DryRun) },
+			Set: func(input string) error {
+				val, err := strconv.ParseBool(input)
+				if err != nil {
+					return fmt.Errorf("输入的不是有效的布尔值")
+				}
+				Config.K8s.DryRun = val
+				return nil
+			},
+		},
+		{
+			Key:     "k8s.sensitiveNodes",
+			Section: "K8S",
+			Label:   "敏感节点(逗号分隔)",
+			Get:     func() string { return strings.Join(Config.K8s.SensitiveNodes, ",") },
+			Set: func(input string) error {
+				nodes := []string{}
+				for _, node := range strings.Split(input, ",") {
+					if node = strings.TrimSpace(node); node != "" {
+						nodes = append(nodes, node)
+					}
+				}
+				Config.K8s.SensitiveNodes = nodes
+				return nil
+			},
+		},
+		stringField("ssh.host", "SSH 主机地址", false, &Config.SSH.Host),
+		stringField("ssh.username", "SSH 用户名", false, &Config.SSH.Username),
+		stringField("ssh.password", "SSH 密码", true, &Config.SSH.Password),
+		{
+			Key:     "ssh.port",
+			Section: "SSH",
+			Label:   "SSH 端口",
+			Get:     func() string { return strconv.Itoa(Config.SSH.Port) },
+			Set: func(input string) error {
+				val, err := strconv.Atoi(input)
+				if err != nil {
+					return fmt.Errorf("输入的不是有效的数字")
+				}
+				Config.SSH.Port = val
+				return nil
+			},
+		},
+		stringField("ssh.privateKeyFile", "SSH 私钥地址", false, &Config.SSH.PrivateKeyFile),
+		stringField("ssh.passphrase", "SSH 私钥口令", true, &Config.SSH.Passphrase),
+		{
+			Key:     "ssh.agent",
+			Section: "SSH",
+			Label:   "使用ssh-agent认证(true/false)",
+			Get:     func() string { return strconv.FormatBool(Config.SSH.Agent) },
+			Set: func(input string) error {
+				val, err := strconv.ParseBool(input)
+				if err != nil {
+					return fmt.Errorf("输入的不是有效的布尔值")
+				}
+				Config.SSH.Agent = val
+				return nil
+			},
+		},
+		stringField("ssh.knownHosts", "known_hosts 文件路径", false, &Config.SSH.KnownHosts),
+		{
+			Key:     "ssh.hostKeyPolicy",
+			Section: "SSH",
+			Label:   "主机密钥校验方式(" + strings.Join(HostKeyPolicies, "/") + ")",
+			Get:     func() string { return Config.SSH.HostKeyPolicy },
+			Set: func(input string) error {
+				if input != "" && !slices.Contains(HostKeyPolicies, input) {
+					return fmt.Errorf("不支持的主机密钥校验方式: %s", input)
+				}
+				Config.SSH.HostKeyPolicy = input
+				return nil
+			},
+		},
+		stringField("ssh.proxyJump", "跳板机([user@]host[:port]，逗号分隔)", false, &Config.SSH.ProxyJump),
+		stringField("ssh.nodeName", "目标主机节点名称", false, &Config.SSH.Nodename),
+		{
+			Key:     "ssh.tokenSource",
+			Section: "SSH",
+			Label:   "Token读取方式(" + strings.Join(TokenSources, "/") + ")",
+			Get:     func() string { return Config.SSH.TokenSource },
+			Set: func(input string) error {
+				if input != "" && !slices.Contains(TokenSources, input) {
+					return fmt.Errorf("不支持的Token读取方式: %s", input)
+				}
+				Config.SSH.TokenSource = input
+				return nil
+			},
+		},
+		stringField("ssh.kubeletRoot", "kubelet数据目录", false, &Config.SSH.KubeletRoot),
+		stringField("ssh.criSocket", "容器运行时socket路径", false, &Config.SSH.CriSocket),
+	}
 }
 
 // UpdateConfig 更新系统配置信息
 // 交互式更新 K8s 和 SSH 的配置项
 // 包括 API 服务器地址、代理地址、认证信息和 SSH 连接信息
 func UpdateConfig() {
-	// 更新基本配置
-	fmt.Println("\n=== K8S 配置更新 ===")
-	Config.K8s.ApiServer = updateStringField("Kubernetes API Server地址", Config.K8s.ApiServer)
-	Config.K8s.ProxyAddress = updateStringField("Kubernetes 代理地址", Config.K8s.ProxyAddress)
-	Config.K8s.TokenFile = updateStringField("Token文件路径", Config.K8s.TokenFile)
-	Config.K8s.Kubeconfig = updateStringField("Kubeconfig文件路径", Config.K8s.Kubeconfig)
-	Config.K8s.AdminCert = updateStringField("管理员证书路径", Config.K8s.AdminCert)
-	Config.K8s.AdminCertKey = updateStringField("管理员证书密钥路径", Config.K8s.AdminCertKey)
-	// 更新SSH配置
-	fmt.Println("\n=== SSH 配置更新 ===")
-	Config.SSH.Host = updateStringField("SSH 主机地址", Config.SSH.Host)
-	Config.SSH.Username = updateStringField("SSH 用户名", Config.SSH.Username)
-	Config.SSH.Password = updateStringField("SSH 密码", Config.SSH.Password)
-	Config.SSH.Port = updateIntField("SSH 端口", Config.SSH.Port)
-	Config.SSH.PrivateKeyFile = updateStringField("SSH 私钥地址", Config.SSH.PrivateKeyFile)
-	Config.SSH.Nodename = updateStringField("目标主机节点名称", Config.SSH.Nodename)
+	section := ""
+	for _, field := range Fields() {
+		if field.Section != section {
+			section = field.Section
+			fmt.Printf("\n=== %s 配置更新 ===\n", section)
+		}
+		current := field.Get()
+		if field.Secret {
+			current = maskPassword(current)
+		}
+		var input string
+		fmt.Printf("%s (当前值: %s): ", field.Label, current)
+		if _, err := fmt.Scanln(&input); err != nil && err != io.EOF {
+			continue
+		}
+		if input == "" {
+			continue
+		}
+		if err := field.Set(input); err != nil {
+			fmt.Println(err.Error() + "，保持原值")
+		}
+	}
 	// 验证配置
-	if err := validateConfig(Config); err != nil {
+	if err := ValidateConfig(Config); err != nil {
 		fmt.Printf("配置验证失败: %v\n", err)
 	}
 }
 
-// validateConfig 验证配置信息的有效性
+// Save 将当前配置写回读取配置时使用的配置文件
+// 当前配置写入正在使用的命名配置(或顶层 k8s/ssh 的第一项)，环境变量覆盖的值不会写入文件
+// 敏感配置项不以明文写入文件，而是存入凭据库并写入凭据库引用
+func Save() error {
+	if err := vaultSecrets(); err != nil {
+		return err
+	}
+	store(withoutEnv())
+	clusters := []map[string]interface{}{}
+	for _, cluster := range Clusters {
+		clusters = append(clusters, k8sEntry(cluster))
+	}
+	viper.Set("k8s", clusters)
+	sshList := []map[string]interface{}{}
+	for _, ssh := range sshEntries {
+		sshList = append(sshList, sshEntry(ssh))
+	}
+	viper.Set("ssh", sshList)
+	if len(Profiles) > 0 {
+		profiles := []map[string]interface{}{}
+		for _, profile := range Profiles {
+			profiles = append(profiles, map[string]interface{}{
+				"name": profile.Name,
+				"k8s":  k8sEntry(profile.K8s),
+				"ssh":  sshEntry(profile.SSH),
+			})
+		}
+		viper.Set("profiles", profiles)
+	}
+	if Profile != "" || viper.IsSet("profile") {
+		viper.Set("profile", ProfileName())
+	}
+	if err := viper.WriteConfig(); err != nil {
+		return fmt.Errorf("保存配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// vaultSecrets 将当前配置中明文的敏感配置项存入凭据库，并将配置项改为凭据库引用
+// 凭据名称为 配置名称-配置键(如 prod-ssh-password)，与 vault migrate 相同；来自环境变量的值不写入文件，不做处理
+func vaultSecrets() error {
+	var store *vault.Store
+	refs := map[string]string{} // 配置键 -> 凭据名称
+	fields := Fields()
+	for _, field := range fields {
+		value := field.Get()
+		if !field.Secret || value == "" || vault.IsRef(value) {
+			continue
+		}
+		if override, ok := envOverrides[field.Key]; ok && value == override.value {
+			continue
+		}
+		if store == nil {
+			var err error
+			if store, err = vault.Unlock(); err != nil {
+				return fmt.Errorf("%s 不能以明文保存，需要存入凭据库: %w", field.Key, err)
+			}
+		}
+		name := ProfileName() + "-" + strings.ReplaceAll(field.Key, ".", "-")
+		if err := store.Set(name, value); err != nil {
+			return err
+		}
+		refs[field.Key] = name
+	}
+	if store == nil {
+		return nil
+	}
+	// 凭据库写入成功后才修改配置项，避免引用不存在的凭据
+	if err := store.Save(); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if name, exists := refs[field.Key]; exists {
+			field.Set(vault.Ref(name))
+		}
+	}
+	return nil
+}
+
+// k8sEntry 将集群配置转换为配置文件中的一项，未设置名称时不写入名称
+func k8sEntry(k8s models.K8SConfig) map[string]interface{} {
+	entry := map[string]interface{}{
+		"apiServer":      k8s.ApiServer,
+		"proxyAddress":   k8s.ProxyAddress,
+		"tokenFile":      k8s.TokenFile,
+		"kubeconfig":     k8s.Kubeconfig,
+		"context":        k8s.Context,
+		"crt":            k8s.AdminCert,
+		"key":            k8s.AdminCertKey,
+		"caFile":         k8s.CAFile,
+		"insecure":       k8s.Insecure,
+		"protobuf":       k8s.Protobuf,
+		"dryRun":         k8s.DryRun,
+		"sensitiveNodes": k8s.SensitiveNodes,
+	}
+	if k8s.Name != "" {
+		entry["name"] = k8s.Name
+	}
+	return entry
+}
+
+// sshEntry 将SSH配置转换为配置文件中的一项
+func sshEntry(ssh models.SSHConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"host":           ssh.Host,
+		"port":           ssh.Port,
+		"username":       ssh.Username,
+		"password":       ssh.Password,
+		"privateKeyFile": ssh.PrivateKeyFile,
+		"passphrase":     ssh.Passphrase,
+		"agent":          ssh.Agent,
+		"knownHosts":     ssh.KnownHosts,
+		"hostKeyPolicy":  ssh.HostKeyPolicy,
+		"proxyJump":      ssh.ProxyJump,
+		"nodeName":       ssh.Nodename,
+		"tokenSource":    ssh.TokenSource,
+		"kubeletRoot":    ssh.KubeletRoot,
+		"criSocket":      ssh.CriSocket,
+	}
+}
+
+// ValidateConfig 验证配置信息的有效性
 // 参数:
 //   - config: K8sEPDSConfig 类型的配置对象
 //
 // 返回:
 //   - error: 如果配置无效返回错误信息，否则返回 nil
-func validateConfig(config models.K8sEPDSConfig) error {
-	if config.K8s.ApiServer == "" {
-		return fmt.Errorf("API Server 地址不能为空")
+func ValidateConfig(config models.K8sEPDSConfig) error {
+	if config.K8s.ApiServer == "" && config.K8s.Kubeconfig == "" && os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return fmt.Errorf("API Server 地址和 Kubeconfig 不能同时为空")
+	}
+
+	if config.SSH.TokenSource != "" && !slices.Contains(TokenSources, config.SSH.TokenSource) {
+		return fmt.Errorf("不支持的Token读取方式: %s", config.SSH.TokenSource)
+	}
+
+	// 不通过SSH读取Token时不需要SSH连接信息
+	if config.SSH.TokenSource != "" && config.SSH.TokenSource != "ssh" {
+		return nil
 	}
 
 	if config.SSH.Port <= 0 || config.SSH.Port > 65535 {
@@ -102,19 +380,39 @@ func validateConfig(config models.K8sEPDSConfig) error {
 		return fmt.Errorf("SSH 主机地址不能为空")
 	}
 
+	if config.SSH.HostKeyPolicy != "" && !slices.Contains(HostKeyPolicies, config.SSH.HostKeyPolicy) {
+		return fmt.Errorf("不支持的主机密钥校验方式: %s", config.SSH.HostKeyPolicy)
+	}
+
 	return nil
 }
 
 // GetConfig 打印当前配置信息
 // 显示所有 K8s 和 SSH 相关的配置项当前值
 func GetConfig() {
+	fmt.Println("\n=== 配置文件 ===")
+	printConfigItem("配置文件", ConfigFile())
+	printConfigItem("命名配置", ProfileName())
+	for _, field := range Fields() {
+		if _, ok := envOverrides[field.Key]; ok {
+			printConfigItem("环境变量覆盖", EnvName(field.Key))
+		}
+	}
+
 	fmt.Println("\n=== Kubernetes 配置 ===")
+	printConfigItem("集群名称", Config.K8s.Name)
 	printConfigItem("API Server", Config.K8s.ApiServer)
 	printConfigItem("代理地址", Config.K8s.ProxyAddress)
 	printConfigItem("Token 文件地址", Config.K8s.TokenFile)
 	printConfigItem("Kubeconfig", Config.K8s.Kubeconfig)
+	printConfigItem("Kubeconfig上下文", Config.K8s.Context)
 	printConfigItem("管理员证书地址", Config.K8s.AdminCert)
 	printConfigItem("证书密钥地址", Config.K8s.AdminCertKey)
+	printConfigItem("CA证书地址", Config.K8s.CAFile)
+	printConfigItem("跳过证书验证", strconv.FormatBool(Config.K8s.Insecure))
+	printConfigItem("protobuf编码", strconv.FormatBool(Config.K8s.Protobuf))
+	printConfigItem("演练模式", strconv.FormatBool(Config.K8s.DryRun))
+	printConfigItem("敏感节点", strings.Join(Config.K8s.SensitiveNodes, ","))
 
 	fmt.Println("\n=== SSH 配置 ===")
 	printConfigItem("主机地址", Config.SSH.Host)
@@ -122,7 +420,31 @@ func GetConfig() {
 	printConfigItem("用户名", Config.SSH.Username)
 	printConfigItem("密码", maskPassword(Config.SSH.Password))
 	printConfigItem("私钥文件地址", Config.SSH.PrivateKeyFile)
+	printConfigItem("私钥口令", maskPassword(Config.SSH.Passphrase))
+	printConfigItem("ssh-agent", strconv.FormatBool(Config.SSH.Agent))
+	printConfigItem("known_hosts", Config.SSH.KnownHosts)
+	printConfigItem("主机密钥校验", hostKeyPolicyName(Config.SSH.HostKeyPolicy))
+	printConfigItem("跳板机", Config.SSH.ProxyJump)
 	printConfigItem("节点名称", Config.SSH.Nodename)
+	printConfigItem("Token读取方式", tokenSourceName(Config.SSH.TokenSource))
+	printConfigItem("kubelet数据目录", Config.SSH.KubeletRoot)
+	printConfigItem("运行时socket", Config.SSH.CriSocket)
+}
+
+// hostKeyPolicyName 主机密钥校验方式的显示名称，未设置时为默认的tofu
+func hostKeyPolicyName(policy string) string {
+	if policy == "" {
+		return "tofu (默认)"
+	}
+	return policy
+}
+
+// tokenSourceName Token读取方式的显示名称，未设置时为默认的ssh
+func tokenSourceName(source string) string {
+	if source == "" {
+		return "ssh (默认)"
+	}
+	return source
 }
 
 // printConfigItem 打印配置项
@@ -130,10 +452,10 @@ func printConfigItem(name, value string) {
     fmt.Printf("%-15s: %s\n", name, value)
 }
 
-// maskPassword 对密码进行掩码处理
+// maskPassword 对密码进行掩码处理，凭据库引用不是敏感信息，原样显示
 func maskPassword(password string) string {
-    if password == "" {
-        return ""
+    if password == "" || vault.IsRef(password) {
+        return password
     }
     return "********"
 }
\ No newline at end of file
@@ -0,0 +1,281 @@
+package conf
+
+import (
+	"fmt"
+	"k8sEPDS/models"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultProfile 不使用命名配置时的配置名称，对应配置文件顶层的 k8s/ssh
+const DefaultProfile = "default"
+
+// 环境变量
+const (
+	EnvConfigFile = "K8SEPDS_CONFIG"  // 配置文件路径
+	EnvProfile    = "K8SEPDS_PROFILE" // 使用的命名配置
+	envPrefix     = "K8SEPDS_"        // 配置项的环境变量前缀，如 K8SEPDS_K8S_APISERVER、K8SEPDS_SSH_PASSWORD
+)
+
+var (
+	// Profiles 配置文件中的命名配置，每个配置包含一个集群及其SSH/受控节点信息
+	Profiles []models.Profile
+	// Profile 当前使用的命名配置，为空时使用顶层的 k8s/ssh
+	Profile string
+
+	sshEntries   []models.SSHConfig // 配置文件顶层的ssh列表
+	envOverrides = map[string]envOverride{}
+)
+
+// envOverride 环境变量覆盖的配置项
+type envOverride struct {
+	value    string // 环境变量的值
+	original string // 配置文件中的值，保存配置时写回该值，避免把环境变量中的密码等写入文件
+}
+
+// DefaultConfigFile 默认的配置文件路径: 环境变量 K8SEPDS_CONFIG，否则为 conf/conf.yaml
+func DefaultConfigFile() string {
+	if file := os.Getenv(EnvConfigFile); file != "" {
+		return file
+	}
+	return filepath.Join("conf", "conf.yaml")
+}
+
+// Load 读取配置文件，选择命名配置并应用环境变量覆盖
+// 参数:
+//   - file: 配置文件路径，为空时使用 DefaultConfigFile
+//   - profile: 使用的命名配置，为空时依次使用环境变量 K8SEPDS_PROFILE 和配置文件中的 profile
+func Load(file string, profile string) error {
+	if file == "" {
+		file = DefaultConfigFile()
+	}
+	viper.SetConfigFile(file)
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	Clusters, sshEntries, Profiles = nil, nil, nil
+	if err := viper.UnmarshalKey("k8s", &Clusters); err != nil {
+		return fmt.Errorf("解析 K8s 配置失败: %w", err)
+	}
+	if err := viper.UnmarshalKey("ssh", &sshEntries); err != nil {
+		return fmt.Errorf("解析 SSH 配置失败: %w", err)
+	}
+	if err := viper.UnmarshalKey("profiles", &Profiles); err != nil {
+		return fmt.Errorf("解析命名配置失败: %w", err)
+	}
+	Config = models.K8sEPDSConfig{}
+	if len(Clusters) > 0 {
+		Config.K8s = Clusters[0]
+	}
+	if len(sshEntries) > 0 {
+		Config.SSH = sshEntries[0]
+	}
+
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	if profile == "" {
+		profile = viper.GetString("profile")
+	}
+	Profile = ""
+	if profile != "" && profile != DefaultProfile {
+		p, ok := findProfile(profile)
+		if !ok {
+			return fmt.Errorf("未找到命名配置: %s", profile)
+		}
+		Profile = p.Name
+		Config.K8s, Config.SSH = p.K8s, p.SSH
+	}
+	applyEnv()
+	return nil
+}
+
+// ConfigFile 当前使用的配置文件路径
+func ConfigFile() string {
+	return viper.ConfigFileUsed()
+}
+
+// ProfileName 当前使用的命名配置名称
+func ProfileName() string {
+	if Profile == "" {
+		return DefaultProfile
+	}
+	return Profile
+}
+
+// CreateProfile 以当前配置为模板新建命名配置，新配置不会自动启用
+// 参数:
+//   - name: 配置名称
+func CreateProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		return fmt.Errorf("无效的配置名称: %q", name)
+	}
+	if _, exists := findProfile(name); exists {
+		return fmt.Errorf("命名配置已存在: %s", name)
+	}
+	template := withoutEnv()
+	template.K8s.Name = name
+	Profiles = append(Profiles, models.Profile{Name: name, K8s: template.K8s, SSH: template.SSH})
+	return nil
+}
+
+// UseProfile 切换到指定的命名配置，保存配置后下次启动时默认使用该配置
+// 参数:
+//   - name: 配置名称，default 表示配置文件顶层的 k8s/ssh
+func UseProfile(name string) error {
+	if name != "" && name != DefaultProfile {
+		if _, ok := findProfile(name); !ok {
+			return fmt.Errorf("未找到命名配置: %s", name)
+		}
+	}
+	store(withoutEnv())
+	if name == "" || name == DefaultProfile {
+		Profile = ""
+		Config = models.K8sEPDSConfig{}
+		if len(Clusters) > 0 {
+			Config.K8s = Clusters[0]
+		}
+		if len(sshEntries) > 0 {
+			Config.SSH = sshEntries[0]
+		}
+	} else {
+		p, _ := findProfile(name)
+		Profile = p.Name
+		Config.K8s, Config.SSH = p.K8s, p.SSH
+	}
+	applyEnv()
+	return nil
+}
+
+// Default 返回默认配置，即顶层 k8s/ssh 的第一项，当前使用默认配置时以内存中的值为准
+func Default() models.Profile {
+	profile := models.Profile{Name: DefaultProfile}
+	if Profile == "" {
+		profile.K8s, profile.SSH = Config.K8s, Config.SSH
+		return profile
+	}
+	if len(Clusters) > 0 {
+		profile.K8s = Clusters[0]
+	}
+	if len(sshEntries) > 0 {
+		profile.SSH = sshEntries[0]
+	}
+	return profile
+}
+
+// AllProfiles 返回默认配置和全部命名配置，默认配置中的多个集群各作为一项
+// 当前使用的配置以内存中的值为准，用于多集群扫描
+func AllProfiles() []models.Profile {
+	result := []models.Profile{}
+	for i, cluster := range Clusters {
+		profile := models.Profile{Name: cluster.Name, K8s: cluster}
+		if i < len(sshEntries) {
+			profile.SSH = sshEntries[i]
+		}
+		if i == 0 && Profile == "" {
+			profile.K8s, profile.SSH = Config.K8s, Config.SSH
+		}
+		result = append(result, profile)
+	}
+	for _, profile := range Profiles {
+		if profile.Name == Profile {
+			profile.K8s, profile.SSH = Config.K8s, Config.SSH
+		}
+		if profile.K8s.Name == "" {
+			profile.K8s.Name = profile.Name
+		}
+		result = append(result, profile)
+	}
+	return result
+}
+
+// NodeConfig 获取受控节点的SSH及Token读取配置
+// 节点为当前配置的受控节点时使用当前配置，否则依次在顶层ssh列表和命名配置中按节点名称查找
+// 都没有找到时使用当前配置并替换节点名称
+// 参数:
+//   - node: 受控节点名称
+func NodeConfig(node string) models.SSHConfig {
+	if node == "" || node == Config.SSH.Nodename {
+		return Config.SSH
+	}
+	for _, entry := range sshEntries {
+		if entry.Nodename == node {
+			return entry
+		}
+	}
+	for _, profile := range Profiles {
+		if profile.SSH.Nodename == node {
+			return profile.SSH
+		}
+	}
+	config := Config.SSH
+	config.Nodename = node
+	return config
+}
+
+// findProfile 按名称查找命名配置
+func findProfile(name string) (models.Profile, bool) {
+	for _, profile := range Profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return models.Profile{}, false
+}
+
+// store 将配置写回当前使用的命名配置，未使用命名配置时写回顶层 k8s/ssh 列表的第一项
+func store(config models.K8sEPDSConfig) {
+	if Profile != "" {
+		for i := range Profiles {
+			if Profiles[i].Name == Profile {
+				Profiles[i].K8s, Profiles[i].SSH = config.K8s, config.SSH
+			}
+		}
+		return
+	}
+	if len(Clusters) == 0 {
+		Clusters = []models.K8SConfig{{}}
+	}
+	if len(sshEntries) == 0 {
+		sshEntries = []models.SSHConfig{{}}
+	}
+	Clusters[0], sshEntries[0] = config.K8s, config.SSH
+}
+
+// withoutEnv 返回去掉环境变量覆盖后的当前配置，在此之后修改过的配置项保留修改后的值
+func withoutEnv() models.K8sEPDSConfig {
+	current := Config
+	for _, field := range Fields() {
+		if override, ok := envOverrides[field.Key]; ok && field.Get() == override.value {
+			field.Set(override.original)
+		}
+	}
+	saved := Config
+	Config = current
+	return saved
+}
+
+// applyEnv 使用环境变量覆盖配置项，变量名为 K8SEPDS_ 加上大写的配置键(点换成下划线)
+func applyEnv() {
+	envOverrides = map[string]envOverride{}
+	for _, field := range Fields() {
+		value, ok := os.LookupEnv(EnvName(field.Key))
+		if !ok {
+			continue
+		}
+		original := field.Get()
+		if err := field.Set(value); err != nil {
+			fmt.Printf("[X] 环境变量 %s 无效: %s\n", EnvName(field.Key), err.Error())
+			continue
+		}
+		envOverrides[field.Key] = envOverride{value: field.Get(), original: original}
+	}
+}
+
+// EnvName 配置项对应的环境变量名称
+func EnvName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
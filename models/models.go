@@ -7,6 +7,8 @@
  */
 package models
 
+import "time"
+
 type Pod struct {
 	Namespace      string   // Pod所在的命名空间
 	Name           string   // Pod的名称
@@ -27,6 +29,13 @@ type SA struct {
 	Permission   map[string][]string            // 权限映射(资源类型->操作列表)
 	Roles        map[string]map[string][]string // 角色映射(类型->角色名称->权限列表)
 	RoleBindings []string                       // 关联的RoleBinding列表
+	Grants       map[string]Grant               // 每个绑定授予的权限(绑定名称->授予的权限)
+}
+
+// Grant 单个RoleBinding/ClusterRoleBinding授予SA的权限
+type Grant struct {
+	Role       string              // 绑定引用的角色名称
+	Permission map[string][]string // 该绑定授予的权限(资源类型->操作列表)，RoleBinding授予的资源带[命名空间]后缀
 }
 
 /*
@@ -57,10 +66,16 @@ type Rule struct {
 	Verbs    []string // 操作列表(允许的操作,如get、list、create等)
 }
 
-type SAtoken struct {
-	SaName         string `json:"name"`  // ServiceAccount的名称
-	PermissionType string `json:"type"`  // 权限类型(如cluster、namespace级别)
-	Token          string `json:"token"` // ServiceAccount的认证令牌
+type Role struct {
+	Namespace string // 角色所在的命名空间(ClusterRole为空)
+	Name      string // 角色的名称
+	Rules     []Rule // 角色的规则
+}
+
+type ServiceAccount struct {
+	Namespace      string // SA所在的命名空间
+	Name           string // SA的名称
+	AutomountToken *bool  // automountServiceAccountToken 设置，nil 表示未设置(默认挂载)
 }
 
 type CriticalSASet struct {
@@ -73,19 +88,87 @@ type SSHConfig struct {
 	Username       string // SSH登录用户名
 	Password       string // SSH登录密码
 	PrivateKeyFile string // SSH私钥文件路径
+	Passphrase     string // 私钥口令，私钥加密且未设置时在终端输入
+	Agent          bool   // 是否使用ssh-agent(SSH_AUTH_SOCK)中的密钥认证
+	KnownHosts     string // known_hosts文件路径，默认 ~/.ssh/known_hosts
+	HostKeyPolicy  string // 主机密钥校验方式: tofu(默认，首次连接时信任并记录)、strict、insecure
+	ProxyJump      string // 跳板机，格式同OpenSSH ProxyJump: [user@]host[:port]，多个按连接顺序用逗号分隔
 	Nodename       string // 目标节点名称
+	TokenSource    string // 读取节点上SA Token的方式: ssh(默认)、local、exec、cri
+	KubeletRoot    string // kubelet数据目录，ssh和local方式使用，默认 /var/lib/kubelet
+	CriSocket      string // cri方式使用的容器运行时socket，默认 /run/containerd/containerd.sock
 }
 
 type K8SConfig struct {
-	ApiServer    string //K8s Api服务器地址
-	ProxyAddress string //代理 如果没有留空
-	TokenFile    string //token文件存放位置
-	Kubeconfig   string
-	AdminCert    string //证书
-	AdminCertKey string
+	Name           string   //集群名称，多集群扫描时用于标识集群，为空时使用API服务器地址或kubeconfig上下文
+	ApiServer      string   //K8s Api服务器地址
+	ProxyAddress   string   //代理 如果没有留空
+	TokenFile      string   //token文件存放位置
+	Kubeconfig     string   //kubeconfig文件路径(多个用路径分隔符分隔)，设置后优先使用
+	Context        string   //kubeconfig中使用的上下文，为空时使用current-context
+	AdminCert      string   `mapstructure:"crt"` //证书
+	AdminCertKey   string   `mapstructure:"key"` //证书密钥
+	CAFile         string   //API服务器的CA证书路径，为空时使用系统根证书验证
+	Insecure       bool     //跳过API服务器证书验证，需要显式开启
+	Protobuf       bool     //使用protobuf编码读取内置资源，减少大集群扫描时的传输量和解析开销
+	DryRun         bool     //演练模式，变更请求以 dryRun=All 发送，由API服务器(含准入控制)验证但不修改集群
+	SensitiveNodes []string //敏感节点(控制平面节点之外需要额外关注的节点)
+}
+
+// Profile 命名配置，一个集群及其SSH/受控节点信息
+type Profile struct {
+	Name string
+	K8s  K8SConfig
+	SSH  SSHConfig
 }
 
 type K8sEPDSConfig struct {
 	K8s K8SConfig
 	SSH SSHConfig
 }
+
+/*
+扫描记录(持久化的一次扫描结果)
+*/
+type ScanRecord struct {
+	ID          string        // 扫描记录标识(扫描开始时间,格式:20060102-150405)
+	Time        time.Time     // 扫描时间
+	Cluster     string        // 被扫描集群(API服务器地址)
+	Node        string        // 扫描时的受控节点名称
+	CriticalSAs []CriticalSA  // 本次扫描得到的关键ServiceAccount
+	Findings    []Finding     // 按SA+权限类型展开的发现项
+	Score       int           // 本次扫描所有发现项的风险分之和
+	Coverage    []CoverageGap // 扫描时无法读取的资源，为空表示扫描完整
+}
+
+/*
+扫描覆盖缺口(扫描时无法读取的资源)
+*/
+type CoverageGap struct {
+	Resource        string   // 无法读取的资源(如 rolebindings、clusterroles/admin、roles/default/reader)
+	Namespace       string   // 资源所在的命名空间，集群范围的资源为空
+	Reason          string   // 错误分类(forbidden/unauthorized/notfound/transport/server/error)
+	Message         string   // 错误信息
+	Global          bool     // 是否影响全部发现项(如无法列出绑定或Pod)
+	ServiceAccounts []string // 受影响的SA(格式:namespace/name)
+}
+
+/*
+发现项(一个SA的一种高危权限)
+*/
+type Finding struct {
+	Cluster      string   // 发现项所在的集群
+	SA           string   // ServiceAccount完整名称(格式:namespace/name)
+	Namespace    string   // SA所在的命名空间
+	Pod          string   // 挂载该SA的Pod名称
+	Node         string   // Pod运行的节点名称
+	Type         string   // 高危权限类型(如"createpods[default]")
+	Kind         string   // 权限分类(如anyescalate、restricthijack)
+	Level        string   // 该权限的范围(cluster/namespace)，带[命名空间]后缀的权限为namespace
+	Severity     string   // 严重程度(critical/high/medium/low)
+	Score        int      // 风险分
+	Mounted      bool     // SA是否被Pod挂载
+	Roles        []string // 授予该权限的角色列表
+	RoleBindings []string // 关联的RoleBinding列表
+	Incomplete   bool     // 相关资源无法读取，该SA的权限可能不完整
+}
@@ -0,0 +1,174 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"k8sEPDS/pkg/scan/utils"
+	"os"
+
+	rbacV1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	rbacListers "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// State 评估RBAC变更所需的集群状态
+type State struct {
+	Roles               []rbacV1.Role
+	ClusterRoles        []rbacV1.ClusterRole
+	RoleBindings        []rbacV1.RoleBinding
+	ClusterRoleBindings []rbacV1.ClusterRoleBinding
+}
+
+// StateSource 提供当前的集群RBAC状态
+type StateSource interface {
+	State() (State, error)
+}
+
+// StaticState 固定的集群状态，用于离线评估AdmissionReview
+type StaticState State
+
+// State 返回固定的集群状态
+func (s StaticState) State() (State, error) {
+	return State(s), nil
+}
+
+// LoadState 从 kubectl get -o json 导出的List文件中读取RBAC状态
+// 参数:
+//   - path: 文件路径，内容为包含Role/ClusterRole/RoleBinding/ClusterRoleBinding的List
+func LoadState(path string) (StaticState, error) {
+	state := StaticState{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, fmt.Errorf("读取集群状态文件失败: %w", err)
+	}
+	var list struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return state, fmt.Errorf("解析集群状态文件失败: %w", err)
+	}
+	for _, item := range list.Items {
+		var meta struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(item, &meta); err != nil {
+			return state, fmt.Errorf("解析集群状态文件失败: %w", err)
+		}
+		switch meta.Kind {
+		case "Role":
+			var role rbacV1.Role
+			err = json.Unmarshal(item, &role)
+			state.Roles = append(state.Roles, role)
+		case "ClusterRole":
+			var role rbacV1.ClusterRole
+			err = json.Unmarshal(item, &role)
+			state.ClusterRoles = append(state.ClusterRoles, role)
+		case "RoleBinding":
+			var binding rbacV1.RoleBinding
+			err = json.Unmarshal(item, &binding)
+			state.RoleBindings = append(state.RoleBindings, binding)
+		case "ClusterRoleBinding":
+			var binding rbacV1.ClusterRoleBinding
+			err = json.Unmarshal(item, &binding)
+			state.ClusterRoleBindings = append(state.ClusterRoleBindings, binding)
+		}
+		if err != nil {
+			return state, fmt.Errorf("解析%s失败: %w", meta.Kind, err)
+		}
+	}
+	return state, nil
+}
+
+// InformerState 基于informer缓存的集群状态
+type InformerState struct {
+	roles               rbacListers.RoleLister
+	clusterRoles        rbacListers.ClusterRoleLister
+	roleBindings        rbacListers.RoleBindingLister
+	clusterRoleBindings rbacListers.ClusterRoleBindingLister
+}
+
+// NewInformerState 启动RBAC informer并等待缓存同步
+func NewInformerState(ctx context.Context, clientset kubernetes.Interface) (*InformerState, error) {
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	rbac := factory.Rbac().V1()
+	s := &InformerState{
+		roles:               rbac.Roles().Lister(),
+		clusterRoles:        rbac.ClusterRoles().Lister(),
+		roleBindings:        rbac.RoleBindings().Lister(),
+		clusterRoleBindings: rbac.ClusterRoleBindings().Lister(),
+	}
+	synced := []cache.InformerSynced{
+		rbac.Roles().Informer().HasSynced,
+		rbac.ClusterRoles().Informer().HasSynced,
+		rbac.RoleBindings().Informer().HasSynced,
+		rbac.ClusterRoleBindings().Informer().HasSynced,
+	}
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return nil, fmt.Errorf("informer缓存同步失败")
+	}
+	return s, nil
+}
+
+// State 返回informer缓存中的集群状态
+func (s *InformerState) State() (State, error) {
+	state := State{}
+	roles, err := s.roles.List(labels.Everything())
+	if err != nil {
+		return state, err
+	}
+	for _, role := range roles {
+		state.Roles = append(state.Roles, *role)
+	}
+	clusterRoles, err := s.clusterRoles.List(labels.Everything())
+	if err != nil {
+		return state, err
+	}
+	for _, role := range clusterRoles {
+		state.ClusterRoles = append(state.ClusterRoles, *role)
+	}
+	roleBindings, err := s.roleBindings.List(labels.Everything())
+	if err != nil {
+		return state, err
+	}
+	for _, binding := range roleBindings {
+		state.RoleBindings = append(state.RoleBindings, *binding)
+	}
+	clusterRoleBindings, err := s.clusterRoleBindings.List(labels.Everything())
+	if err != nil {
+		return state, err
+	}
+	for _, binding := range clusterRoleBindings {
+		state.ClusterRoleBindings = append(state.ClusterRoleBindings, *binding)
+	}
+	return state, nil
+}
+
+// criticalSAs 使用 GetCriticalSA 的检测逻辑评估集群状态
+func (s State) criticalSAs() []models.CriticalSA {
+	rules := map[string][]models.Rule{}
+	for i := range s.Roles {
+		rules[s.Roles[i].Namespace+"/"+s.Roles[i].Name] = utils.ConvertRules(s.Roles[i].Rules)
+	}
+	for i := range s.ClusterRoles {
+		rules[s.ClusterRoles[i].Name] = utils.ConvertRules(s.ClusterRoles[i].Rules)
+	}
+	clusterRoleBindings := make([]models.RoleBinding, 0, len(s.ClusterRoleBindings))
+	for i := range s.ClusterRoleBindings {
+		clusterRoleBindings = append(clusterRoleBindings, utils.ConvertClusterRoleBinding(&s.ClusterRoleBindings[i]))
+	}
+	roleBindings := make([]models.RoleBinding, 0, len(s.RoleBindings))
+	for i := range s.RoleBindings {
+		roleBindings = append(roleBindings, utils.ConvertRoleBinding(&s.RoleBindings[i]))
+	}
+	sas := scan.BuildSaBinding(clusterRoleBindings, roleBindings, func(role string) []models.Rule {
+		return rules[role]
+	})
+	return scan.GetCriticalSA(sas, "")
+}
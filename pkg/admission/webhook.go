@@ -0,0 +1,293 @@
+package admission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"k8sEPDS/pkg/diff"
+	"k8sEPDS/pkg/scan"
+	"net/http"
+	"strings"
+
+	admissionV1 "k8s.io/api/admission/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 执行模式
+const (
+	ModeEnforce = "enforce" // 拒绝引入关键权限的变更
+	ModeWarn    = "warn"    // 允许变更，但向客户端返回警告
+	ModeDryRun  = "dryrun"  // 允许变更，只记录审计注解
+)
+
+// 评估失败(如无法获取集群状态、无法解析对象)时的处理方式，与 ValidatingWebhookConfiguration 的 failurePolicy 对应
+const (
+	FailurePolicyFail   = "fail"   // 拒绝请求(fail-closed)
+	FailurePolicyIgnore = "ignore" // 放行请求并返回警告(fail-open)
+)
+
+// maxReviewBytes AdmissionReview请求体的大小上限
+// API服务器中单个对象不超过约1.5MB，UPDATE请求同时包含新旧对象
+const maxReviewBytes = 3 << 20
+
+// Config 准入控制配置
+type Config struct {
+	Mode             string   // 执行模式(enforce/warn/dryrun)
+	FailurePolicy    string   // 评估失败时的处理方式(fail/ignore)，只在 enforce 模式下生效，warn/dryrun 模式始终放行
+	ExemptNamespaces []string // 豁免的命名空间，这些命名空间中的变更不做检查
+	// ExemptSANamespaces 是否同时豁免 ExemptNamespaces 中的SA，默认不豁免
+	// 豁免后其他命名空间中的RoleBinding和ClusterRoleBinding授予这些SA的权限也不做检查
+	ExemptSANamespaces bool
+}
+
+// Validate 检查执行模式和失败处理方式是否合法
+func (c Config) Validate() error {
+	switch c.Mode {
+	case ModeEnforce, ModeWarn, ModeDryRun:
+	default:
+		return fmt.Errorf("不支持的执行模式: %q", c.Mode)
+	}
+	switch c.FailurePolicy {
+	case FailurePolicyFail, FailurePolicyIgnore:
+	default:
+		return fmt.Errorf("不支持的失败处理方式: %q", c.FailurePolicy)
+	}
+	return nil
+}
+
+// Violation 变更为某个SA引入的关键权限
+type Violation struct {
+	SA       string   // ServiceAccount完整名称(格式:namespace/name)
+	Types    []string // 新增的高危权限类型
+	Level    string   // 变更后的权限范围
+	Reason   string   // 新增关键SA/新增权限类型/权限范围扩大
+	Bindings []string // 变更后该SA新增的绑定
+}
+
+// Webhook RBAC变更的ValidatingAdmissionWebhook
+type Webhook struct {
+	config Config
+	source StateSource
+	exempt map[string]bool
+}
+
+// NewWebhook 创建准入控制实例
+// 参数:
+//   - config: 准入控制配置
+//   - source: 当前集群RBAC状态的来源
+func NewWebhook(config Config, source StateSource) *Webhook {
+	w := &Webhook{config: config, source: source, exempt: map[string]bool{}}
+	for _, namespace := range config.ExemptNamespaces {
+		w.exempt[namespace] = true
+	}
+	return w
+}
+
+// ServeHTTP 处理API服务器发送的AdmissionReview请求
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxReviewBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(rw, "AdmissionReview过大", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var review admissionV1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(rw, "无效的AdmissionReview", http.StatusBadRequest)
+		return
+	}
+	response := w.Review(review)
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(response)
+}
+
+// Review 评估一个AdmissionReview并返回带有响应的AdmissionReview
+// 评估失败时按 FailurePolicy 处理: fail 拒绝请求，ignore 放行并返回警告，避免准入控制故障阻塞集群中的RBAC变更
+func (w *Webhook) Review(review admissionV1.AdmissionReview) admissionV1.AdmissionReview {
+	request := review.Request
+	response := &admissionV1.AdmissionResponse{UID: request.UID, Allowed: true}
+	result := admissionV1.AdmissionReview{TypeMeta: review.TypeMeta, Response: response}
+	if result.APIVersion == "" {
+		result.APIVersion = "admission.k8s.io/v1"
+		result.Kind = "AdmissionReview"
+	}
+	if w.exempt[request.Namespace] {
+		return result
+	}
+
+	violations, err := w.Evaluate(request)
+	if err != nil {
+		message := "k8sEPDS: 评估RBAC变更失败: " + err.Error()
+		if w.config.Mode == ModeEnforce && w.config.FailurePolicy == FailurePolicyFail {
+			response.Allowed = false
+			response.Result = &metaV1.Status{
+				Status:  metaV1.StatusFailure,
+				Code:    http.StatusInternalServerError,
+				Reason:  metaV1.StatusReasonInternalError,
+				Message: message,
+			}
+			return result
+		}
+		response.Warnings = []string{message}
+		return result
+	}
+	if len(violations) == 0 {
+		return result
+	}
+
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.String())
+	}
+	response.AuditAnnotations = map[string]string{
+		"k8sepds/violations": strings.Join(messages, "; "),
+	}
+	switch w.config.Mode {
+	case ModeDryRun:
+	case ModeWarn:
+		response.Warnings = messages
+	default:
+		response.Allowed = false
+		response.Result = &metaV1.Status{
+			Status:  metaV1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metaV1.StatusReasonForbidden,
+			Message: "k8sEPDS: 该变更会为ServiceAccount引入关键权限: " + strings.Join(messages, "; "),
+		}
+	}
+	return result
+}
+
+// Evaluate 评估RBAC变更是否会为ServiceAccount引入新的escalate/hijack能力
+func (w *Webhook) Evaluate(request *admissionV1.AdmissionRequest) ([]Violation, error) {
+	if request.Operation != admissionV1.Create && request.Operation != admissionV1.Update {
+		return nil, nil
+	}
+	before, err := w.source.State()
+	if err != nil {
+		return nil, fmt.Errorf("获取集群状态失败: %w", err)
+	}
+	after, err := apply(before, request)
+	if err != nil {
+		return nil, err
+	}
+
+	result := diff.Compare(before.criticalSAs(), after.criticalSAs())
+	violations := []Violation{}
+	for _, change := range result.NewSAs {
+		if types := w.capabilities(change.SA, change.Types); len(types) != 0 {
+			violations = append(violations, Violation{SA: change.SA, Types: types, Level: change.ToLevel, Reason: "新增关键SA", Bindings: change.NewBindings})
+		}
+	}
+	for _, change := range result.NewTypes {
+		if types := w.capabilities(change.SA, change.Types); len(types) != 0 {
+			violations = append(violations, Violation{SA: change.SA, Types: types, Level: change.ToLevel, Reason: "新增权限类型", Bindings: change.NewBindings})
+		}
+	}
+	for _, change := range result.ScopeChanges {
+		if w.exemptSA(change.SA) {
+			continue
+		}
+		violations = append(violations, Violation{SA: change.SA, Level: change.ToLevel, Reason: "权限范围扩大", Bindings: change.NewBindings})
+	}
+	return violations, nil
+}
+
+// capabilities 过滤出属于escalate/hijack类别的权限类型，豁免的SA不做检查
+func (w *Webhook) capabilities(sa string, types []string) []string {
+	if w.exemptSA(sa) {
+		return nil
+	}
+	result := []string{}
+	for _, permType := range types {
+		category := scan.Category(scan.PermissionKinds[scan.DispatchName(permType)])
+		if category == "escalate" || category == "hijack" {
+			result = append(result, permType)
+		}
+	}
+	return result
+}
+
+// exemptSA 是否豁免SA，只有开启 ExemptSANamespaces 时才按SA所在的命名空间豁免
+func (w *Webhook) exemptSA(sa string) bool {
+	return w.config.ExemptSANamespaces && w.exempt[scan.SANamespace(sa)]
+}
+
+// String 违规信息的文本描述
+func (v Violation) String() string {
+	if len(v.Types) == 0 {
+		return fmt.Sprintf("%s %s(%s)", v.SA, v.Reason, v.Level)
+	}
+	return fmt.Sprintf("%s %s%v(%s)", v.SA, v.Reason, v.Types, v.Level)
+}
+
+// apply 将请求中的对象应用到集群状态上，返回变更后的状态
+func apply(state State, request *admissionV1.AdmissionRequest) (State, error) {
+	after := State{
+		Roles:               append([]rbacV1.Role{}, state.Roles...),
+		ClusterRoles:        append([]rbacV1.ClusterRole{}, state.ClusterRoles...),
+		RoleBindings:        append([]rbacV1.RoleBinding{}, state.RoleBindings...),
+		ClusterRoleBindings: append([]rbacV1.ClusterRoleBinding{}, state.ClusterRoleBindings...),
+	}
+	raw := request.Object.Raw
+	var err error
+	switch request.Kind.Kind {
+	case "Role":
+		var role rbacV1.Role
+		if err = json.Unmarshal(raw, &role); err == nil {
+			if role.Namespace == "" {
+				role.Namespace = request.Namespace
+			}
+			after.Roles = replace(after.Roles, role, func(r rbacV1.Role) bool {
+				return r.Namespace == role.Namespace && r.Name == role.Name
+			})
+		}
+	case "ClusterRole":
+		var role rbacV1.ClusterRole
+		if err = json.Unmarshal(raw, &role); err == nil {
+			after.ClusterRoles = replace(after.ClusterRoles, role, func(r rbacV1.ClusterRole) bool {
+				return r.Name == role.Name
+			})
+		}
+	case "RoleBinding":
+		var binding rbacV1.RoleBinding
+		if err = json.Unmarshal(raw, &binding); err == nil {
+			if binding.Namespace == "" {
+				binding.Namespace = request.Namespace
+			}
+			after.RoleBindings = replace(after.RoleBindings, binding, func(b rbacV1.RoleBinding) bool {
+				return b.Namespace == binding.Namespace && b.Name == binding.Name
+			})
+		}
+	case "ClusterRoleBinding":
+		var binding rbacV1.ClusterRoleBinding
+		if err = json.Unmarshal(raw, &binding); err == nil {
+			after.ClusterRoleBindings = replace(after.ClusterRoleBindings, binding, func(b rbacV1.ClusterRoleBinding) bool {
+				return b.Name == binding.Name
+			})
+		}
+	default:
+		return after, fmt.Errorf("不支持的资源类型: %s", request.Kind.Kind)
+	}
+	if err != nil {
+		return after, fmt.Errorf("解析%s失败: %w", request.Kind.Kind, err)
+	}
+	return after, nil
+}
+
+// replace 用新对象替换满足条件的旧对象，不存在时追加
+func replace[T any](items []T, item T, match func(T) bool) []T {
+	for i := range items {
+		if match(items[i]) {
+			items[i] = item
+			return items
+		}
+	}
+	return append(items, item)
+}
@@ -0,0 +1,297 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	admissionV1 "k8s.io/api/admission/v1"
+)
+
+// failingSource 无法获取集群状态的状态来源
+type failingSource struct{}
+
+func (failingSource) State() (State, error) {
+	return State{}, errors.New("informer缓存未同步")
+}
+
+// loadReview 读取 testdata 中的AdmissionReview
+func loadReview(t *testing.T, name string) admissionV1.AdmissionReview {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var review admissionV1.AdmissionReview
+	if err := json.Unmarshal(data, &review); err != nil {
+		t.Fatalf("解析 %s 失败: %v", name, err)
+	}
+	return review
+}
+
+// fixtureState 读取 testdata/state.json 中的集群状态
+func fixtureState(t *testing.T) StaticState {
+	t.Helper()
+	state, err := LoadState(filepath.Join("testdata", "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestReview(t *testing.T) {
+	state := fixtureState(t)
+	tests := []struct {
+		name          string
+		review        string
+		mode          string
+		failurePolicy string
+		source        StateSource
+		exemptSAs     bool // 是否同时豁免豁免命名空间中的SA
+		wantAllowed   bool
+		wantCode      int32  // 拒绝时的状态码
+		wantWarning   string // 警告中应包含的内容，为空表示没有警告
+		wantAudit     string // 审计注解中应包含的内容，为空表示没有审计注解
+	}{
+		{
+			name:        "enforce拒绝新增关键SA",
+			review:      "clusterrolebinding-critical.json",
+			mode:        ModeEnforce,
+			wantAllowed: false,
+			wantCode:    http.StatusForbidden,
+			wantAudit:   "app/attacker 新增关键SA[createpods]",
+		},
+		{
+			name:        "warn放行并返回警告",
+			review:      "clusterrolebinding-critical.json",
+			mode:        ModeWarn,
+			wantAllowed: true,
+			wantWarning: "app/attacker",
+			wantAudit:   "app/attacker",
+		},
+		{
+			name:        "dryrun只记录审计注解",
+			review:      "clusterrolebinding-critical.json",
+			mode:        ModeDryRun,
+			wantAllowed: true,
+			wantAudit:   "app/attacker",
+		},
+		{
+			name:        "修改Role引入读取Secret",
+			review:      "role-update-escalate.json",
+			mode:        ModeEnforce,
+			wantAllowed: false,
+			wantCode:    http.StatusForbidden,
+			wantAudit:   "app/ci 新增关键SA[getsecrets[app]]",
+		},
+		{
+			name:        "不引入关键权限的绑定",
+			review:      "rolebinding-benign.json",
+			mode:        ModeEnforce,
+			wantAllowed: true,
+		},
+		{
+			name:        "豁免命名空间",
+			review:      "rolebinding-exempt.json",
+			mode:        ModeEnforce,
+			wantAllowed: true,
+		},
+		{
+			name:        "集群范围授权给豁免命名空间中的SA",
+			review:      "clusterrolebinding-kube-system.json",
+			mode:        ModeEnforce,
+			wantAllowed: false,
+			wantCode:    http.StatusForbidden,
+			wantAudit:   "kube-system/helper 新增关键SA[createpods]",
+		},
+		{
+			name:        "开启SA豁免后不检查豁免命名空间中的SA",
+			review:      "clusterrolebinding-kube-system.json",
+			mode:        ModeEnforce,
+			exemptSAs:   true,
+			wantAllowed: true,
+		},
+		{
+			name:        "删除不做检查",
+			review:      "clusterrolebinding-delete.json",
+			mode:        ModeEnforce,
+			wantAllowed: true,
+		},
+		{
+			name:          "对象无法解析时fail拒绝",
+			review:        "clusterrolebinding-malformed.json",
+			mode:          ModeEnforce,
+			failurePolicy: FailurePolicyFail,
+			wantAllowed:   false,
+			wantCode:      http.StatusInternalServerError,
+		},
+		{
+			name:          "对象无法解析时ignore放行",
+			review:        "clusterrolebinding-malformed.json",
+			mode:          ModeEnforce,
+			failurePolicy: FailurePolicyIgnore,
+			wantAllowed:   true,
+			wantWarning:   "评估RBAC变更失败",
+		},
+		{
+			name:          "无法获取集群状态时fail拒绝",
+			review:        "clusterrolebinding-critical.json",
+			mode:          ModeEnforce,
+			failurePolicy: FailurePolicyFail,
+			source:        failingSource{},
+			wantAllowed:   false,
+			wantCode:      http.StatusInternalServerError,
+		},
+		{
+			name:          "warn模式评估失败时始终放行",
+			review:        "clusterrolebinding-critical.json",
+			mode:          ModeWarn,
+			failurePolicy: FailurePolicyFail,
+			source:        failingSource{},
+			wantAllowed:   true,
+			wantWarning:   "informer缓存未同步",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := tt.source
+			if source == nil {
+				source = state
+			}
+			policy := tt.failurePolicy
+			if policy == "" {
+				policy = FailurePolicyFail
+			}
+			webhook := NewWebhook(Config{Mode: tt.mode, FailurePolicy: policy, ExemptNamespaces: []string{"kube-system"}, ExemptSANamespaces: tt.exemptSAs}, source)
+			review := loadReview(t, tt.review)
+			result := webhook.Review(review)
+			response := result.Response
+			if response == nil {
+				t.Fatal("响应为空")
+			}
+			if response.UID != review.Request.UID {
+				t.Errorf("UID = %q, 期望 %q", response.UID, review.Request.UID)
+			}
+			if result.APIVersion != "admission.k8s.io/v1" || result.Kind != "AdmissionReview" {
+				t.Errorf("TypeMeta = %s/%s", result.APIVersion, result.Kind)
+			}
+			if response.Allowed != tt.wantAllowed {
+				t.Fatalf("Allowed = %v, 期望 %v (result: %+v)", response.Allowed, tt.wantAllowed, response.Result)
+			}
+			if !tt.wantAllowed && (response.Result == nil || response.Result.Code != tt.wantCode) {
+				t.Errorf("Result = %+v, 期望状态码 %d", response.Result, tt.wantCode)
+			}
+			warnings := strings.Join(response.Warnings, "; ")
+			if tt.wantWarning == "" && warnings != "" {
+				t.Errorf("不应有警告: %s", warnings)
+			}
+			if !strings.Contains(warnings, tt.wantWarning) {
+				t.Errorf("警告 %q 中没有 %q", warnings, tt.wantWarning)
+			}
+			audit := response.AuditAnnotations["k8sepds/violations"]
+			if tt.wantAudit == "" && audit != "" {
+				t.Errorf("不应有审计注解: %s", audit)
+			}
+			if !strings.Contains(audit, tt.wantAudit) {
+				t.Errorf("审计注解 %q 中没有 %q", audit, tt.wantAudit)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	webhook := NewWebhook(Config{Mode: ModeEnforce, FailurePolicy: FailurePolicyFail}, fixtureState(t))
+	tests := []struct {
+		review string
+		want   []Violation
+	}{
+		{
+			review: "clusterrolebinding-critical.json",
+			want:   []Violation{{SA: "app/attacker", Types: []string{"createpods"}, Level: "cluster", Reason: "新增关键SA"}},
+		},
+		{
+			review: "role-update-escalate.json",
+			want:   []Violation{{SA: "app/ci", Types: []string{"getsecrets[app]"}, Level: "namespace", Reason: "新增关键SA"}},
+		},
+		{review: "rolebinding-benign.json"},
+		{review: "clusterrolebinding-delete.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.review, func(t *testing.T) {
+			violations, err := webhook.Evaluate(loadReview(t, tt.review).Request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(violations) != len(tt.want) {
+				t.Fatalf("违规 %v, 期望 %v", violations, tt.want)
+			}
+			for i, want := range tt.want {
+				got := violations[i]
+				if got.SA != want.SA || got.Level != want.Level || got.Reason != want.Reason || strings.Join(got.Types, ",") != strings.Join(want.Types, ",") {
+					t.Errorf("违规 %+v, 期望 %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	webhook := NewWebhook(Config{Mode: ModeEnforce, FailurePolicy: FailurePolicyFail}, fixtureState(t))
+	fixture, err := os.ReadFile(filepath.Join("testdata", "clusterrolebinding-critical.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		body       []byte
+		wantStatus int
+	}{
+		{name: "AdmissionReview", body: fixture, wantStatus: http.StatusOK},
+		{name: "无效的JSON", body: []byte("{"), wantStatus: http.StatusBadRequest},
+		{name: "缺少request", body: []byte(`{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview"}`), wantStatus: http.StatusBadRequest},
+		{name: "请求体过大", body: bytes.Repeat([]byte(" "), maxReviewBytes+1), wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			webhook.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(tt.body)))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("状态码 %d, 期望 %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var review admissionV1.AdmissionReview
+			if err := json.Unmarshal(recorder.Body.Bytes(), &review); err != nil {
+				t.Fatal(err)
+			}
+			if review.Response == nil || review.Response.Allowed {
+				t.Errorf("响应 %+v, 期望拒绝", review.Response)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		config  Config
+		wantErr bool
+	}{
+		{Config{Mode: ModeEnforce, FailurePolicy: FailurePolicyFail}, false},
+		{Config{Mode: ModeWarn, FailurePolicy: FailurePolicyIgnore}, false},
+		{Config{Mode: "audit", FailurePolicy: FailurePolicyFail}, true},
+		{Config{Mode: ModeEnforce, FailurePolicy: "open"}, true},
+		{Config{Mode: ModeEnforce}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: Validate() = %v, 期望出错 %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
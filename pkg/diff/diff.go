@@ -0,0 +1,197 @@
+package diff
+
+import (
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"sort"
+)
+
+// Change 描述单个ServiceAccount在两次扫描之间的变化
+type Change struct {
+	SA          string   // ServiceAccount完整名称(格式:namespace/name)
+	Types       []string // 新增(或移除)的高危权限类型
+	FromLevel   string   // 旧扫描中的权限范围
+	ToLevel     string   // 新扫描中的权限范围
+	NewBindings []string // 导致变化的新出现的RoleBinding/ClusterRoleBinding
+	NewRoles    []string // 导致变化的绑定引用的新出现的Role/ClusterRole
+}
+
+// Result 两次扫描的差异
+type Result struct {
+	NewSAs       []Change // 新出现的关键SA
+	RemovedSAs   []Change // 不再是关键SA的SA
+	NewTypes     []Change // 已有关键SA上新增的权限类型
+	ScopeChanges []Change // 权限范围从namespace扩大到cluster的SA
+}
+
+// Empty 判断两次扫描之间是否没有任何变化
+func (r Result) Empty() bool {
+	return len(r.NewSAs) == 0 && len(r.RemovedSAs) == 0 && len(r.NewTypes) == 0 && len(r.ScopeChanges) == 0
+}
+
+// Compare 比较两次 GetCriticalSA 的扫描结果
+// 参数:
+//   - oldSAs: 旧扫描结果
+//   - newSAs: 新扫描结果
+//
+// 返回:
+//   - Result: 按SA名称排序的差异
+func Compare(oldSAs []models.CriticalSA, newSAs []models.CriticalSA) Result {
+	result := Result{}
+	oldMap := index(oldSAs)
+	newMap := index(newSAs)
+
+	for name, newSA := range newMap {
+		oldSA, exists := oldMap[name]
+		if !exists {
+			newBindings, newRoles := causes(newSA, models.CriticalSA{}, newSA.Type)
+			result.NewSAs = append(result.NewSAs, Change{
+				SA:          name,
+				Types:       newSA.Type,
+				ToLevel:     newSA.Level,
+				NewBindings: newBindings,
+				NewRoles:    newRoles,
+			})
+			continue
+		}
+		if newTypes := subtract(newSA.Type, oldSA.Type); len(newTypes) != 0 {
+			newBindings, newRoles := causes(newSA, oldSA, newTypes)
+			result.NewTypes = append(result.NewTypes, Change{
+				SA:          name,
+				Types:       newTypes,
+				FromLevel:   oldSA.Level,
+				ToLevel:     newSA.Level,
+				NewBindings: newBindings,
+				NewRoles:    newRoles,
+			})
+		}
+		if oldSA.Level == "namespace" && newSA.Level == "cluster" {
+			// 权限范围由新增的集群范围权限扩大
+			clusterTypes := []string{}
+			for _, permType := range subtract(newSA.Type, oldSA.Type) {
+				if scan.Scope(permType) == "cluster" {
+					clusterTypes = append(clusterTypes, permType)
+				}
+			}
+			newBindings, newRoles := causes(newSA, oldSA, clusterTypes)
+			result.ScopeChanges = append(result.ScopeChanges, Change{
+				SA:          name,
+				FromLevel:   oldSA.Level,
+				ToLevel:     newSA.Level,
+				NewBindings: newBindings,
+				NewRoles:    newRoles,
+			})
+		}
+	}
+	for name, oldSA := range oldMap {
+		if _, exists := newMap[name]; !exists {
+			result.RemovedSAs = append(result.RemovedSAs, Change{
+				SA:        name,
+				Types:     oldSA.Type,
+				FromLevel: oldSA.Level,
+			})
+		}
+	}
+
+	for _, changes := range [][]Change{result.NewSAs, result.RemovedSAs, result.NewTypes, result.ScopeChanges} {
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].SA < changes[j].SA
+		})
+	}
+	return result
+}
+
+// causes 找出授予指定权限类型的新绑定及其引用的新角色
+// 按每个绑定单独授予的权限判断，单个绑定无法授予的权限类型(需要多个绑定的权限组合)归因于全部新绑定，
+// 导致变化的新角色为这些绑定(含已有绑定)引用的新角色，没有记录绑定权限的旧扫描结果归因于全部新绑定和新角色
+// 参数:
+//   - newSA: 新扫描中的SA
+//   - oldSA: 旧扫描中的SA，新出现的SA为零值
+//   - types: 需要归因的权限类型
+//
+// 返回:
+//   - []string: 导致变化的新绑定
+//   - []string: 这些绑定引用的新角色
+func causes(newSA models.CriticalSA, oldSA models.CriticalSA, types []string) ([]string, []string) {
+	newBindings := subtract(newSA.SA0.RoleBindings, oldSA.SA0.RoleBindings)
+	newRoles := subtract(newSA.Roles, oldSA.Roles)
+	if len(newSA.SA0.Grants) == 0 {
+		return newBindings, newRoles
+	}
+
+	wanted := make(map[string]bool, len(types))
+	for _, permType := range types {
+		wanted[permType] = true
+	}
+	granted := map[string]bool{}
+	causing := map[string]bool{}
+	for binding, grant := range newSA.SA0.Grants {
+		for _, permType := range grantedTypes(newSA.SA0.Name, grant) {
+			if wanted[permType] {
+				granted[permType] = true
+				causing[binding] = true
+			}
+		}
+	}
+	if len(granted) < len(wanted) {
+		for _, binding := range newBindings {
+			causing[binding] = true
+		}
+	}
+
+	bindings := []string{}
+	for _, binding := range newBindings {
+		if causing[binding] {
+			bindings = append(bindings, binding)
+		}
+	}
+	causingRoles := map[string]bool{}
+	for binding := range causing {
+		causingRoles[newSA.SA0.Grants[binding].Role] = true
+	}
+	roles := []string{}
+	for _, role := range newRoles {
+		if causingRoles[role] {
+			roles = append(roles, role)
+		}
+	}
+	return bindings, roles
+}
+
+// grantedTypes 单个绑定授予的高危权限类型
+func grantedTypes(name string, grant models.Grant) []string {
+	sa := &models.SA{
+		Name:       name,
+		Permission: grant.Permission,
+		Roles:      map[string]map[string][]string{grant.Role: grant.Permission},
+	}
+	for _, criticalSA := range scan.GetCriticalSA(map[string]*models.SA{name: sa}, "") {
+		return criticalSA.Type
+	}
+	return nil
+}
+
+// index 以SA名称为键建立索引
+func index(criticalSAs []models.CriticalSA) map[string]models.CriticalSA {
+	result := make(map[string]models.CriticalSA, len(criticalSAs))
+	for _, criticalSA := range criticalSAs {
+		result[criticalSA.SA0.Name] = criticalSA
+	}
+	return result
+}
+
+// subtract 返回在 a 中但不在 b 中的元素
+func subtract(a []string, b []string) []string {
+	exists := make(map[string]bool, len(b))
+	for _, s := range b {
+		exists[s] = true
+	}
+	result := []string{}
+	for _, s := range a {
+		if !exists[s] {
+			result = append(result, s)
+			exists[s] = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,170 @@
+package diff
+
+import (
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// fixtureRules 测试用的角色规则
+var fixtureRules = map[string][]models.Rule{
+	"pod-creator":   {{Resourcs: []string{"pods"}, Verbs: []string{"create"}}},
+	"secret-reader": {{Resourcs: []string{"secrets"}, Verbs: []string{"get"}}},
+	"view":          {{Resourcs: []string{"pods", "services"}, Verbs: []string{"list"}}},
+	"role-patcher":  {{Resourcs: []string{"clusterroles"}, Verbs: []string{"patch"}}},
+	"role-patcher2": {{Resourcs: []string{"clusterroles"}, Verbs: []string{"patch"}}},
+}
+
+// binding 构造绑定到 app/deployer 的RoleBinding，namespace为空时为ClusterRoleBinding
+func binding(name string, namespace string, role string) models.RoleBinding {
+	return models.RoleBinding{Namespace: namespace, Name: name, RoleRef: role, Subject: []string{"app/deployer"}}
+}
+
+// criticalSAs 按绑定关系扫描出关键SA
+func criticalSAs(bindings ...models.RoleBinding) []models.CriticalSA {
+	clusterRoleBindings := []models.RoleBinding{}
+	roleBindings := []models.RoleBinding{}
+	for _, binding := range bindings {
+		if binding.Namespace == "" {
+			clusterRoleBindings = append(clusterRoleBindings, binding)
+		} else {
+			roleBindings = append(roleBindings, binding)
+		}
+	}
+	sas := scan.BuildSaBinding(clusterRoleBindings, roleBindings, func(role string) []models.Rule {
+		return fixtureRules[role]
+	})
+	return scan.GetCriticalSA(sas, "")
+}
+
+// withoutGrants 去掉绑定授予的权限，模拟旧版本保存的扫描记录
+func withoutGrants(criticalSAs []models.CriticalSA) []models.CriticalSA {
+	for i := range criticalSAs {
+		criticalSAs[i].SA0.Grants = nil
+	}
+	return criticalSAs
+}
+
+func TestCompare(t *testing.T) {
+	secrets := binding("app-secrets", "app", "secret-reader")
+	pods := binding("app-pods", "app", "pod-creator")
+	view := binding("app-view", "app", "view")
+	clusterPods := binding("cluster-pods", "", "pod-creator")
+	tests := []struct {
+		name        string
+		old         []models.CriticalSA
+		new         []models.CriticalSA
+		newSAs      []Change
+		removedSAs  []Change
+		newTypes    []Change
+		scopeChange []Change
+	}{
+		{
+			name: "没有变化",
+			old:  criticalSAs(secrets, view),
+			new:  criticalSAs(secrets, view),
+		},
+		{
+			name: "新出现的SA只列出授予高危权限的绑定",
+			old:  []models.CriticalSA{},
+			new:  criticalSAs(pods, view),
+			newSAs: []Change{{
+				SA: "app/deployer", Types: []string{"createpods[app]"}, ToLevel: "namespace",
+				NewBindings: []string{"app-pods"}, NewRoles: []string{"pod-creator"},
+			}},
+		},
+		{
+			name:       "不再是关键SA",
+			old:        criticalSAs(secrets),
+			new:        criticalSAs(view),
+			removedSAs: []Change{{SA: "app/deployer", Types: []string{"getsecrets[app]"}, FromLevel: "namespace"}},
+		},
+		{
+			name: "新增权限只归因于授予该权限的新绑定",
+			old:  criticalSAs(secrets),
+			new:  criticalSAs(secrets, pods, view),
+			newTypes: []Change{{
+				SA: "app/deployer", Types: []string{"createpods[app]"}, FromLevel: "namespace", ToLevel: "namespace",
+				NewBindings: []string{"app-pods"}, NewRoles: []string{"pod-creator"},
+			}},
+		},
+		{
+			name: "权限范围扩大归因于新的ClusterRoleBinding",
+			old:  criticalSAs(pods),
+			new:  criticalSAs(pods, clusterPods, view),
+			newTypes: []Change{{
+				SA: "app/deployer", Types: []string{"createpods"}, FromLevel: "namespace", ToLevel: "cluster",
+				NewBindings: []string{"cluster-pods"}, NewRoles: []string{},
+			}},
+			scopeChange: []Change{{
+				SA: "app/deployer", FromLevel: "namespace", ToLevel: "cluster",
+				NewBindings: []string{"cluster-pods"}, NewRoles: []string{},
+			}},
+		},
+		{
+			name: "已有角色通过新的ClusterRoleBinding扩大范围",
+			old:  criticalSAs(secrets, pods),
+			new:  criticalSAs(secrets, view, binding("cluster-secrets", "", "secret-reader")),
+			newTypes: []Change{{
+				SA: "app/deployer", Types: []string{"getsecrets"}, FromLevel: "namespace", ToLevel: "cluster",
+				NewBindings: []string{"cluster-secrets"}, NewRoles: []string{},
+			}},
+			scopeChange: []Change{{
+				SA: "app/deployer", FromLevel: "namespace", ToLevel: "cluster",
+				NewBindings: []string{"cluster-secrets"}, NewRoles: []string{},
+			}},
+		},
+		{
+			name: "多个绑定组合授予的权限归因于全部新绑定",
+			old:  criticalSAs(secrets),
+			new:  criticalSAs(secrets, binding("patch-1", "", "role-patcher"), binding("patch-2", "", "role-patcher2")),
+			newTypes: []Change{{
+				SA: "app/deployer", Types: []string{"patchclusterroles", "patchroles"}, FromLevel: "namespace", ToLevel: "cluster",
+				NewBindings: []string{"patch-1", "patch-2"}, NewRoles: []string{"role-patcher", "role-patcher2"},
+			}},
+			scopeChange: []Change{{
+				SA: "app/deployer", FromLevel: "namespace", ToLevel: "cluster",
+				NewBindings: []string{"patch-1", "patch-2"}, NewRoles: []string{"role-patcher", "role-patcher2"},
+			}},
+		},
+		{
+			name: "旧版本扫描记录归因于全部新绑定",
+			old:  withoutGrants(criticalSAs(secrets)),
+			new:  withoutGrants(criticalSAs(secrets, pods, view)),
+			newTypes: []Change{{
+				SA: "app/deployer", Types: []string{"createpods[app]"}, FromLevel: "namespace", ToLevel: "namespace",
+				NewBindings: []string{"app-pods", "app-view"}, NewRoles: []string{"pod-creator", "view"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Compare(tt.old, tt.new)
+			for _, changes := range [][]Change{result.NewSAs, result.RemovedSAs, result.NewTypes, result.ScopeChanges} {
+				for i := range changes {
+					sort.Strings(changes[i].Types)
+					sort.Strings(changes[i].NewBindings)
+					sort.Strings(changes[i].NewRoles)
+				}
+			}
+			check := func(kind string, got []Change, want []Change) {
+				if len(got) == 0 && len(want) == 0 {
+					return
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %+v, 期望 %+v", kind, got, want)
+				}
+			}
+			check("NewSAs", result.NewSAs, tt.newSAs)
+			check("RemovedSAs", result.RemovedSAs, tt.removedSAs)
+			check("NewTypes", result.NewTypes, tt.newTypes)
+			check("ScopeChanges", result.ScopeChanges, tt.scopeChange)
+			empty := len(tt.newSAs)+len(tt.removedSAs)+len(tt.newTypes)+len(tt.scopeChange) == 0
+			if result.Empty() != empty {
+				t.Errorf("Empty() = %v, 期望 %v", result.Empty(), empty)
+			}
+		})
+	}
+}
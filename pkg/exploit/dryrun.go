@@ -0,0 +1,20 @@
+package exploit
+
+import (
+	"fmt"
+	"k8sEPDS/pkg/request"
+)
+
+// skipDryRun 演练模式中变更只由API服务器验证，不会真正创建或修改资源，依赖变更结果的后续步骤(等待调度、读取新Token等)无法执行
+// 参数:
+//   - step: 被跳过的后续步骤说明
+//
+// 返回:
+//   - bool: 是否为演练模式，为 true 时调用方应跳过后续步骤
+func skipDryRun(step string) bool {
+	if !request.DryRun() {
+		return false
+	}
+	fmt.Println("[dry-run] The change was only validated by the API server, skipping:", step)
+	return true
+}
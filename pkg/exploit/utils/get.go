@@ -24,16 +24,21 @@ func GetSecrets(token string, adminName string, SaNamespace string) (string, err
 		Method: "GET",
 		Token:  token,
 	}
-	resp, err := request.ApiRequest(opts)
-	if err != nil {
-		return "", err
-	}
-	secrets := gjson.Get(resp, "items").Array()
-	for _, secret := range secrets {
-		//fmt.Println(secret.Get("metadata.name").String())
+	// 分页列出，找到后停止，不需要读取全部Secret
+	errFound := errors.New("found")
+	var secretToken string
+	err := request.List(opts, func(secret gjson.Result) error {
 		if strings.Contains(secret.Get("metadata.name").String(), adminName) {
-			return secret.Get("data.token").String(), nil
+			secretToken = secret.Get("data.token").String()
+			return errFound
 		}
+		return nil
+	})
+	if errors.Is(err, errFound) {
+		return secretToken, nil
+	}
+	if err != nil {
+		return "", err
 	}
 
 	//If there is no listSecret permission, the above fuzzy query cannot find it, and you need to specify the namespace and secret name for directed acquisition.
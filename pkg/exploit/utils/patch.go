@@ -75,14 +75,13 @@ func PatchNodes(token string, ControledNode string) error {
 		Api:    "/api/v1/nodes",
 		Method: "GET",
 	}
-	resp, err := request.ApiRequest(opts)
-	if err != nil {
-		return err
-	}
-	nodes := gjson.Get(resp, "items").Array()
 	nodesName := []string{}
-	for _, node := range nodes {
+	err := request.List(opts, func(node gjson.Result) error {
 		nodesName = append(nodesName, node.Get("metadata.name").String())
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	for _, node := range nodesName {
 		if node != ControledNode {
@@ -0,0 +1,99 @@
+package gate
+
+import (
+	"k8sEPDS/models"
+	"sort"
+)
+
+// Decision 单个发现项的评估结果
+type Decision struct {
+	Finding    models.Finding
+	Action     string // fail/warn/ignore
+	Rule       string // 命中的规则描述，使用默认动作时为 default
+	Suppressed string // 被抑制时为抑制原因
+	Existing   bool   // 发现项已存在于基线扫描中
+}
+
+// Result 一次评估的结果
+type Result struct {
+	Decisions  []Decision
+	Failed     int // 违反策略的发现项数量
+	Warned     int // 警告的发现项数量
+	Ignored    int // 忽略的发现项数量(含已存在于基线中的发现项)
+	Suppressed int // 被抑制的发现项数量
+}
+
+// Passed 没有违反策略的发现项
+func (r Result) Passed() bool {
+	return r.Failed == 0
+}
+
+// Evaluate 按策略评估发现项
+// 指定基线时，只有基线中不存在的发现项(新的提权路径)会被按规则评估，其余视为忽略
+// 参数:
+//   - policy: CI门禁策略
+//   - findings: 本次扫描的发现项
+//   - baseline: 基线扫描的发现项，为nil时不使用基线
+//
+// 返回:
+//   - Result: 评估结果，决策按 fail、warn、ignore 排列
+func Evaluate(policy Policy, findings []models.Finding, baseline []models.Finding) Result {
+	existing := map[string]bool{}
+	for _, finding := range baseline {
+		existing[finding.SA+"|"+finding.Type] = true
+	}
+	defaultAction := policy.Default
+	if defaultAction == "" {
+		defaultAction = ActionIgnore
+	}
+
+	result := Result{Decisions: []Decision{}}
+	for _, finding := range findings {
+		decision := Decision{Finding: finding, Action: defaultAction, Rule: "default"}
+		for _, suppression := range policy.Suppressions {
+			if suppression.Matches(finding) {
+				decision.Action = ActionIgnore
+				decision.Suppressed = suppression.Reason
+				if decision.Suppressed == "" {
+					decision.Suppressed = "suppressed"
+				}
+				break
+			}
+		}
+		if decision.Suppressed == "" {
+			for _, rule := range policy.Rules {
+				if rule.Matches(finding) {
+					decision.Action = rule.Action
+					decision.Rule = rule.String()
+					break
+				}
+			}
+			if baseline != nil && existing[finding.SA+"|"+finding.Type] {
+				decision.Existing = true
+				decision.Action = ActionIgnore
+			}
+		}
+
+		switch {
+		case decision.Suppressed != "":
+			result.Suppressed++
+		case decision.Action == ActionFail:
+			result.Failed++
+		case decision.Action == ActionWarn:
+			result.Warned++
+		default:
+			result.Ignored++
+		}
+		result.Decisions = append(result.Decisions, decision)
+	}
+
+	ranks := map[string]int{ActionFail: 0, ActionWarn: 1, ActionIgnore: 2}
+	sort.SliceStable(result.Decisions, func(i, j int) bool {
+		a, b := result.Decisions[i], result.Decisions[j]
+		if ranks[a.Action] != ranks[b.Action] {
+			return ranks[a.Action] < ranks[b.Action]
+		}
+		return a.Finding.Score > b.Finding.Score
+	})
+	return result
+}
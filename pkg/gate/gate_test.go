@@ -0,0 +1,290 @@
+package gate
+
+import (
+	"bytes"
+	"k8sEPDS/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// finding 构造测试用的发现项，范围、分类和严重程度按权限类型给出
+func finding(sa string, permType string, kind string, severity string, score int) models.Finding {
+	namespace, _, _ := strings.Cut(sa, "/")
+	level := "cluster"
+	if strings.HasSuffix(permType, "]") {
+		level = "namespace"
+	}
+	return models.Finding{
+		SA: sa, Namespace: namespace, Type: permType, Kind: kind, Level: level, Severity: severity, Score: score,
+		Pod: "pod-" + namespace, Node: "node1", Roles: []string{"role"}, RoleBindings: []string{"binding"},
+	}
+}
+
+var (
+	clusterPods    = finding("app/deployer", "createpods", "anyescalate", "critical", 100)
+	namespacePods  = finding("app/deployer", "createpods[app]", "restrictescalate", "high", 80)
+	clusterSecrets = finding("kube-system/helper", "getsecrets(admin-token)", "restrictescalate", "high", 70)
+	patchNodes     = finding("monitor/agent", "patchnodes", "anyhijack", "medium", 50)
+	deleteNodes    = finding("monitor/agent", "deletenodes", "anydos", "low", 20)
+)
+
+func TestRuleMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		finding models.Finding
+		want    bool
+	}{
+		{name: "空规则命中全部", rule: Rule{Action: ActionFail}, finding: deleteNodes, want: true},
+		{name: "分类", rule: Rule{Category: "escalate"}, finding: namespacePods, want: true},
+		{name: "分类不符", rule: Rule{Category: "escalate"}, finding: patchNodes, want: false},
+		{name: "权限分类", rule: Rule{Kind: "anyescalate"}, finding: clusterPods, want: true},
+		{name: "权限分类不符", rule: Rule{Kind: "anyescalate"}, finding: namespacePods, want: false},
+		{name: "集群范围", rule: Rule{Scope: "cluster"}, finding: clusterSecrets, want: true},
+		{name: "命名空间范围的权限不是集群范围", rule: Rule{Scope: "cluster"}, finding: namespacePods, want: false},
+		{name: "命名空间范围", rule: Rule{Scope: "namespace"}, finding: namespacePods, want: true},
+		{name: "完整权限类型", rule: Rule{Permission: "createpods[app]"}, finding: namespacePods, want: true},
+		{name: "归一化权限名称", rule: Rule{Permission: "createpods"}, finding: namespacePods, want: true},
+		{name: "带资源名的权限归一化", rule: Rule{Permission: "getsecrets"}, finding: clusterSecrets, want: true},
+		{name: "权限不符", rule: Rule{Permission: "createpods"}, finding: patchNodes, want: false},
+		{name: "命名空间通配符", rule: Rule{Namespace: "kube-*"}, finding: clusterSecrets, want: true},
+		{name: "命名空间不符", rule: Rule{Namespace: "kube-*"}, finding: clusterPods, want: false},
+		{name: "严重程度相等", rule: Rule{MinSeverity: "high"}, finding: namespacePods, want: true},
+		{name: "严重程度更高", rule: Rule{MinSeverity: "high"}, finding: clusterPods, want: true},
+		{name: "严重程度更低", rule: Rule{MinSeverity: "high"}, finding: patchNodes, want: false},
+		{name: "多个条件都满足", rule: Rule{Category: "escalate", Scope: "cluster", Namespace: "app"}, finding: clusterPods, want: true},
+		{name: "多个条件部分满足", rule: Rule{Category: "escalate", Scope: "cluster", Namespace: "app"}, finding: namespacePods, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(tt.finding); got != tt.want {
+				t.Errorf("%s.Matches(%s %s) = %v, 期望 %v", tt.rule, tt.finding.SA, tt.finding.Type, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressionMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		suppression Suppression
+		finding     models.Finding
+		want        bool
+	}{
+		{name: "SA的全部发现项", suppression: Suppression{SA: "app/deployer"}, finding: namespacePods, want: true},
+		{name: "SA不符", suppression: Suppression{SA: "app/deployer"}, finding: patchNodes, want: false},
+		{name: "SA通配符", suppression: Suppression{SA: "kube-system/*"}, finding: clusterSecrets, want: true},
+		{name: "SA通配符不跨命名空间", suppression: Suppression{SA: "kube-system/*"}, finding: clusterPods, want: false},
+		{name: "完整权限类型", suppression: Suppression{SA: "app/deployer", Permission: "createpods[app]"}, finding: namespacePods, want: true},
+		{name: "完整权限类型不匹配其他范围", suppression: Suppression{SA: "app/deployer", Permission: "createpods[app]"}, finding: clusterPods, want: false},
+		{name: "归一化权限名称", suppression: Suppression{SA: "app/deployer", Permission: "createpods"}, finding: namespacePods, want: true},
+		{name: "权限不符", suppression: Suppression{SA: "monitor/agent", Permission: "patchnodes"}, finding: deleteNodes, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.suppression.Matches(tt.finding); got != tt.want {
+				t.Errorf("%+v.Matches(%s %s) = %v, 期望 %v", tt.suppression, tt.finding.SA, tt.finding.Type, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{pattern: "app", value: "app", want: true},
+		{pattern: "app", value: "app2", want: false},
+		{pattern: "*", value: "kube-system", want: true},
+		{pattern: "kube-*", value: "kube-system", want: true},
+		{pattern: "kube-*", value: "app", want: false},
+		{pattern: "*/default", value: "app/default", want: true},
+		{pattern: "*", value: "app/default", want: false},
+		{pattern: "team-?", value: "team-a", want: true},
+		{pattern: "team-[ab]", value: "team-c", want: false},
+		// 格式错误时按字符串相等处理
+		{pattern: "team-[", value: "team-[", want: true},
+		{pattern: "team-[", value: "team-a", want: false},
+	}
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.value); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, 期望 %v", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	findings := []models.Finding{deleteNodes, patchNodes, namespacePods, clusterSecrets, clusterPods}
+	tests := []struct {
+		name     string
+		policy   Policy
+		baseline []models.Finding
+		// want 按 SA 权限类型 给出每个发现项的动作，抑制的发现项为 suppressed
+		want                                map[string]string
+		order                               []string // 决策的顺序
+		failed, warned, ignored, suppressed int
+		existing                            []string // 已存在于基线中的发现项
+	}{
+		{
+			name:   "默认策略",
+			policy: DefaultPolicy,
+			want: map[string]string{
+				"app/deployer createpods":                    ActionFail,
+				"kube-system/helper getsecrets(admin-token)": ActionFail,
+				"app/deployer createpods[app]":               ActionWarn,
+				"monitor/agent patchnodes":                   ActionWarn,
+				"monitor/agent deletenodes":                  ActionIgnore,
+			},
+			order: []string{
+				"app/deployer createpods", "kube-system/helper getsecrets(admin-token)",
+				"app/deployer createpods[app]", "monitor/agent patchnodes", "monitor/agent deletenodes",
+			},
+			failed: 2, warned: 2, ignored: 1,
+		},
+		{
+			name:   "按顺序取第一条命中的规则",
+			policy: Policy{Rules: []Rule{{Action: ActionWarn, Namespace: "app"}, {Action: ActionFail, Category: "escalate"}}},
+			want: map[string]string{
+				"app/deployer createpods":                    ActionWarn,
+				"app/deployer createpods[app]":               ActionWarn,
+				"kube-system/helper getsecrets(admin-token)": ActionFail,
+				"monitor/agent patchnodes":                   ActionIgnore,
+				"monitor/agent deletenodes":                  ActionIgnore,
+			},
+			failed: 1, warned: 2, ignored: 2,
+		},
+		{
+			name:   "指定默认动作",
+			policy: Policy{Rules: []Rule{{Action: ActionIgnore, Category: "dos"}}, Default: ActionWarn},
+			want: map[string]string{
+				"app/deployer createpods":                    ActionWarn,
+				"app/deployer createpods[app]":               ActionWarn,
+				"kube-system/helper getsecrets(admin-token)": ActionWarn,
+				"monitor/agent patchnodes":                   ActionWarn,
+				"monitor/agent deletenodes":                  ActionIgnore,
+			},
+			warned: 4, ignored: 1,
+		},
+		{
+			name: "抑制先于规则",
+			policy: Policy{
+				Rules:        []Rule{{Action: ActionFail}},
+				Suppressions: []Suppression{{SA: "kube-system/*", Reason: "系统组件"}, {SA: "app/deployer", Permission: "createpods[app]"}},
+			},
+			want: map[string]string{
+				"app/deployer createpods":                    ActionFail,
+				"app/deployer createpods[app]":               "suppressed",
+				"kube-system/helper getsecrets(admin-token)": "系统组件",
+				"monitor/agent patchnodes":                   ActionFail,
+				"monitor/agent deletenodes":                  ActionFail,
+			},
+			failed: 3, suppressed: 2,
+		},
+		{
+			name:     "基线中已存在的发现项被忽略",
+			policy:   DefaultPolicy,
+			baseline: []models.Finding{clusterPods, patchNodes, finding("app/other", "createpods", "anyescalate", "critical", 100)},
+			want: map[string]string{
+				"app/deployer createpods":                    ActionIgnore,
+				"kube-system/helper getsecrets(admin-token)": ActionFail,
+				"app/deployer createpods[app]":               ActionWarn,
+				"monitor/agent patchnodes":                   ActionIgnore,
+				"monitor/agent deletenodes":                  ActionIgnore,
+			},
+			failed: 1, warned: 1, ignored: 3,
+			existing: []string{"app/deployer createpods", "monitor/agent patchnodes"},
+		},
+		{
+			name:     "抑制的发现项不标记为已存在",
+			policy:   Policy{Rules: []Rule{{Action: ActionFail}}, Suppressions: []Suppression{{SA: "app/deployer", Reason: "已确认"}}},
+			baseline: []models.Finding{clusterPods},
+			want: map[string]string{
+				"app/deployer createpods":                    "已确认",
+				"app/deployer createpods[app]":               "已确认",
+				"kube-system/helper getsecrets(admin-token)": ActionFail,
+				"monitor/agent patchnodes":                   ActionFail,
+				"monitor/agent deletenodes":                  ActionFail,
+			},
+			failed: 3, suppressed: 2,
+		},
+		{
+			name:     "空基线视为全部为新发现项",
+			policy:   Policy{Rules: []Rule{{Action: ActionFail, Namespace: "app"}}},
+			baseline: []models.Finding{},
+			want: map[string]string{
+				"app/deployer createpods":                    ActionFail,
+				"app/deployer createpods[app]":               ActionFail,
+				"kube-system/helper getsecrets(admin-token)": ActionIgnore,
+				"monitor/agent patchnodes":                   ActionIgnore,
+				"monitor/agent deletenodes":                  ActionIgnore,
+			},
+			failed: 2, ignored: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Evaluate(tt.policy, findings, tt.baseline)
+			if result.Failed != tt.failed || result.Warned != tt.warned || result.Ignored != tt.ignored || result.Suppressed != tt.suppressed {
+				t.Errorf("Failed=%d Warned=%d Ignored=%d Suppressed=%d, 期望 %d %d %d %d",
+					result.Failed, result.Warned, result.Ignored, result.Suppressed, tt.failed, tt.warned, tt.ignored, tt.suppressed)
+			}
+			if result.Passed() != (tt.failed == 0) {
+				t.Errorf("Passed() = %v", result.Passed())
+			}
+			if len(result.Decisions) != len(findings) {
+				t.Fatalf("决策数量 %d, 期望 %d", len(result.Decisions), len(findings))
+			}
+			existing := map[string]bool{}
+			for _, key := range tt.existing {
+				existing[key] = true
+			}
+			ranks := map[string]int{ActionFail: 0, ActionWarn: 1, ActionIgnore: 2}
+			for i, decision := range result.Decisions {
+				key := decision.Finding.SA + " " + decision.Finding.Type
+				got := decision.Action
+				if decision.Suppressed != "" {
+					if decision.Action != ActionIgnore {
+						t.Errorf("%s: 被抑制的发现项动作为 %s", key, decision.Action)
+					}
+					got = decision.Suppressed
+				}
+				if got != tt.want[key] {
+					t.Errorf("%s: 动作 %s, 期望 %s", key, got, tt.want[key])
+				}
+				if decision.Existing != existing[key] {
+					t.Errorf("%s: Existing=%v", key, decision.Existing)
+				}
+				if i > 0 && ranks[result.Decisions[i-1].Action] > ranks[decision.Action] {
+					t.Errorf("决策没有按 fail、warn、ignore 排列: %s 在 %s 之后", key, result.Decisions[i-1].Action)
+				}
+				if tt.order != nil && key != tt.order[i] {
+					t.Errorf("第%d个决策为 %s, 期望 %s", i+1, key, tt.order[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	policy := DefaultPolicy
+	policy.Suppressions = []Suppression{{SA: "kube-system/*", Reason: "系统组件"}}
+	baseline := []models.Finding{patchNodes}
+	result := Evaluate(policy, []models.Finding{deleteNodes, patchNodes, namespacePods, clusterSecrets, clusterPods}, baseline)
+
+	buffer := bytes.Buffer{}
+	if err := WriteJUnit(&buffer, "prod", result); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "junit.xml")
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != string(want) {
+		t.Errorf("JUnit输出与 %s 不一致:\n%s", golden, buffer.String())
+	}
+}
@@ -0,0 +1,108 @@
+package gate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit 以JUnit XML输出评估结果，便于CI系统展示
+// 每个发现项为一个测试用例: fail 为失败，warn 为通过并在输出中给出警告，忽略和抑制的发现项为跳过
+// 参数:
+//   - w: 输出
+//   - cluster: 被扫描集群，作为测试套件名称
+//   - result: 评估结果
+func WriteJUnit(w io.Writer, cluster string, result Result) error {
+	suite := junitTestSuite{Name: "k8sEPDS " + cluster, TestCases: []junitTestCase{}}
+	for _, decision := range result.Decisions {
+		finding := decision.Finding
+		testCase := junitTestCase{ClassName: finding.SA, Name: finding.Type}
+		detail := describe(decision)
+		switch {
+		case decision.Action == ActionFail:
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s 具有 %s 权限(%s)", finding.SA, finding.Type, decision.Rule),
+				Type:    finding.Severity,
+				Text:    detail,
+			}
+			suite.Failures++
+		case decision.Action == ActionWarn:
+			testCase.SystemOut = "[warn] " + detail
+		default:
+			testCase.Skipped = &junitSkipped{Message: skipReason(decision)}
+			suite.Skipped++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	if len(suite.TestCases) == 0 {
+		suite.TestCases = append(suite.TestCases, junitTestCase{ClassName: "k8sEPDS", Name: "未发现关键ServiceAccount"})
+	}
+	suite.Tests = len(suite.TestCases)
+	suites := junitTestSuites{Tests: suite.Tests, Failures: suite.Failures, Skipped: suite.Skipped, Suites: []junitTestSuite{suite}}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// describe 发现项的详细描述
+func describe(decision Decision) string {
+	finding := decision.Finding
+	return fmt.Sprintf("SA: %s\n权限类型: %s\n分类: %s\n范围: %s\n严重程度: %s\nPod: %s\n节点: %s\n角色: %s\n绑定: %s\n规则: %s\n",
+		finding.SA, finding.Type, finding.Kind, finding.Level, finding.Severity, finding.Pod, finding.Node,
+		strings.Join(finding.Roles, ","), strings.Join(finding.RoleBindings, ","), decision.Rule)
+}
+
+// skipReason 被忽略的原因
+func skipReason(decision Decision) string {
+	switch {
+	case decision.Suppressed != "":
+		return "suppressed: " + decision.Suppressed
+	case decision.Existing:
+		return "已存在于基线扫描"
+	default:
+		return "ignored: " + decision.Rule
+	}
+}
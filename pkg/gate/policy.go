@@ -0,0 +1,167 @@
+package gate
+
+import (
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"os"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// 规则动作
+const (
+	ActionFail   = "fail"   // 违反策略，CI以非零状态退出
+	ActionWarn   = "warn"   // 输出警告，不影响退出状态
+	ActionIgnore = "ignore" // 不输出
+)
+
+// severityRanks 严重程度由低到高的排序，用于 minSeverity 比较
+var severityRanks = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// Rule 策略规则，所有非空条件都满足时命中，按顺序取第一条命中的规则
+type Rule struct {
+	Action      string `json:"action"`                // fail/warn/ignore
+	Category    string `json:"category,omitempty"`    // escalate/hijack/dos
+	Kind        string `json:"kind,omitempty"`        // anyescalate/restricthijack 等
+	Scope       string `json:"scope,omitempty"`       // 权限本身的范围 cluster/namespace (见 scan.Scope)
+	Permission  string `json:"permission,omitempty"`  // 完整权限类型或归一化名称(如 createpods)
+	Namespace   string `json:"namespace,omitempty"`   // SA所在命名空间，支持通配符
+	MinSeverity string `json:"minSeverity,omitempty"` // 严重程度不低于该值时命中
+}
+
+// Suppression 已确认接受的发现项，命中后不参与规则评估
+type Suppression struct {
+	SA         string `json:"sa"`                   // SA完整名称(格式:namespace/name)，支持通配符(如 kube-system/*)
+	Permission string `json:"permission,omitempty"` // 为空时抑制该SA的全部发现项
+	Reason     string `json:"reason,omitempty"`     // 抑制原因
+}
+
+// Policy CI门禁策略
+type Policy struct {
+	Rules        []Rule        `json:"rules"`
+	Suppressions []Suppression `json:"suppressions,omitempty"`
+	Default      string        `json:"default,omitempty"` // 没有规则命中时的动作，默认 ignore
+}
+
+// DefaultPolicy 未指定策略文件时使用的策略:
+// 集群范围的提权路径直接失败，其余提权和劫持路径给出警告
+var DefaultPolicy = Policy{
+	Rules: []Rule{
+		{Action: ActionFail, Category: "escalate", Scope: "cluster"},
+		{Action: ActionFail, Kind: "anyescalate"},
+		{Action: ActionWarn, Category: "escalate"},
+		{Action: ActionWarn, Category: "hijack"},
+	},
+	Default: ActionIgnore,
+}
+
+// LoadPolicy 从YAML或JSON文件中读取策略
+// 参数:
+//   - file: 策略文件路径
+func LoadPolicy(file string) (Policy, error) {
+	policy := Policy{}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return policy, fmt.Errorf("读取策略文件失败: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return policy, fmt.Errorf("解析策略文件失败: %w", err)
+	}
+	return policy, policy.Validate()
+}
+
+// Validate 检查规则动作和严重程度是否合法
+func (p Policy) Validate() error {
+	for i, rule := range p.Rules {
+		if !validAction(rule.Action) {
+			return fmt.Errorf("第%d条规则的动作无效: %q", i+1, rule.Action)
+		}
+		if rule.MinSeverity != "" && severityRanks[rule.MinSeverity] == 0 {
+			return fmt.Errorf("第%d条规则的严重程度无效: %q", i+1, rule.MinSeverity)
+		}
+	}
+	if p.Default != "" && !validAction(p.Default) {
+		return fmt.Errorf("默认动作无效: %q", p.Default)
+	}
+	for i, suppression := range p.Suppressions {
+		if suppression.SA == "" {
+			return fmt.Errorf("第%d条抑制缺少sa", i+1)
+		}
+	}
+	return nil
+}
+
+// Marshal 将策略输出为YAML，便于以默认策略为模板编写策略文件
+func (p Policy) Marshal() ([]byte, error) {
+	return yaml.Marshal(p)
+}
+
+// Matches 判断规则是否命中发现项
+func (r Rule) Matches(finding models.Finding) bool {
+	if r.Category != "" && scan.Category(finding.Kind) != r.Category {
+		return false
+	}
+	if r.Kind != "" && finding.Kind != r.Kind {
+		return false
+	}
+	if r.Scope != "" && finding.Level != r.Scope {
+		return false
+	}
+	if r.Permission != "" && !matchPermission(r.Permission, finding.Type) {
+		return false
+	}
+	if r.Namespace != "" && !matchPattern(r.Namespace, finding.Namespace) {
+		return false
+	}
+	if r.MinSeverity != "" && severityRanks[finding.Severity] < severityRanks[r.MinSeverity] {
+		return false
+	}
+	return true
+}
+
+// String 规则的简短描述，用于输出命中原因
+func (r Rule) String() string {
+	conditions := []string{}
+	for _, condition := range [][2]string{
+		{"category", r.Category}, {"kind", r.Kind}, {"scope", r.Scope},
+		{"permission", r.Permission}, {"namespace", r.Namespace}, {"minSeverity", r.MinSeverity},
+	} {
+		if condition[1] != "" {
+			conditions = append(conditions, condition[0]+"="+condition[1])
+		}
+	}
+	if len(conditions) == 0 {
+		return r.Action + " *"
+	}
+	return r.Action + " " + strings.Join(conditions, ",")
+}
+
+// Matches 判断抑制是否命中发现项
+func (s Suppression) Matches(finding models.Finding) bool {
+	if !matchPattern(s.SA, finding.SA) {
+		return false
+	}
+	return s.Permission == "" || matchPermission(s.Permission, finding.Type)
+}
+
+// validAction 判断是否为合法的规则动作
+func validAction(action string) bool {
+	return action == ActionFail || action == ActionWarn || action == ActionIgnore
+}
+
+// matchPermission 权限条件既可以是完整类型，也可以是归一化后的名称
+func matchPermission(pattern string, permType string) bool {
+	return pattern == permType || pattern == scan.DispatchName(permType)
+}
+
+// matchPattern 支持 * 通配符的匹配，格式错误时按字符串相等处理
+func matchPattern(pattern string, value string) bool {
+	matched, err := path.Match(pattern, value)
+	if err != nil {
+		return pattern == value
+	}
+	return matched
+}
@@ -0,0 +1,56 @@
+package gui
+
+import (
+	"k8sEPDS/conf"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newConfigForm 构建配置编辑表单，替代控制台下的 conf.UpdateConfig
+// 参数:
+//   - status: 显示保存结果的状态栏
+func newConfigForm(status *widget.Label) fyne.CanvasObject {
+	fields := conf.Fields()
+	entries := make([]*widget.Entry, len(fields))
+	form := widget.NewForm()
+	section := ""
+	for i, field := range fields {
+		if field.Section != section {
+			section = field.Section
+			form.Append("", widget.NewLabelWithStyle("=== "+section+" ===", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+		if field.Secret {
+			entries[i] = widget.NewPasswordEntry()
+		} else {
+			entries[i] = widget.NewEntry()
+		}
+		entries[i].SetText(field.Get())
+		form.Append(field.Label, entries[i])
+	}
+	form.SubmitText = "保存"
+	form.OnSubmit = func() {
+		for i, field := range fields {
+			if err := field.Set(entries[i].Text); err != nil {
+				status.SetText("[X] " + field.Label + ": " + err.Error())
+				return
+			}
+		}
+		if err := conf.ValidateConfig(conf.Config); err != nil {
+			status.SetText("[X] 配置验证失败: " + err.Error())
+			return
+		}
+		if err := conf.Save(); err != nil {
+			status.SetText("[X] " + err.Error())
+			return
+		}
+		status.SetText("[√] 配置已保存: " + conf.ConfigFile())
+	}
+	form.CancelText = "重置"
+	form.OnCancel = func() {
+		for i, field := range fields {
+			entries[i].SetText(field.Get())
+		}
+	}
+	return form
+}
@@ -0,0 +1,158 @@
+package gui
+
+import (
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/exploit"
+	"sort"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// exploitView 利用面板，收集 exploit.Params 中声明的参数并运行利用模块
+// 扫描结果在后台goroutine中写入，关键SA和候选SA由 mu 保护
+type exploitView struct {
+	status      *widget.Label
+	mu          sync.Mutex
+	criticalSAs []models.CriticalSA
+	candidates  []models.CriticalSAWrapper // 具有所选模块对应权限的关键SA
+
+	module   *widget.Select
+	sa       *widget.Select
+	form     *widget.Form
+	run      *widget.Button
+	inputs   map[string]*widget.Entry
+	confirms map[string]*widget.Check
+}
+
+// newExploitView 创建利用面板
+// 参数:
+//   - status: 显示运行结果的状态栏
+func newExploitView(status *widget.Label) *exploitView {
+	v := &exploitView{status: status}
+	modules := make([]string, 0, len(exploit.Modules))
+	for name := range exploit.Modules {
+		modules = append(modules, name)
+	}
+	sort.Strings(modules)
+	v.sa = widget.NewSelect(nil, nil)
+	v.form = widget.NewForm()
+	v.module = widget.NewSelect(modules, func(string) {
+		v.refreshCandidates()
+		v.refreshForm()
+	})
+	v.run = widget.NewButton("执行", func() {
+		v.execute()
+	})
+	return v
+}
+
+// content 构建利用面板
+func (v *exploitView) content() fyne.CanvasObject {
+	top := widget.NewForm(
+		widget.NewFormItem("利用模块", v.module),
+		widget.NewFormItem("使用SA", v.sa),
+	)
+	return container.NewVScroll(container.NewVBox(top, widget.NewSeparator(), v.form, v.run))
+}
+
+// update 使用新的扫描结果刷新可选的SA
+func (v *exploitView) update(criticalSAs []models.CriticalSA) {
+	v.mu.Lock()
+	v.criticalSAs = criticalSAs
+	v.mu.Unlock()
+	v.refreshCandidates()
+}
+
+// refreshCandidates 列出可用于所选模块的关键SA
+func (v *exploitView) refreshCandidates() {
+	v.mu.Lock()
+	v.candidates = exploit.Candidates(v.criticalSAs, v.module.Selected)
+	options := []string{}
+	for _, candidate := range v.candidates {
+		options = append(options, candidate.Crisa.SA0.Name+" "+candidate.Type)
+	}
+	v.mu.Unlock()
+	v.sa.SetOptions(options)
+	v.sa.ClearSelected()
+	if len(options) != 0 {
+		v.sa.SetSelectedIndex(0)
+	}
+}
+
+// snapshot 返回当前候选SA的副本
+func (v *exploitView) snapshot() []models.CriticalSAWrapper {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]models.CriticalSAWrapper{}, v.candidates...)
+}
+
+// refreshForm 根据所选模块的参数重建表单
+func (v *exploitView) refreshForm() {
+	v.form.Items = nil
+	v.inputs = map[string]*widget.Entry{}
+	v.confirms = map[string]*widget.Check{}
+	for _, param := range exploit.Params[v.module.Selected] {
+		if param.Confirm {
+			check := widget.NewCheck("", nil)
+			v.confirms[param.Name] = check
+			v.form.Append(param.Label, check)
+			continue
+		}
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder(param.Name)
+		v.inputs[param.Name] = entry
+		v.form.Append(param.Label, entry)
+	}
+	v.form.Refresh()
+}
+
+// values 收集表单中填写的参数
+func (v *exploitView) values() exploit.Values {
+	values := exploit.Values{}
+	for name, entry := range v.inputs {
+		values[name] = entry.Text
+	}
+	for name, check := range v.confirms {
+		if check.Checked {
+			values[name] = "Y"
+		} else {
+			values[name] = "N"
+		}
+	}
+	return values
+}
+
+// execute 使用表单参数运行所选利用模块，模块输出仍打印到控制台
+func (v *exploitView) execute() {
+	module, ok := exploit.Modules[v.module.Selected]
+	if !ok {
+		v.status.SetText("[X] 请选择利用模块")
+		return
+	}
+	index := v.sa.SelectedIndex()
+	candidates := v.snapshot()
+	if index < 0 || index >= len(candidates) {
+		v.status.SetText("[X] 没有可用于该模块的关键SA，请先扫描")
+		return
+	}
+	sa, name, values := candidates[index].Crisa, v.module.Selected, v.values()
+	v.run.Disable()
+	v.status.SetText(fmt.Sprintf("[msg] 正在使用 %s 执行 %s", sa.SA0.Name, name))
+	go func() {
+		defer v.run.Enable()
+		success, err := module([]models.CriticalSA{sa}, conf.Config.SSH, values)
+		switch {
+		case err != nil:
+			v.status.SetText(fmt.Sprintf("[X] %s 执行失败: %s", name, err.Error()))
+		case success:
+			v.status.SetText(fmt.Sprintf("[√] %s 执行完成", name))
+		default:
+			v.status.SetText(fmt.Sprintf("[msg] %s 已执行，详细输出见控制台", name))
+		}
+	}()
+}
@@ -0,0 +1,206 @@
+package gui
+
+import (
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// findingColumns 发现项表格的列
+var findingColumns = []struct {
+	Title string
+	Value func(models.Finding) string
+	Width float32
+}{
+	{"SA", func(f models.Finding) string { return f.SA }, 220},
+	{"权限类型", func(f models.Finding) string { return f.Type }, 220},
+	{"分类", func(f models.Finding) string { return f.Kind }, 130},
+	{"范围", func(f models.Finding) string { return f.Level }, 90},
+	{"严重程度", func(f models.Finding) string { return f.Severity }, 80},
+	{"风险分", func(f models.Finding) string { return strconv.Itoa(f.Score) }, 60},
+	{"Pod", func(f models.Finding) string { return f.Pod }, 180},
+	{"节点", func(f models.Finding) string { return f.Node }, 120},
+}
+
+// scoreColumn 风险分所在的列，按数值排序
+const scoreColumn = 5
+
+// findingsView 可排序的发现项表格和SA详情面板
+// 扫描结果在后台goroutine中写入，表格回调在界面线程中读取，行和排序状态由 mu 保护
+// 刷新表格时会同步调用表格回调，调用 Refresh 前必须释放 mu
+type findingsView struct {
+	mu          sync.Mutex
+	criticalSAs []models.CriticalSA
+	rows        []models.Finding
+	sortColumn  int
+	descending  bool
+	table       *widget.Table
+	detail      *widget.Label
+}
+
+// newFindingsView 创建发现项视图，默认按风险分降序
+func newFindingsView() *findingsView {
+	v := &findingsView{sortColumn: scoreColumn, descending: true, detail: widget.NewLabel("选择一个发现项查看SA详情")}
+	v.detail.Wrapping = fyne.TextWrapWord
+	v.table = widget.NewTableWithHeaders(
+		func() (int, int) {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+			return len(v.rows), len(findingColumns)
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, cell fyne.CanvasObject) {
+			v.mu.Lock()
+			text := ""
+			if id.Row < len(v.rows) {
+				text = findingColumns[id.Col].Value(v.rows[id.Row])
+			}
+			v.mu.Unlock()
+			cell.(*widget.Label).SetText(text)
+		},
+	)
+	v.table.ShowHeaderColumn = false
+	v.table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewButton("", nil)
+	}
+	v.table.UpdateHeader = func(id widget.TableCellID, header fyne.CanvasObject) {
+		button := header.(*widget.Button)
+		title := findingColumns[id.Col].Title
+		v.mu.Lock()
+		sortColumn, descending := v.sortColumn, v.descending
+		v.mu.Unlock()
+		if id.Col == sortColumn {
+			if descending {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		button.SetText(title)
+		column := id.Col
+		button.OnTapped = func() { v.sortBy(column) }
+	}
+	for i, column := range findingColumns {
+		v.table.SetColumnWidth(i, column.Width)
+	}
+	v.table.OnSelected = func(id widget.TableCellID) {
+		v.selectRow(id.Row)
+	}
+	return v
+}
+
+// content 构建发现项视图
+func (v *findingsView) content() fyne.CanvasObject {
+	split := container.NewHSplit(v.table, container.NewVScroll(v.detail))
+	split.Offset = 0.7
+	return split
+}
+
+// update 使用新的扫描结果刷新表格
+func (v *findingsView) update(criticalSAs []models.CriticalSA) {
+	rows := scan.Findings(criticalSAs)
+	v.mu.Lock()
+	v.criticalSAs, v.rows = criticalSAs, rows
+	v.sort()
+	v.mu.Unlock()
+	v.detail.SetText("选择一个发现项查看SA详情")
+	v.table.UnselectAll()
+	v.table.Refresh()
+}
+
+// sortBy 按列排序，重复选择同一列时切换升降序
+func (v *findingsView) sortBy(column int) {
+	if column < 0 || column >= len(findingColumns) {
+		return
+	}
+	v.mu.Lock()
+	if column == v.sortColumn {
+		v.descending = !v.descending
+	} else {
+		v.sortColumn, v.descending = column, false
+	}
+	v.sort()
+	v.mu.Unlock()
+	v.table.Refresh()
+}
+
+// snapshot 返回当前行的副本
+func (v *findingsView) snapshot() []models.Finding {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]models.Finding{}, v.rows...)
+}
+
+// sort 按当前排序列排序，风险分按数值比较，调用方需持有 mu
+func (v *findingsView) sort() {
+	value := findingColumns[v.sortColumn].Value
+	less := func(a models.Finding, b models.Finding) bool {
+		if v.sortColumn == scoreColumn {
+			return a.Score < b.Score
+		}
+		return value(a) < value(b)
+	}
+	sort.SliceStable(v.rows, func(i, j int) bool {
+		if v.descending {
+			return less(v.rows[j], v.rows[i])
+		}
+		return less(v.rows[i], v.rows[j])
+	})
+}
+
+// selectRow 在详情面板中显示发现项对应SA的角色和绑定
+func (v *findingsView) selectRow(row int) {
+	v.mu.Lock()
+	if row < 0 || row >= len(v.rows) {
+		v.mu.Unlock()
+		return
+	}
+	finding, criticalSAs := v.rows[row], v.criticalSAs
+	v.mu.Unlock()
+	for _, criticalSA := range criticalSAs {
+		if criticalSA.SA0.Name == finding.SA {
+			v.detail.SetText(describeSA(criticalSA))
+			return
+		}
+	}
+}
+
+// describeSA 生成SA详情文本
+func describeSA(criticalSA models.CriticalSA) string {
+	var b strings.Builder
+	sa := criticalSA.SA0
+	fmt.Fprintf(&b, "SA: %s\n", sa.Name)
+	fmt.Fprintf(&b, "权限范围: %s\n", criticalSA.Level)
+	fmt.Fprintf(&b, "被挂载: %t\n", sa.IsMounted)
+	if sa.IsMounted {
+		fmt.Fprintf(&b, "Pod: %s/%s (节点 %s)\n", sa.SAPod.Namespace, sa.SAPod.Name, sa.SAPod.NodeName)
+	}
+	fmt.Fprintf(&b, "\n高危权限:\n")
+	for _, permType := range criticalSA.Type {
+		fmt.Fprintf(&b, "  %s\n", permType)
+	}
+	fmt.Fprintf(&b, "\n绑定:\n")
+	for _, binding := range sa.RoleBindings {
+		fmt.Fprintf(&b, "  %s\n", binding)
+	}
+	fmt.Fprintf(&b, "\n角色:\n")
+	for _, grant := range scan.WhatCan(&sa) {
+		resource := grant.Resource
+		if grant.ResourceName != "" {
+			resource += "(" + grant.ResourceName + ")"
+		}
+		if grant.Namespace != "" {
+			resource += "[" + grant.Namespace + "]"
+		}
+		fmt.Fprintf(&b, "  %s: %s %v\n", grant.Role, resource, grant.Verbs)
+	}
+	return b.String()
+}
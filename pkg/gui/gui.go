@@ -0,0 +1,140 @@
+package gui
+
+import (
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Scanner 执行一次扫描，返回全部SA、其中的关键SA和无法读取的资源(与 scan.ScanCluster 一致)
+type Scanner func(node string) (map[string]*models.SA, []models.CriticalSA, []models.CoverageGap, error)
+
+// GUI 桌面前端，包含配置编辑、发现项表格、SA详情和利用面板
+// 只依赖 fyne.App，可以使用 fyne.io/fyne/v2/test 的驱动在无界面环境中测试
+// 扫描在后台goroutine中执行，扫描结果由 mu 保护，同一时间只进行一次扫描
+type GUI struct {
+	app        fyne.App
+	window     fyne.Window
+	scanner    Scanner
+	status     *widget.Label
+	scanButton *widget.Button
+
+	scanning    sync.Mutex
+	mu          sync.Mutex
+	sas         map[string]*models.SA
+	criticalSAs []models.CriticalSA
+
+	findings *findingsView
+	exploit  *exploitView
+}
+
+// New 创建桌面前端
+// 参数:
+//   - app: fyne应用，桌面使用 app.New，测试使用 test.NewApp
+//   - scanner: 扫描实现，为空时使用 scan.ScanCluster
+func New(app fyne.App, scanner Scanner) *GUI {
+	if scanner == nil {
+		scanner = scan.ScanCluster
+	}
+	g := &GUI{app: app, scanner: scanner, status: widget.NewLabel("尚未扫描")}
+	g.findings = newFindingsView()
+	g.exploit = newExploitView(g.status)
+	g.window = app.NewWindow("k8sEPDS")
+	g.window.SetContent(g.content())
+	g.window.Resize(fyne.NewSize(1100, 700))
+	return g
+}
+
+// Window 返回主窗口
+func (g *GUI) Window() fyne.Window {
+	return g.window
+}
+
+// ShowAndRun 显示主窗口并运行事件循环
+func (g *GUI) ShowAndRun() {
+	g.window.ShowAndRun()
+}
+
+// content 构建主窗口内容
+func (g *GUI) content() fyne.CanvasObject {
+	g.scanButton = widget.NewButton("扫描", func() {
+		go g.Scan()
+	})
+	tabs := container.NewAppTabs(
+		container.NewTabItem("配置", newConfigForm(g.status)),
+		container.NewTabItem("发现项", g.findings.content()),
+		container.NewTabItem("利用", g.exploit.content()),
+	)
+	return container.NewBorder(container.NewHBox(g.scanButton, g.status), nil, nil, nil, tabs)
+}
+
+// Scan 使用当前配置扫描集群并刷新发现项和利用面板，已有扫描在进行时直接返回
+func (g *GUI) Scan() {
+	if !g.scanning.TryLock() {
+		return
+	}
+	defer g.scanning.Unlock()
+	g.scanButton.Disable()
+	defer g.scanButton.Enable()
+
+	g.status.SetText("扫描中...")
+	start := time.Now()
+	sas, criticalSAs, coverage, err := g.scanner(conf.Config.SSH.Nodename)
+	if err != nil {
+		g.status.SetText("扫描失败: " + err.Error())
+		return
+	}
+	g.mu.Lock()
+	g.sas, g.criticalSAs = sas, criticalSAs
+	g.mu.Unlock()
+	g.findings.update(criticalSAs)
+	g.exploit.update(criticalSAs)
+	status := fmt.Sprintf("%s 扫描完成，关键SA %d 个，耗时 %s",
+		start.Format(time.DateTime), len(criticalSAs), time.Since(start).Round(time.Millisecond))
+	if len(coverage) > 0 {
+		status += fmt.Sprintf("，%d 项资源无法读取，结果可能不完整", len(coverage))
+	}
+	g.status.SetText(status)
+}
+
+// CriticalSAs 返回最近一次扫描得到的关键SA
+func (g *GUI) CriticalSAs() []models.CriticalSA {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.criticalSAs
+}
+
+// Findings 返回发现项表格当前的行(已排序)
+func (g *GUI) Findings() []models.Finding {
+	return g.findings.snapshot()
+}
+
+// Candidates 返回利用面板中可用于所选模块的关键SA
+// 参数:
+//   - module: 利用模块名称
+func (g *GUI) Candidates(module string) []models.CriticalSAWrapper {
+	g.exploit.module.SetSelected(module)
+	return g.exploit.snapshot()
+}
+
+// SortBy 按列排序发现项，重复选择同一列时切换升降序
+func (g *GUI) SortBy(column int) {
+	g.findings.sortBy(column)
+}
+
+// SelectFinding 选中发现项并在详情面板中显示对应的SA
+func (g *GUI) SelectFinding(row int) {
+	g.findings.selectRow(row)
+}
+
+// Detail 返回详情面板当前显示的文本
+func (g *GUI) Detail() string {
+	return g.findings.detail.Text
+}
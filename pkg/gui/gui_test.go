@@ -0,0 +1,164 @@
+package gui
+
+import (
+	"errors"
+	"k8sEPDS/models"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// fixtureSAs 测试使用的关键SA，其中只有 app/deployer 的Pod运行在受控节点上
+func fixtureSAs() []models.CriticalSA {
+	return []models.CriticalSA{
+		{
+			InNode: true,
+			Type:   []string{"createpods", "getsecrets[app]"},
+			Level:  "cluster",
+			SA0: models.SA{
+				IsMounted:    true,
+				Name:         "app/deployer",
+				SAPod:        models.Pod{Namespace: "app", Name: "deployer-0", NodeName: "node1"},
+				RoleBindings: []string{"deployer-binding"},
+			},
+		},
+		{
+			Type:  []string{"getsecrets[monitoring]"},
+			Level: "namespace",
+			SA0: models.SA{
+				IsMounted: true,
+				Name:      "monitoring/agent",
+				SAPod:     models.Pod{Namespace: "monitoring", Name: "agent-0", NodeName: "node2"},
+			},
+		},
+	}
+}
+
+// fixtureScanner 返回固定扫描结果的扫描实现
+func fixtureScanner(criticalSAs []models.CriticalSA, err error) Scanner {
+	return func(string) (map[string]*models.SA, []models.CriticalSA, []models.CoverageGap, error) {
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		sas := map[string]*models.SA{}
+		for i := range criticalSAs {
+			sas[criticalSAs[i].SA0.Name] = &criticalSAs[i].SA0
+		}
+		return sas, criticalSAs, nil, nil
+	}
+}
+
+func TestScanFindings(t *testing.T) {
+	g := New(test.NewApp(), fixtureScanner(fixtureSAs(), nil))
+	g.Scan()
+
+	if !strings.Contains(g.status.Text, "关键SA 2 个") {
+		t.Errorf("状态栏 %q", g.status.Text)
+	}
+	findings := g.Findings()
+	if len(findings) != 3 {
+		t.Fatalf("发现项 %d 个, 期望 3 个: %+v", len(findings), findings)
+	}
+	if !sort.SliceIsSorted(findings, func(i, j int) bool { return findings[i].Score > findings[j].Score }) {
+		t.Errorf("默认应按风险分降序: %+v", findings)
+	}
+	if rows, _ := g.findings.table.Length(); rows != 3 {
+		t.Errorf("表格行数 %d, 期望 3", rows)
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	g := New(test.NewApp(), fixtureScanner(fixtureSAs(), nil))
+	g.Scan()
+
+	g.SortBy(0)
+	findings := g.Findings()
+	if findings[0].SA != "app/deployer" || findings[len(findings)-1].SA != "monitoring/agent" {
+		t.Errorf("按SA升序: %+v", findings)
+	}
+	g.SortBy(0)
+	findings = g.Findings()
+	if findings[0].SA != "monitoring/agent" {
+		t.Errorf("再次选择同一列应切换为降序: %+v", findings)
+	}
+	g.SortBy(len(findingColumns))
+	if got := g.Findings(); got[0].SA != "monitoring/agent" {
+		t.Errorf("无效的列不应改变排序: %+v", got)
+	}
+}
+
+func TestSelectFinding(t *testing.T) {
+	g := New(test.NewApp(), fixtureScanner(fixtureSAs(), nil))
+	g.Scan()
+	g.SortBy(0)
+
+	g.SelectFinding(0)
+	detail := g.Detail()
+	for _, want := range []string{"SA: app/deployer", "app/deployer-0", "createpods", "deployer-binding"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("详情中没有 %q:\n%s", want, detail)
+		}
+	}
+	g.SelectFinding(100)
+	if g.Detail() != detail {
+		t.Error("越界的行不应改变详情")
+	}
+}
+
+func TestScanError(t *testing.T) {
+	g := New(test.NewApp(), fixtureScanner(nil, errors.New("无法连接API Server")))
+	g.Scan()
+
+	if g.status.Text != "扫描失败: 无法连接API Server" {
+		t.Errorf("状态栏 %q", g.status.Text)
+	}
+	if len(g.Findings()) != 0 || g.CriticalSAs() != nil {
+		t.Error("扫描失败时不应有发现项")
+	}
+	if g.scanButton.Disabled() {
+		t.Error("扫描结束后应恢复扫描按钮")
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	g := New(test.NewApp(), fixtureScanner(fixtureSAs(), nil))
+	g.Scan()
+
+	candidates := g.Candidates("getsecrets")
+	if len(candidates) != 1 || candidates[0].Crisa.SA0.Name != "app/deployer" || candidates[0].Type != "getsecrets[app]" {
+		t.Errorf("只有受控节点上的SA可用于利用: %+v", candidates)
+	}
+	if candidates := g.Candidates("deletenodes"); len(candidates) != 0 {
+		t.Errorf("没有对应权限的模块不应有候选SA: %+v", candidates)
+	}
+	if len(g.exploit.sa.Options) != 0 {
+		t.Errorf("SA选项 %v", g.exploit.sa.Options)
+	}
+}
+
+// TestConcurrentScan 多次点击扫描时只进行一次扫描，扫描时可以同时读取结果，使用 -race 运行
+func TestConcurrentScan(t *testing.T) {
+	g := New(test.NewApp(), fixtureScanner(fixtureSAs(), nil))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			g.Scan()
+		}()
+		go func() {
+			defer wg.Done()
+			g.Findings()
+			g.CriticalSAs()
+			g.exploit.snapshot()
+		}()
+	}
+	wg.Wait()
+	g.Scan()
+	if len(g.Findings()) != 3 {
+		t.Errorf("发现项 %+v", g.Findings())
+	}
+}
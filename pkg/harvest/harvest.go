@@ -0,0 +1,157 @@
+package harvest
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"k8sEPDS/pkg/tokensource"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Credential 在受控节点上收集到的一个ServiceAccount Token
+type Credential struct {
+	Path     string             `json:"path"`            // Token文件在节点上的路径
+	Token    string             `json:"token,omitempty"` // Token原文，输出时可以隐藏
+	Claims   tokensource.Claims `json:"claims"`          // Token中的声明
+	SA       string             `json:"sa"`              // ServiceAccount(格式: namespace/name)
+	Pod      string             `json:"pod"`             // 挂载该Token的Pod(格式: namespace/name)，API列表中没有时取自Token中的声明
+	PodUID   string             `json:"podUid"`
+	Node     string             `json:"node"`     // Pod所在节点
+	Listed   bool               `json:"listed"`   // Pod是否在API列出的Pod中，false 表示 GetPods 遗漏的Pod(如静态Pod、已删除但卷未清理的Pod)
+	Expired  bool               `json:"expired"`  // Token是否已过期
+	Types    []string           `json:"types"`    // SA具有的高危权限类型，不是关键SA时为空
+	Level    string             `json:"level"`    // 权限范围(cluster/namespace)，有任一集群范围的权限时为cluster
+	Severity string             `json:"severity"` // 最高的严重程度，不是关键SA时为空
+	Score    int                `json:"score"`    // 最高的风险分
+}
+
+// Skipped 读取失败或不是ServiceAccount Token的文件
+type Skipped struct {
+	Path   string `json:"path"`
+	PodUID string `json:"podUid"`
+	Reason string `json:"reason"`
+}
+
+// Result 一次收集的结果
+type Result struct {
+	Node        string               `json:"node"`
+	Source      string               `json:"source"`      // 读取Token的方式
+	Credentials []Credential         `json:"credentials"` // 按可用性和风险排序
+	Skipped     []Skipped            `json:"skipped"`
+	Coverage    []models.CoverageGap `json:"-"` // 扫描集群时无法读取的资源，输出JSON时转换为报告中的覆盖缺口
+}
+
+// Node 收集受控节点上全部Pod挂载的Token，并与集群扫描的关键SA关联后排序
+// 参数:
+//   - ctx: 上下文
+//   - node: 受控节点的SSH及Token读取配置，读取方式需要支持枚举(ssh/local/cri)
+func Node(ctx context.Context, node models.SSHConfig) (Result, error) {
+	source, err := tokensource.For(node)
+	if err != nil {
+		return Result{}, err
+	}
+	harvester, ok := source.(tokensource.Harvester)
+	if !ok {
+		return Result{}, fmt.Errorf("Token读取方式 %s 不支持枚举节点上的Token，请使用 ssh、local 或 cri", source.Name())
+	}
+	tokens, err := harvester.Harvest(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("收集节点 %s 上的Token失败: %w", node.Nodename, err)
+	}
+
+	coverage := &scan.Coverage{}
+	index, err := scan.BuildIndex(coverage, node.Nodename)
+	if err != nil {
+		return Result{}, fmt.Errorf("扫描集群失败: %w", err)
+	}
+	sas := index.SaBinding(coverage)
+	index.MarkMounted(sas)
+	criticalSAs := scan.GetCriticalSA(sas, node.Nodename)
+
+	result := Rank(tokens, index.PodByUID, criticalSAs, time.Now())
+	result.Node, result.Source, result.Coverage = node.Nodename, source.Name(), coverage.Gaps()
+	return result, nil
+}
+
+// Rank 解码Token并与关键SA关联，按未过期优先、风险分从高到低、高危权限数量从多到少排序
+// 参数:
+//   - tokens: 在节点上找到的Token
+//   - podByUID: 根据UID查找API列出的Pod
+//   - criticalSAs: 集群扫描得到的关键SA
+//   - now: 判断Token是否过期的时间
+func Rank(tokens []tokensource.NodeToken, podByUID func(uid string) (models.Pod, bool), criticalSAs []models.CriticalSA, now time.Time) Result {
+	critical := map[string][]models.CriticalSA{}
+	for _, criticalSA := range criticalSAs {
+		critical[criticalSA.SA0.Name] = append(critical[criticalSA.SA0.Name], criticalSA)
+	}
+
+	result := Result{Credentials: []Credential{}, Skipped: []Skipped{}}
+	for _, token := range tokens {
+		if token.Err != nil {
+			result.Skipped = append(result.Skipped, Skipped{Path: token.Path, PodUID: token.PodUID, Reason: token.Err.Error()})
+			continue
+		}
+		claims, err := tokensource.DecodeClaims(token.Token)
+		if err != nil {
+			result.Skipped = append(result.Skipped, Skipped{Path: token.Path, PodUID: token.PodUID, Reason: err.Error()})
+			continue
+		}
+		credential := Credential{
+			Path:    token.Path,
+			Token:   token.Token,
+			Claims:  claims,
+			SA:      claims.SA(),
+			Expired: claims.Expired(now),
+		}
+		uid := token.PodUID
+		if uid == "" {
+			uid = claims.PodUID
+		}
+		credential.PodUID = uid
+		if pod, listed := podByUID(uid); listed {
+			credential.Pod, credential.Node, credential.Listed = pod.Namespace+"/"+pod.Name, pod.NodeName, true
+		} else {
+			credential.Node = claims.Node
+			if claims.Pod != "" {
+				credential.Pod = claims.Namespace + "/" + claims.Pod
+			}
+		}
+		for _, criticalSA := range critical[credential.SA] {
+			for _, permType := range criticalSA.Type {
+				if slices.Contains(credential.Types, permType) {
+					continue
+				}
+				credential.Types = append(credential.Types, permType)
+				level := scan.Scope(permType)
+				if credential.Level != "cluster" {
+					credential.Level = level
+				}
+				severity, score := scan.Severity(scan.Kind(level, permType))
+				if score > credential.Score {
+					credential.Severity, credential.Score = severity, score
+				}
+			}
+		}
+		result.Credentials = append(result.Credentials, credential)
+	}
+	sort.SliceStable(result.Credentials, func(i, j int) bool {
+		a, b := result.Credentials[i], result.Credentials[j]
+		if a.Expired != b.Expired {
+			return !a.Expired
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Types) != len(b.Types) {
+			return len(a.Types) > len(b.Types)
+		}
+		if a.SA != b.SA {
+			return a.SA < b.SA
+		}
+		return a.Path < b.Path
+	})
+	return result
+}
@@ -0,0 +1,233 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"k8sEPDS/models"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// scansBucket 保存扫描记录的bucket名称
+var scansBucket = []byte("scans")
+
+// DB 基于本地文件的扫描历史数据库
+type DB struct {
+	db *bolt.DB
+}
+
+// FindingAge 发现项(SA+权限类型)的存续时间
+type FindingAge struct {
+	SA        string    // ServiceAccount完整名称
+	Type      string    // 高危权限类型
+	Namespace string    // SA所在的命名空间
+	Severity  string    // 严重程度
+	FirstSeen time.Time // 首次发现时间
+	LastSeen  time.Time // 最后一次发现时间
+	Open      bool      // 在最近一次扫描中是否仍然存在
+}
+
+// TrendPoint 某次扫描中一个命名空间的发现项统计
+type TrendPoint struct {
+	ScanID   string    // 扫描记录标识
+	Time     time.Time // 扫描时间
+	Findings int       // 发现项数量
+	Score    int       // 风险分之和
+}
+
+// NamespaceTrend 命名空间的发现项变化趋势
+type NamespaceTrend struct {
+	Namespace string
+	Points    []TrendPoint // 按扫描时间排序
+}
+
+// Open 打开(或创建)扫描历史数据库
+// 参数:
+//   - path: 数据库文件路径
+//
+// 返回:
+//   - *DB: 数据库实例，使用完毕后需调用 Close
+//   - error: 错误信息
+func Open(path string) (*DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("打开扫描历史数据库失败: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(scansBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化扫描历史数据库失败: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close 关闭数据库
+func (h *DB) Close() error {
+	return h.db.Close()
+}
+
+// SaveScan 保存一次扫描记录
+func (h *DB) SaveScan(record models.ScanRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化扫描记录失败: %w", err)
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(scansBucket).Put(scanKey(record), data)
+	})
+}
+
+// ListScans 按扫描时间从旧到新列出扫描记录
+// 参数:
+//   - cluster: 只返回该集群的扫描记录，为空时返回全部
+func (h *DB) ListScans(cluster string) ([]models.ScanRecord, error) {
+	result := []models.ScanRecord{}
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(scansBucket).ForEach(func(_, v []byte) error {
+			var record models.ScanRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("解析扫描记录失败: %w", err)
+			}
+			if cluster == "" || record.Cluster == cluster {
+				result = append(result, record)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// FindingAges 统计每个发现项(SA+权限类型)首次和最后一次出现的时间
+func (h *DB) FindingAges(cluster string) ([]FindingAge, error) {
+	scans, err := h.ListScans(cluster)
+	if err != nil {
+		return nil, err
+	}
+	ages := map[string]*FindingAge{}
+	for _, record := range scans {
+		for _, finding := range record.Findings {
+			key := findingKey(finding)
+			age, exists := ages[key]
+			if !exists {
+				age = &FindingAge{
+					SA:        finding.SA,
+					Type:      finding.Type,
+					Namespace: finding.Namespace,
+					FirstSeen: record.Time,
+				}
+				ages[key] = age
+			}
+			age.Severity = finding.Severity
+			age.LastSeen = record.Time
+		}
+	}
+	result := make([]FindingAge, 0, len(ages))
+	for _, age := range ages {
+		if len(scans) != 0 {
+			age.Open = age.LastSeen.Equal(scans[len(scans)-1].Time)
+		}
+		result = append(result, *age)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].FirstSeen.Equal(result[j].FirstSeen) {
+			return result[i].FirstSeen.Before(result[j].FirstSeen)
+		}
+		return findingKeyOf(result[i].SA, result[i].Type) < findingKeyOf(result[j].SA, result[j].Type)
+	})
+	return result, nil
+}
+
+// MeanTimeToRemediation 计算发现项的平均修复时间
+// 发现项从出现到第一次在扫描中消失算作一次修复，再次出现后重新计时
+// 返回:
+//   - time.Duration: 平均修复时间
+//   - int: 已修复的发现项次数，为0时平均修复时间无意义
+//   - error: 错误信息
+func (h *DB) MeanTimeToRemediation(cluster string) (time.Duration, int, error) {
+	scans, err := h.ListScans(cluster)
+	if err != nil {
+		return 0, 0, err
+	}
+	openSince := map[string]time.Time{}
+	var total time.Duration
+	count := 0
+	for _, record := range scans {
+		present := map[string]bool{}
+		for _, finding := range record.Findings {
+			key := findingKey(finding)
+			present[key] = true
+			if _, open := openSince[key]; !open {
+				openSince[key] = record.Time
+			}
+		}
+		for key, since := range openSince {
+			if !present[key] {
+				total += record.Time.Sub(since)
+				count++
+				delete(openSince, key)
+			}
+		}
+	}
+	if count == 0 {
+		return 0, 0, nil
+	}
+	return total / time.Duration(count), count, nil
+}
+
+// NamespaceTrends 统计每个命名空间在各次扫描中的发现项数量和风险分
+func (h *DB) NamespaceTrends(cluster string) ([]NamespaceTrend, error) {
+	scans, err := h.ListScans(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := map[string]bool{}
+	for _, record := range scans {
+		for _, finding := range record.Findings {
+			namespaces[finding.Namespace] = true
+		}
+	}
+	result := make([]NamespaceTrend, 0, len(namespaces))
+	for namespace := range namespaces {
+		trend := NamespaceTrend{Namespace: namespace}
+		for _, record := range scans {
+			point := TrendPoint{ScanID: record.ID, Time: record.Time}
+			for _, finding := range record.Findings {
+				if finding.Namespace == namespace {
+					point.Findings++
+					point.Score += finding.Score
+				}
+			}
+			trend.Points = append(trend.Points, point)
+		}
+		result = append(result, trend)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Namespace < result[j].Namespace
+	})
+	return result, nil
+}
+
+// scanKey 扫描记录的键，按时间排序
+func scanKey(record models.ScanRecord) []byte {
+	return []byte(record.Time.UTC().Format(time.RFC3339Nano) + "/" + record.Cluster)
+}
+
+// findingKey 发现项的唯一标识(SA+权限类型)
+func findingKey(finding models.Finding) string {
+	return findingKeyOf(finding.SA, finding.Type)
+}
+
+func findingKeyOf(sa string, permType string) string {
+	return sa + "|" + permType
+}
@@ -0,0 +1,63 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"os"
+	"path/filepath"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// LegacyDir 旧版本保存扫描记录的目录，每次扫描一个JSON文件(scan-<ID>.json)
+const LegacyDir = "scans"
+
+// ImportLegacy 将旧版本JSON文件中的扫描记录导入数据库，导入后将目录重命名为 <dir>.imported，避免重复导入
+// 旧记录只保存了关键SA，发现项和风险分按当前规则重新展开
+// 参数:
+//   - dir: 旧版本的扫描记录目录
+//   - cluster: 旧记录所属的集群(API服务器地址)，旧版本不记录集群
+//
+// 返回:
+//   - int: 导入的扫描记录数，目录不存在时为0
+//   - error: 错误信息，出错时不导入任何记录，也不重命名目录
+func (h *DB) ImportLegacy(dir string, cluster string) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "scan-*.json"))
+	if err != nil || len(paths) == 0 {
+		return 0, err
+	}
+	records := make([]models.ScanRecord, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, fmt.Errorf("读取旧扫描记录失败: %w", err)
+		}
+		var record models.ScanRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return 0, fmt.Errorf("解析旧扫描记录 %s 失败: %w", path, err)
+		}
+		records = append(records, scan.RestoreScanRecord(record, cluster))
+	}
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(scansBucket)
+		for _, record := range records {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("序列化扫描记录失败: %w", err)
+			}
+			if err := bucket.Put(scanKey(record), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("导入旧扫描记录失败: %w", err)
+	}
+	if err := os.Rename(dir, dir+".imported"); err != nil {
+		return len(records), fmt.Errorf("旧扫描记录已导入，但重命名目录失败: %w", err)
+	}
+	return len(records), nil
+}
@@ -0,0 +1,161 @@
+package introspect
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/tokensource"
+	"strings"
+	"time"
+
+	authenticationV1 "k8s.io/api/authentication/v1"
+	authorizationV1 "k8s.io/api/authorization/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Rule SelfSubjectRulesReview 返回的一条规则
+type Rule struct {
+	Verbs           []string `json:"verbs"`
+	APIGroups       []string `json:"apiGroups,omitempty"`
+	Resources       []string `json:"resources,omitempty"`
+	ResourceNames   []string `json:"resourceNames,omitempty"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+}
+
+// Report 一个Token的检查结果
+type Report struct {
+	Checked     time.Time           `json:"checked"`               // 检查时间
+	Claims      *tokensource.Claims `json:"claims,omitempty"`      // Token中的声明，不是JWT时为空
+	ClaimsError string              `json:"claimsError,omitempty"` // 无法解码声明的原因
+	Encoded     bool                `json:"encoded,omitempty"`     // 传入的是Secret data中base64编码的Token，已解码后检查
+
+	Reviewed      bool     `json:"reviewed"`      // TokenReview 是否执行成功
+	Authenticated bool     `json:"authenticated"` // API服务器是否认可该Token
+	Username      string   `json:"username,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
+	Audiences     []string `json:"audiences,omitempty"`
+	ReviewError   string   `json:"reviewError,omitempty"` // TokenReview 失败的原因或API服务器返回的认证错误
+
+	Namespace       string `json:"namespace"`            // 查询权限的命名空间
+	Rules           []Rule `json:"rules"`                // Token当前在该命名空间及集群范围内的权限
+	RulesIncomplete bool   `json:"rulesIncomplete"`      // 授权模块无法列出全部规则(如使用了Webhook授权)
+	RulesError      string `json:"rulesError,omitempty"` // SelfSubjectRulesReview 失败的原因
+}
+
+// Usable Token当前是否可用: TokenReview 认可，或使用该Token查询权限成功
+func (report Report) Usable() bool {
+	return report.Authenticated || (report.RulesError == "" && len(report.Rules) > 0)
+}
+
+// Remaining Token的剩余有效时间
+// 返回:
+//   - time.Duration: 剩余时间，已过期时为0
+//   - bool: Token是否设置了过期时间
+func (report Report) Remaining() (time.Duration, bool) {
+	if report.Claims == nil || report.Claims.Expiry.IsZero() {
+		return 0, false
+	}
+	return max(report.Claims.Expiry.Sub(report.Checked), 0), true
+}
+
+// Inspect 检查Token: 解码JWT声明，通过 TokenReview 验证，并以该Token执行 SelfSubjectRulesReview 查询其当前权限
+// TokenReview 优先使用配置中的凭据，没有权限时使用该Token本身
+// 参数:
+//   - ctx: 上下文
+//   - token: 被检查的Token
+//   - namespace: 查询权限的命名空间，为空时使用Token所属SA的命名空间，仍为空时使用 default
+func Inspect(ctx context.Context, token string, namespace string) Report {
+	report := Report{Checked: time.Now(), Rules: []Rule{}}
+	token, report.Encoded = DecodeSecretToken(token)
+	if claims, err := tokensource.DecodeClaims(token); err == nil {
+		report.Claims = &claims
+		if namespace == "" {
+			namespace = claims.Namespace
+		}
+	} else {
+		report.ClaimsError = err.Error()
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+	report.Namespace = namespace
+	review(ctx, &report, token)
+	rules(ctx, &report, token)
+	return report
+}
+
+// review 通过 TokenReview 验证Token
+func review(ctx context.Context, report *Report, token string) {
+	var lastErr error
+	// 先使用配置中的凭据，没有 tokenreviews 的创建权限时使用Token本身(如绑定了 system:auth-delegator)
+	for _, credential := range []string{"", token} {
+		clientset, err := request.GetClientSet(credential)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		result, err := clientset.AuthenticationV1().TokenReviews().Create(ctx, &authenticationV1.TokenReview{
+			Spec: authenticationV1.TokenReviewSpec{Token: token},
+		}, metaV1.CreateOptions{})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		report.Reviewed = true
+		report.Authenticated = result.Status.Authenticated
+		report.Username = result.Status.User.Username
+		report.Groups = result.Status.User.Groups
+		report.Audiences = result.Status.Audiences
+		report.ReviewError = result.Status.Error
+		return
+	}
+	report.ReviewError = fmt.Sprintf("TokenReview 失败: %v", lastErr)
+}
+
+// rules 使用Token执行 SelfSubjectRulesReview
+func rules(ctx context.Context, report *Report, token string) {
+	clientset, err := request.GetClientSet(token)
+	if err != nil {
+		report.RulesError = err.Error()
+		return
+	}
+	result, err := clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationV1.SelfSubjectRulesReview{
+		Spec: authorizationV1.SelfSubjectRulesReviewSpec{Namespace: report.Namespace},
+	}, metaV1.CreateOptions{})
+	if err != nil {
+		report.RulesError = err.Error()
+		return
+	}
+	for _, rule := range result.Status.ResourceRules {
+		report.Rules = append(report.Rules, Rule{
+			Verbs:         rule.Verbs,
+			APIGroups:     rule.APIGroups,
+			Resources:     rule.Resources,
+			ResourceNames: rule.ResourceNames,
+		})
+	}
+	for _, rule := range result.Status.NonResourceRules {
+		report.Rules = append(report.Rules, Rule{Verbs: rule.Verbs, NonResourceURLs: rule.NonResourceURLs})
+	}
+	report.RulesIncomplete = result.Status.Incomplete
+	if result.Status.EvaluationError != "" {
+		report.RulesError = result.Status.EvaluationError
+	}
+}
+
+// DecodeSecretToken 从Secret中读取的Token(data.token)为base64编码，解码后才是JWT
+// 返回:
+//   - string: 解码后的Token，不是base64编码的JWT时原样返回
+//   - bool: 是否进行了解码
+func DecodeSecretToken(token string) (string, bool) {
+	token = strings.TrimSpace(token)
+	if strings.Count(token, ".") == 2 {
+		return token, false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err != nil || strings.Count(string(decoded), ".") != 2 {
+		return token, false
+	}
+	return strings.TrimSpace(string(decoded)), true
+}
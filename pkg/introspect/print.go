@@ -0,0 +1,112 @@
+package introspect
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Print 输出Token的检查结果: 声明、TokenReview 结果和当前权限摘要
+func Print(report Report) {
+	if report.Encoded {
+		fmt.Println("[msg] The token was base64-encoded secret data and has been decoded for inspection")
+	}
+	if claims := report.Claims; claims != nil {
+		fmt.Printf("[msg] Token claims: sa=%s iss=%s aud=%s\n", claims.SA(), claims.Issuer, list(claims.Audience))
+		if remaining, ok := report.Remaining(); !ok {
+			fmt.Println("[msg] Expiry: never (no exp claim)")
+		} else if remaining == 0 {
+			fmt.Printf("[!] Expiry: %s (expired)\n", claims.Expiry.Local().Format(time.DateTime))
+		} else {
+			fmt.Printf("[msg] Expiry: %s (%s remaining)\n", claims.Expiry.Local().Format(time.DateTime), remaining.Round(time.Second))
+		}
+		switch {
+		case claims.Legacy:
+			fmt.Printf("[msg] Bound to: secret %s/%s (legacy token)\n", claims.Namespace, claims.Secret)
+		case claims.Pod != "" || claims.Node != "":
+			fmt.Printf("[msg] Bound to: pod %s/%s (%s), node %s\n", claims.Namespace, or(claims.Pod), or(claims.PodUID), or(claims.Node))
+		default:
+			fmt.Println("[msg] Bound to: nothing (not bound to a pod or secret)")
+		}
+	} else {
+		fmt.Println("[!] Token claims could not be decoded:", report.ClaimsError)
+	}
+
+	switch {
+	case !report.Reviewed:
+		fmt.Println("[!]", report.ReviewError)
+	case report.Authenticated:
+		fmt.Printf("[√] TokenReview: authenticated as %s, groups %s, audiences %s\n", report.Username, list(report.Groups), list(report.Audiences))
+	default:
+		fmt.Println("[X] TokenReview: not authenticated", report.ReviewError)
+	}
+
+	if report.RulesError != "" {
+		fmt.Println("[!] SelfSubjectRulesReview:", report.RulesError)
+	}
+	PrintRules(report.Namespace, report.Rules, report.RulesIncomplete)
+}
+
+// PrintRules 输出Token在命名空间中的权限摘要，没有规则时不输出
+// 参数:
+//   - namespace: 查询权限的命名空间
+//   - rules: SelfSubjectRulesReview 得到的规则
+//   - incomplete: 授权模块是否无法列出全部规则
+func PrintRules(namespace string, rules []Rule, incomplete bool) {
+	if len(rules) == 0 {
+		return
+	}
+	note := ""
+	if incomplete {
+		note = " (incomplete, the authorizer cannot list every rule)"
+	}
+	fmt.Printf("[msg] Current permissions in namespace %s%s:\n", namespace, note)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, rule := range rules {
+		fmt.Fprintf(w, "  %s\t%s\n", strings.Join(rule.Verbs, ","), target(rule))
+	}
+	w.Flush()
+}
+
+// target 规则作用的对象: 资源(含API组和资源名称)或非资源URL
+func target(rule Rule) string {
+	if len(rule.NonResourceURLs) > 0 {
+		return strings.Join(rule.NonResourceURLs, ",")
+	}
+	// 核心组("")不加后缀，多个组时写为 resource.{a,b}
+	suffix := ""
+	switch groups := slices.DeleteFunc(slices.Clone(rule.APIGroups), func(group string) bool { return group == "" }); {
+	case len(groups) == 1:
+		suffix = "." + groups[0]
+	case len(groups) > 1:
+		suffix = ".{" + strings.Join(groups, ",") + "}"
+	}
+	resources := make([]string, 0, len(rule.Resources))
+	for _, resource := range rule.Resources {
+		resources = append(resources, resource+suffix)
+	}
+	result := strings.Join(resources, ",")
+	if len(rule.ResourceNames) > 0 {
+		result += " [" + strings.Join(rule.ResourceNames, ",") + "]"
+	}
+	return result
+}
+
+// list 输出列表，为空时为 "-"
+func list(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
+
+// or 为空时输出 "-"
+func or(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
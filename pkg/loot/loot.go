@@ -0,0 +1,219 @@
+package loot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"k8sEPDS/pkg/introspect"
+	"k8sEPDS/pkg/vault"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Permissions Token在一个命名空间中的权限(包含集群范围的规则)
+type Permissions struct {
+	Rules      []introspect.Rule `json:"rules"`
+	Incomplete bool              `json:"incomplete"` // 授权模块无法列出全部规则
+	Checked    time.Time         `json:"checked"`    // 查询权限的时间
+}
+
+// Entry Token库中的一个Token
+type Entry struct {
+	ID          string                 `json:"id"` // Token的SHA-256摘要前12位
+	Token       string                 `json:"token"`
+	SA          string                 `json:"sa"`               // ServiceAccount(格式: namespace/name)，无法解码时为空
+	Technique   string                 `json:"technique"`        // 获取方式(利用模块名称或 harvest)
+	Source      string                 `json:"source,omitempty"` // 获取来源的补充说明(如节点、Token文件路径)
+	Cluster     string                 `json:"cluster"`          // 获取时使用的API服务器
+	Obtained    time.Time              `json:"obtained"`         // 首次获取的时间
+	Expiry      time.Time              `json:"expiry"`           // 过期时间，零值表示不过期
+	Valid       bool                   `json:"valid"`            // 最近一次验证时是否可用(TokenReview 认可或能以该Token查询权限)
+	Username    string                 `json:"username,omitempty"`
+	Permissions map[string]Permissions `json:"permissions"` // 已验证的权限，键为命名空间
+}
+
+// Expired Token在指定时间是否已过期
+func (entry Entry) Expired(now time.Time) bool {
+	return !entry.Expiry.IsZero() && !now.Before(entry.Expiry)
+}
+
+// NewEntry 根据Token的检查结果创建Token库条目，Secret中base64编码的Token保存解码后的值
+// 参数:
+//   - token: 获取到的Token
+//   - technique: 获取方式
+//   - source: 获取来源的补充说明，可以为空
+//   - cluster: 获取时使用的API服务器
+//   - report: introspect.Inspect 的检查结果
+func NewEntry(token string, technique string, source string, cluster string, report introspect.Report) Entry {
+	token, _ = introspect.DecodeSecretToken(token)
+	entry := Entry{
+		ID:          ID(token),
+		Token:       token,
+		Technique:   technique,
+		Source:      source,
+		Cluster:     cluster,
+		Obtained:    report.Checked,
+		Valid:       report.Usable(),
+		Username:    report.Username,
+		Permissions: map[string]Permissions{},
+	}
+	if report.Claims != nil {
+		entry.SA, entry.Expiry = report.Claims.SA(), report.Claims.Expiry
+	}
+	if report.RulesError == "" {
+		entry.Permissions[report.Namespace] = Permissions{Rules: report.Rules, Incomplete: report.RulesIncomplete, Checked: report.Checked}
+	}
+	return entry
+}
+
+// ID Token在Token库中的标识
+func ID(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Store 已解锁的Token库，以凭据库相同的格式加密保存，每个Token为一项以JSON编码的凭据
+type Store struct {
+	vault   *vault.Store
+	entries map[string]Entry
+}
+
+// Create 新建Token库，文件已存在时返回错误
+func Create(path string, passphrase string) (*Store, error) {
+	store, err := vault.Create(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{vault: store, entries: map[string]Entry{}}, nil
+}
+
+// Open 使用口令打开Token库
+func Open(path string, passphrase string) (*Store, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("Token库不存在: %s", path)
+	}
+	store, err := vault.Open(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	loot := &Store{vault: store, entries: map[string]Entry{}}
+	for _, name := range store.Names() {
+		value, _ := store.Get(name)
+		var entry Entry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			return nil, fmt.Errorf("解析Token库条目 %s 失败: %w", name, err)
+		}
+		loot.entries[name] = entry
+	}
+	return loot, nil
+}
+
+// Path Token库文件路径
+func (s *Store) Path() string {
+	return s.vault.Path()
+}
+
+// Add 添加Token，已存在时保留首次获取的时间和方式，更新验证结果并合并已验证的权限，调用 Save 后写入文件
+// 返回:
+//   - Entry: 合并后的条目
+//   - bool: 是否为新的Token
+func (s *Store) Add(entry Entry) (Entry, bool) {
+	existing, exists := s.entries[entry.ID]
+	if exists {
+		permissions := existing.Permissions
+		if permissions == nil {
+			permissions = map[string]Permissions{}
+		}
+		for namespace, permission := range entry.Permissions {
+			permissions[namespace] = permission
+		}
+		entry.Technique, entry.Source, entry.Obtained, entry.Permissions = existing.Technique, existing.Source, existing.Obtained, permissions
+		if entry.Cluster == "" {
+			entry.Cluster = existing.Cluster
+		}
+	}
+	s.entries[entry.ID] = entry
+	return entry, !exists
+}
+
+// Get 按ID(或ID前缀)查找Token
+func (s *Store) Get(id string) (Entry, error) {
+	if entry, exists := s.entries[id]; exists {
+		return entry, nil
+	}
+	matches := []Entry{}
+	for _, entry := range s.entries {
+		if id != "" && strings.HasPrefix(entry.ID, id) {
+			matches = append(matches, entry)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Entry{}, fmt.Errorf("Token库中没有Token: %s", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return Entry{}, fmt.Errorf("ID前缀 %s 匹配多个Token，请输入更长的ID", id)
+	}
+}
+
+// Delete 删除Token，调用 Save 后写入文件
+func (s *Store) Delete(id string) error {
+	entry, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	delete(s.entries, entry.ID)
+	return nil
+}
+
+// Entries 按获取时间排序的全部Token
+func (s *Store) Entries() []Entry {
+	entries := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Obtained.Equal(entries[j].Obtained) {
+			return entries[i].Obtained.Before(entries[j].Obtained)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
+
+// Prune 删除已过期的Token，调用 Save 后写入文件
+// 返回:
+//   - []Entry: 被删除的Token
+func (s *Store) Prune(now time.Time) []Entry {
+	pruned := []Entry{}
+	for _, entry := range s.Entries() {
+		if entry.Expired(now) {
+			delete(s.entries, entry.ID)
+			pruned = append(pruned, entry)
+		}
+	}
+	return pruned
+}
+
+// Save 加密并写入文件
+func (s *Store) Save() error {
+	for _, name := range s.vault.Names() {
+		if _, exists := s.entries[name]; !exists {
+			s.vault.Delete(name)
+		}
+	}
+	for id, entry := range s.entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := s.vault.Set(id, string(data)); err != nil {
+			return err
+		}
+	}
+	return s.vault.Save()
+}
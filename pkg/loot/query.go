@@ -0,0 +1,128 @@
+package loot
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/pkg/introspect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Query 查找能执行某个操作的Token，如 create pods (kube-system)
+type Query struct {
+	Verb      string // 动词，如 create、get、patch
+	Resource  string // 资源，可以带API组和子资源，如 pods、pods/exec、deployments.apps
+	Name      string // 资源名称，为空时只匹配不限制资源名称的规则
+	Namespace string // 命名空间，为空时使用 default
+	Cluster   string // API服务器，为空时不限制
+}
+
+// String 查询的显示
+func (q Query) String() string {
+	result := q.Verb + " " + q.Resource
+	if q.Name != "" {
+		result += "/" + q.Name
+	}
+	return result + " (" + q.namespace() + ")"
+}
+
+// namespace 查询的命名空间
+func (q Query) namespace() string {
+	if q.Namespace == "" {
+		return "default"
+	}
+	return q.Namespace
+}
+
+// Allows 规则是否允许查询的操作
+// 参数:
+//   - rules: SelfSubjectRulesReview 得到的规则
+//   - q: 查询，资源不带API组时匹配任意API组
+func Allows(rules []introspect.Rule, q Query) bool {
+	resource, subresource, _ := strings.Cut(q.Resource, "/")
+	resource, group, grouped := strings.Cut(resource, ".")
+	for _, rule := range rules {
+		if len(rule.NonResourceURLs) > 0 {
+			continue
+		}
+		if !matches(rule.Verbs, q.Verb) {
+			continue
+		}
+		if grouped && !matches(rule.APIGroups, group) {
+			continue
+		}
+		if len(rule.ResourceNames) > 0 && !slices.Contains(rule.ResourceNames, q.Name) {
+			continue
+		}
+		for _, ruleResource := range rule.Resources {
+			if ruleResource == "*" ||
+				(subresource == "" && ruleResource == resource) ||
+				(subresource != "" && (ruleResource == resource+"/"+subresource || ruleResource == resource+"/*" || ruleResource == "*/"+subresource)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches 列表中是否包含该值或通配符
+func matches(values []string, value string) bool {
+	return slices.Contains(values, "*") || slices.Contains(values, value)
+}
+
+// Best 查找能执行查询操作的最佳Token: 未过期、最近一次验证时可用且剩余有效时间最长(不过期的Token优先)
+// 没有在查询的命名空间中验证过权限的Token会先以该Token执行 SelfSubjectRulesReview，并将结果保存到条目中，调用 Save 后写入文件
+// 参数:
+//   - ctx: 上下文
+//   - q: 查询
+//
+// 返回:
+//   - Entry: 最佳Token
+//   - []Entry: 全部能执行该操作的Token，按优先顺序排序
+//   - error: 没有能执行该操作的Token时返回错误
+func (s *Store) Best(ctx context.Context, q Query) (Entry, []Entry, error) {
+	now := time.Now()
+	namespace := q.namespace()
+	candidates := []Entry{}
+	for _, entry := range s.Entries() {
+		if entry.Expired(now) || (q.Cluster != "" && entry.Cluster != q.Cluster) {
+			continue
+		}
+		if _, checked := entry.Permissions[namespace]; !checked {
+			entry = s.reinspect(ctx, entry, namespace)
+		}
+		if !entry.Valid {
+			continue
+		}
+		if Allows(entry.Permissions[namespace].Rules, q) {
+			candidates = append(candidates, entry)
+		}
+	}
+	if len(candidates) == 0 {
+		return Entry{}, nil, fmt.Errorf("Token库中没有能执行 %s 的可用Token", q)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Expiry.IsZero() != b.Expiry.IsZero() {
+			return a.Expiry.IsZero()
+		}
+		if !a.Expiry.Equal(b.Expiry) {
+			return a.Expiry.After(b.Expiry)
+		}
+		return a.Obtained.After(b.Obtained)
+	})
+	return candidates[0], candidates, nil
+}
+
+// reinspect 在指定命名空间中重新验证Token并更新条目
+func (s *Store) reinspect(ctx context.Context, entry Entry, namespace string) Entry {
+	report := introspect.Inspect(ctx, entry.Token, namespace)
+	if !report.Reviewed && report.RulesError != "" {
+		// 无法访问API服务器，保留原有结果
+		return entry
+	}
+	updated, _ := s.Add(NewEntry(entry.Token, entry.Technique, entry.Source, entry.Cluster, report))
+	return updated
+}
@@ -0,0 +1,101 @@
+package loot
+
+import (
+	"fmt"
+	"k8sEPDS/pkg/introspect"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/vault"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// EnvFile Token库文件路径的环境变量，口令与凭据库相同可以通过环境变量 K8SEPDS_VAULT_PASSPHRASE 提供
+const EnvFile = "K8SEPDS_LOOT"
+
+var (
+	sessionMu  sync.Mutex
+	session    *Store
+	sessionErr error
+)
+
+// DefaultFile 默认的Token库文件路径: 环境变量 K8SEPDS_LOOT，否则为 conf/loot.vault
+func DefaultFile() string {
+	if file := os.Getenv(EnvFile); file != "" {
+		return file
+	}
+	return filepath.Join("conf", "loot.vault")
+}
+
+// Unlock 解锁默认Token库，不存在时新建，每次运行只需输入一次口令
+// 解锁失败(如无法读取口令)后本次运行不再重试，避免每获取一个Token都要求输入口令
+func Unlock() (*Store, error) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if session != nil || sessionErr != nil {
+		return session, sessionErr
+	}
+	session, sessionErr = unlock(DefaultFile())
+	return session, sessionErr
+}
+
+// unlock 打开或新建Token库
+func unlock(path string) (*Store, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		passphrase, err := vault.Passphrase("[输入] 新建Token库 " + path + "，设置口令: ")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := os.LookupEnv(vault.EnvPassphrase); !ok {
+			confirm, err := vault.Passphrase("[输入] 再次输入口令: ")
+			if err != nil {
+				return nil, err
+			}
+			if passphrase != confirm {
+				return nil, fmt.Errorf("两次输入的口令不一致")
+			}
+		}
+		return Create(path, passphrase)
+	}
+	passphrase, err := vault.Passphrase("[输入] Token库口令: ")
+	if err != nil {
+		return nil, err
+	}
+	store, err := Open(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	// 打开时删除已过期的Token
+	if pruned := store.Prune(time.Now()); len(pruned) > 0 {
+		if err := store.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// Record 将获取到的Token及其检查结果保存到默认Token库，并删除已过期的Token
+// 参数:
+//   - token: 获取到的Token
+//   - technique: 获取方式(利用模块名称或 harvest)
+//   - source: 获取来源的补充说明，可以为空
+//   - report: introspect.Inspect 的检查结果
+//
+// 返回:
+//   - Entry: 保存的条目
+//   - bool: 是否为新的Token
+//   - error: Token已过期、解锁或写入Token库失败时返回错误
+func Record(token string, technique string, source string, report introspect.Report) (Entry, bool, error) {
+	entry := NewEntry(token, technique, source, request.Cluster(), report)
+	if entry.Expired(time.Now()) {
+		return entry, false, fmt.Errorf("Token已过期")
+	}
+	store, err := Unlock()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	entry, added := store.Add(entry)
+	store.Prune(time.Now())
+	return entry, added, store.Save()
+}
@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/scan"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名称前缀
+const namespace = "k8sepds"
+
+// Exporter 过度权限态势的Prometheus指标
+type Exporter struct {
+	registry     *prometheus.Registry
+	posture      *postureCollector
+	scanDuration prometheus.Gauge
+	lastScan     prometheus.Gauge
+	scans        prometheus.Counter
+}
+
+// postureCollector 导出最近一次扫描得到的态势指标
+// 每次扫描生成完整的快照后整体替换，抓取时不会看到清空后尚未填充的中间状态，也不会保留已消失的标签组合
+type postureCollector struct {
+	findings     *prometheus.Desc
+	criticalSAs  *prometheus.Desc
+	tokens       *prometheus.Desc
+	coverageGaps *prometheus.Desc
+	snapshot     atomic.Pointer[[]prometheus.Metric]
+}
+
+// apiErrorCollector 将 request.APIErrorCounts 导出为计数器
+type apiErrorCollector struct {
+	desc *prometheus.Desc
+}
+
+// NewExporter 创建指标导出器
+func NewExporter() *Exporter {
+	e := &Exporter{
+		registry: prometheus.NewRegistry(),
+		posture: &postureCollector{
+			findings: prometheus.NewDesc(namespace+"_critical_findings",
+				"关键SA的高危权限数量(按权限类型、攻击类别、权限范围、命名空间和节点)",
+				[]string{"permission", "category", "scope", "namespace", "node"}, nil),
+			criticalSAs: prometheus.NewDesc(namespace+"_critical_service_accounts",
+				"关键SA数量(按权限范围和命名空间)", []string{"scope", "namespace"}, nil),
+			tokens: prometheus.NewDesc(namespace+"_critical_tokens",
+				"关键SA令牌数量(mounted表示被Pod挂载)", []string{"state"}, nil),
+			coverageGaps: prometheus.NewDesc(namespace+"_coverage_gaps",
+				"最近一次扫描无法读取的资源数量(按资源类型和错误分类)，大于0时扫描结果可能不完整", []string{"resource", "reason"}, nil),
+		},
+		scanDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "scan_duration_seconds",
+			Help:      "最近一次扫描耗时",
+		}),
+		lastScan: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_scan_timestamp_seconds",
+			Help:      "最近一次扫描完成的时间",
+		}),
+		scans: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "scans_total",
+			Help:      "已完成的扫描次数",
+		}),
+	}
+	e.registry.MustRegister(e.posture, e.scanDuration, e.lastScan, e.scans, &apiErrorCollector{
+		desc: prometheus.NewDesc(namespace+"_api_errors_total", "API请求错误次数(按HTTP状态码或transport)", []string{"reason"}, nil),
+	})
+	return e
+}
+
+// Update 使用一次扫描结果更新指标
+// 参数:
+//   - criticalSAs: GetCriticalSA 的扫描结果
+//   - coverage: 扫描时无法读取的资源
+//   - duration: 扫描耗时
+func (e *Exporter) Update(criticalSAs []models.CriticalSA, coverage []models.CoverageGap, duration time.Duration) {
+	e.posture.update(criticalSAs, coverage)
+	e.scanDuration.Set(duration.Seconds())
+	e.lastScan.SetToCurrentTime()
+	e.scans.Inc()
+}
+
+// update 根据扫描结果生成新的快照并替换旧快照
+func (c *postureCollector) update(criticalSAs []models.CriticalSA, coverage []models.CoverageGap) {
+	gauges := newGaugeSet()
+	for _, gap := range coverage {
+		resource, _, _ := strings.Cut(gap.Resource, "/")
+		gauges.add(c.coverageGaps, 1, resource, gap.Reason)
+	}
+	for _, finding := range scan.Findings(criticalSAs) {
+		gauges.add(c.findings, 1, scan.DispatchName(finding.Type), scan.Category(finding.Kind), finding.Level, finding.Namespace, finding.Node)
+	}
+	mounted, unmounted := 0, 0
+	for _, criticalSA := range criticalSAs {
+		gauges.add(c.criticalSAs, 1, criticalSA.Level, scan.SANamespace(criticalSA.SA0.Name))
+		if criticalSA.SA0.IsMounted && criticalSA.SA0.SAPod.TokenMounted {
+			mounted++
+		} else {
+			unmounted++
+		}
+	}
+	gauges.add(c.tokens, float64(mounted), "mounted")
+	gauges.add(c.tokens, float64(unmounted), "unmounted")
+	metrics := gauges.metrics()
+	c.snapshot.Store(&metrics)
+}
+
+// Describe 实现 prometheus.Collector
+func (c *postureCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.findings
+	ch <- c.criticalSAs
+	ch <- c.tokens
+	ch <- c.coverageGaps
+}
+
+// Collect 实现 prometheus.Collector，导出最近一次扫描的快照，尚未扫描时不导出
+func (c *postureCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.snapshot.Load()
+	if snapshot == nil {
+		return
+	}
+	for _, metric := range *snapshot {
+		ch <- metric
+	}
+}
+
+// gaugeSet 按指标和标签累加的值，用于生成快照
+type gaugeSet struct {
+	keys   []gaugeKey
+	values map[gaugeKey]float64
+	labels map[gaugeKey][]string
+}
+
+// gaugeKey 指标和标签组合
+type gaugeKey struct {
+	desc   *prometheus.Desc
+	labels string
+}
+
+// newGaugeSet 创建空的 gaugeSet
+func newGaugeSet() *gaugeSet {
+	return &gaugeSet{values: map[gaugeKey]float64{}, labels: map[gaugeKey][]string{}}
+}
+
+// add 将值累加到指标的标签组合上
+func (g *gaugeSet) add(desc *prometheus.Desc, value float64, labels ...string) {
+	key := gaugeKey{desc: desc, labels: strings.Join(labels, "\x00")}
+	if _, exists := g.values[key]; !exists {
+		g.keys = append(g.keys, key)
+		g.labels[key] = labels
+	}
+	g.values[key] += value
+}
+
+// metrics 按添加顺序生成常量指标
+func (g *gaugeSet) metrics() []prometheus.Metric {
+	metrics := make([]prometheus.Metric, 0, len(g.keys))
+	for _, key := range g.keys {
+		metrics = append(metrics, prometheus.MustNewConstMetric(key.desc, prometheus.GaugeValue, g.values[key], g.labels[key]...))
+	}
+	return metrics
+}
+
+// Handler 返回 /metrics 的HTTP处理器
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// Describe 实现 prometheus.Collector
+func (c *apiErrorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect 实现 prometheus.Collector
+func (c *apiErrorCollector) Collect(ch chan<- prometheus.Metric) {
+	for reason, count := range request.APIErrorCounts() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(count), reason)
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"k8sEPDS/models"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixtureSA 命名空间 ns 中具有 getsecrets 权限的关键SA
+func fixtureSA(ns string) models.CriticalSA {
+	return models.CriticalSA{
+		Type:  []string{"getsecrets[" + ns + "]"},
+		Level: "namespace",
+		SA0:   models.SA{Name: ns + "/reader", IsMounted: true, SAPod: models.Pod{Namespace: ns, NodeName: "node1", TokenMounted: true}},
+	}
+}
+
+// gather 抓取指标，返回 名称{标签=值,...} -> 值
+func gather(t *testing.T, e *Exporter, name string) map[string]float64 {
+	t.Helper()
+	families, err := e.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := []string{}
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetName()+"="+label.GetValue())
+			}
+			sort.Strings(labels)
+			samples[name+"{"+strings.Join(labels, ",")+"}"] = metric.GetGauge().GetValue()
+		}
+	}
+	return samples
+}
+
+func TestUpdate(t *testing.T) {
+	e := NewExporter()
+	if samples := gather(t, e, "k8sepds_critical_tokens"); len(samples) != 0 {
+		t.Errorf("尚未扫描时导出了指标: %v", samples)
+	}
+
+	e.Update([]models.CriticalSA{fixtureSA("app"), fixtureSA("monitoring")}, nil, time.Second)
+	e.Update([]models.CriticalSA{fixtureSA("app")}, nil, time.Second)
+	tests := []struct {
+		name string
+		want map[string]float64
+	}{
+		{"k8sepds_critical_service_accounts", map[string]float64{
+			"k8sepds_critical_service_accounts{namespace=app,scope=namespace}": 1,
+		}},
+		{"k8sepds_critical_tokens", map[string]float64{
+			"k8sepds_critical_tokens{state=mounted}":   1,
+			"k8sepds_critical_tokens{state=unmounted}": 0,
+		}},
+	}
+	for _, tt := range tests {
+		got := gather(t, e, tt.name)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s = %v, 期望 %v (上一次扫描的标签组合应被移除)", tt.name, got, tt.want)
+			continue
+		}
+		for sample, value := range tt.want {
+			if got[sample] != value {
+				t.Errorf("%s = %v, 期望 %v", sample, got[sample], value)
+			}
+		}
+	}
+}
+
+// TestUpdateDuringScrape 更新指标时抓取到的总是完整的一次扫描结果，使用 -race 运行
+func TestUpdateDuringScrape(t *testing.T) {
+	e := NewExporter()
+	criticalSAs := []models.CriticalSA{fixtureSA("app")}
+	e.Update(criticalSAs, nil, time.Second)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			e.Update(criticalSAs, nil, time.Second)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if got := gather(t, e, "k8sepds_critical_service_accounts"); len(got) != 1 {
+			t.Fatalf("抓取到 %v, 期望 1 个关键SA指标", got)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,138 @@
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/scan"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// Target 一个被扫描的集群
+type Target struct {
+	Name string           // 集群名称，作为发现项和扫描记录的集群标识
+	K8s  models.K8SConfig // 集群的连接配置
+	Node string           // 受控节点名称，为空时使用扫描时指定的受控节点
+}
+
+// Result 单个集群的扫描结果
+type Result struct {
+	Target Target
+	Record models.ScanRecord
+	Err    error
+}
+
+// Targets 根据命名配置生成扫描目标，每个目标使用所属配置中SSH的受控节点
+// allContexts 为true时，配置了kubeconfig的集群会展开为kubeconfig中的全部上下文
+// 参数:
+//   - profiles: 配置文件中的集群及其SSH配置
+//   - allContexts: 是否展开kubeconfig中的全部上下文
+func Targets(profiles []models.Profile, allContexts bool) ([]Target, error) {
+	targets := []Target{}
+	names := map[string]bool{}
+	add := func(target Target) error {
+		if names[target.Name] {
+			return fmt.Errorf("集群名称重复: %s", target.Name)
+		}
+		names[target.Name] = true
+		targets = append(targets, target)
+		return nil
+	}
+	for _, profile := range profiles {
+		cluster, node := profile.K8s, profile.SSH.Nodename
+		if allContexts && cluster.Kubeconfig != "" {
+			contexts, err := request.Contexts(cluster.Kubeconfig)
+			if err != nil {
+				return nil, err
+			}
+			for _, context := range contexts {
+				k8s := cluster
+				k8s.Context = context
+				name := context
+				if cluster.Name != "" {
+					name = cluster.Name + "/" + context
+				}
+				if err := add(Target{Name: name, K8s: k8s, Node: node}); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if err := add(Target{Name: targetName(cluster), K8s: cluster, Node: node}); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// Scan 同时扫描多个集群，单个集群失败不影响其他集群
+// 参数:
+//   - ctx: 上下文
+//   - targets: 扫描目标
+//   - node: 未配置受控节点的目标使用的受控节点名称
+//   - parallel: 同时扫描的集群数量上限，小于1时不限制
+//
+// 返回:
+//   - []Result: 与 targets 顺序一致的扫描结果
+func Scan(ctx context.Context, targets []Target, node string, parallel int) []Result {
+	if parallel < 1 {
+		parallel = len(targets)
+	}
+	results := make([]Result, len(targets))
+	semaphore := make(chan struct{}, max(parallel, 1))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i] = scanTarget(ctx, target, node)
+		}(i, target)
+	}
+	wg.Wait()
+	return results
+}
+
+// scanTarget 扫描单个集群
+func scanTarget(ctx context.Context, target Target, node string) Result {
+	result := Result{Target: target}
+	if target.Node != "" {
+		node = target.Node
+	}
+	config, err := request.ConfigFor(target.K8s)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		result.Err = fmt.Errorf("创建客户端失败: %w", err)
+		return result
+	}
+	_, criticalSAs, coverage, err := scan.ScanClient(ctx, clientset, node)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Record = scan.NewScanRecord(criticalSAs, coverage, target.Name, node)
+	return result
+}
+
+// targetName 集群的默认名称，未设置名称时按连接方式选用kubeconfig上下文、kubeconfig路径或API服务器地址
+func targetName(cluster models.K8SConfig) string {
+	switch {
+	case cluster.Name != "":
+		return cluster.Name
+	case cluster.Kubeconfig != "" && cluster.Context != "":
+		return cluster.Context
+	case cluster.Kubeconfig != "":
+		return cluster.Kubeconfig
+	case cluster.ApiServer != "":
+		return cluster.ApiServer
+	}
+	return "in-cluster"
+}
@@ -0,0 +1,191 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/scan"
+	"k8sEPDS/pkg/watch"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 运行模式
+const (
+	ModeSchedule = "schedule" // 按固定周期执行完整扫描
+	ModeWatch    = "watch"    // 基于informer在变化时更新报告
+)
+
+// Options 控制器运行参数
+type Options struct {
+	Mode           string        // 运行模式(schedule/watch)
+	Interval       time.Duration // schedule模式下的扫描周期
+	ControlledNode string        // 受控节点名称
+	SensitiveNodes []string      // 敏感节点
+}
+
+// Publisher 将扫描结果写入PolicyReport/ClusterPolicyReport
+type Publisher struct {
+	clientset kubernetes.Interface
+	dynamic   dynamic.Interface
+}
+
+// Run 以控制器模式运行扫描器，直到ctx被取消
+func Run(ctx context.Context, opts Options) error {
+	clientset, err := request.GetClientSet("")
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := request.GetDynamicClient("")
+	if err != nil {
+		return err
+	}
+	publisher := &Publisher{clientset: clientset, dynamic: dynamicClient}
+
+	switch opts.Mode {
+	case ModeWatch:
+		watcher := watch.NewWatcher(clientset, opts.ControlledNode, opts.SensitiveNodes, func(event watch.Event) {
+			fmt.Println("[event]", event.Type, event.SA, event.Types)
+		})
+		watcher.SetUpdateHandler(func(criticalSAs []models.CriticalSA) {
+			if err := publisher.Publish(ctx, criticalSAs, nil); err != nil {
+				fmt.Println("[X] 写入PolicyReport失败:", err.Error())
+			}
+		})
+		return watcher.Run(ctx)
+	case ModeSchedule, "":
+		if opts.Interval <= 0 {
+			return fmt.Errorf("扫描周期必须大于0")
+		}
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		for {
+			_, criticalSAs, coverage, err := scan.ScanCluster(opts.ControlledNode)
+			if len(coverage) > 0 {
+				fmt.Printf("[msg] %d 项资源无法读取，PolicyReport 可能不完整\n", len(coverage))
+			}
+			if err != nil {
+				// 没有扫描结果时不写入，避免清理掉仍然有效的PolicyReport
+				fmt.Println("[X] 扫描失败:", err.Error())
+			} else if err := publisher.Publish(ctx, criticalSAs, coverage); err != nil {
+				fmt.Println("[X] 写入PolicyReport失败:", err.Error())
+			} else {
+				fmt.Printf("[√] %s 已写入 %d 个关键SA的PolicyReport\n", time.Now().Format(time.DateTime), len(criticalSAs))
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	default:
+		return fmt.Errorf("不支持的运行模式: %s", opts.Mode)
+	}
+}
+
+// Publish 将关键SA写入PolicyReport，并清理已没有发现项的命名空间中的旧报告
+// 扫描有覆盖缺口时，没有发现项不代表命名空间中没有高危权限，此时不清理旧报告
+// 参数:
+//   - ctx: 上下文
+//   - criticalSAs: 关键SA
+//   - coverage: 扫描时的覆盖缺口，基于informer缓存的结果为nil
+func (p *Publisher) Publish(ctx context.Context, criticalSAs []models.CriticalSA, coverage []models.CoverageGap) error {
+	findings := scan.Findings(criticalSAs)
+	owners := map[string]coreV1.ObjectReference{}
+	for _, finding := range findings {
+		key := finding.Namespace + "/" + finding.Pod
+		if _, exists := owners[key]; exists || finding.Pod == "" {
+			continue
+		}
+		if owner, err := p.resolveOwner(ctx, finding.Namespace, finding.Pod); err == nil {
+			owners[key] = owner
+		}
+	}
+	reports, clusterReport := BuildReports(findings, owners, time.Now())
+
+	for _, report := range reports {
+		if err := p.apply(ctx, p.dynamic.Resource(policyReportGVR).Namespace(report.GetNamespace()), report); err != nil {
+			return err
+		}
+	}
+	if err := p.apply(ctx, p.dynamic.Resource(clusterPolicyReportGVR), clusterReport); err != nil {
+		return err
+	}
+
+	if !scan.Complete(coverage) {
+		fmt.Println("[msg] 扫描结果不完整，保留已有的PolicyReport")
+		return nil
+	}
+	existing, err := p.dynamic.Resource(policyReportGVR).List(ctx, metaV1.ListOptions{
+		LabelSelector: managedByLabel + "=" + reportSource,
+	})
+	if err != nil {
+		return fmt.Errorf("获取已有PolicyReport失败: %w", err)
+	}
+	for _, report := range existing.Items {
+		if _, exists := reports[report.GetNamespace()]; exists {
+			continue
+		}
+		err := p.dynamic.Resource(policyReportGVR).Namespace(report.GetNamespace()).Delete(ctx, report.GetName(), metaV1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("删除过期PolicyReport失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// apply 创建或更新报告
+func (p *Publisher) apply(ctx context.Context, client dynamic.ResourceInterface, report *unstructured.Unstructured) error {
+	current, err := client.Get(ctx, report.GetName(), metaV1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, report, metaV1.CreateOptions{})
+	} else if err == nil {
+		report.SetResourceVersion(current.GetResourceVersion())
+		_, err = client.Update(ctx, report, metaV1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("写入%s %s失败: %w", report.GetKind(), report.GetNamespace()+"/"+report.GetName(), err)
+	}
+	return nil
+}
+
+// resolveOwner 沿ownerReferences查找Pod所属的顶层工作负载
+// ReplicaSet会继续查找Deployment，Job会继续查找CronJob，没有控制器时返回Pod本身
+func (p *Publisher) resolveOwner(ctx context.Context, namespace string, podName string) (coreV1.ObjectReference, error) {
+	pod, err := p.clientset.CoreV1().Pods(namespace).Get(ctx, podName, metaV1.GetOptions{})
+	if err != nil {
+		return coreV1.ObjectReference{}, err
+	}
+	owner := coreV1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: namespace, Name: pod.Name, UID: pod.UID}
+	refs := pod.OwnerReferences
+	for {
+		controller := metaV1.GetControllerOfNoCopy(&metaV1.ObjectMeta{OwnerReferences: refs})
+		if controller == nil {
+			return owner, nil
+		}
+		owner = coreV1.ObjectReference{APIVersion: controller.APIVersion, Kind: controller.Kind, Namespace: namespace, Name: controller.Name, UID: controller.UID}
+		switch controller.Kind {
+		case "ReplicaSet":
+			rs, err := p.clientset.AppsV1().ReplicaSets(namespace).Get(ctx, controller.Name, metaV1.GetOptions{})
+			if err != nil {
+				return owner, nil
+			}
+			refs = rs.OwnerReferences
+		case "Job":
+			job, err := p.clientset.BatchV1().Jobs(namespace).Get(ctx, controller.Name, metaV1.GetOptions{})
+			if err != nil {
+				return owner, nil
+			}
+			refs = job.OwnerReferences
+		default:
+			return owner, nil
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package operator
+
+import (
+	"context"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/request"
+	"sort"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fixtureReport 本工具之前写入的PolicyReport
+func fixtureReport(namespace string) *unstructured.Unstructured {
+	report := newReport("PolicyReport", []interface{}{})
+	report.SetNamespace(namespace)
+	return report
+}
+
+// fixturePublisher 使用fake客户端的Publisher，monitoring 命名空间中已有旧报告
+func fixturePublisher() *Publisher {
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			policyReportGVR:        "PolicyReportList",
+			clusterPolicyReportGVR: "ClusterPolicyReportList",
+		},
+		fixtureReport("monitoring"),
+	)
+	return &Publisher{clientset: fake.NewSimpleClientset(), dynamic: dynamicClient}
+}
+
+// reportNamespaces 已写入PolicyReport的命名空间
+func reportNamespaces(t *testing.T, p *Publisher) []string {
+	t.Helper()
+	list, err := p.dynamic.Resource(policyReportGVR).List(context.Background(), metaV1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	namespaces := []string{}
+	for _, report := range list.Items {
+		namespaces = append(namespaces, report.GetNamespace())
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+func TestPublish(t *testing.T) {
+	criticalSAs := []models.CriticalSA{{
+		Type:  []string{"getsecrets[app]"},
+		Level: "namespace",
+		SA0:   models.SA{Name: "app/deployer"},
+	}}
+	tests := []struct {
+		name     string
+		coverage []models.CoverageGap
+		want     []string
+	}{
+		{name: "扫描完整时清理旧报告", want: []string{"app"}},
+		{
+			name:     "引用的角色不存在不影响清理",
+			coverage: []models.CoverageGap{{Resource: "clusterroles/missing", Reason: string(request.KindNotFound)}},
+			want:     []string{"app"},
+		},
+		{
+			name:     "无法读取命名空间绑定时保留旧报告",
+			coverage: []models.CoverageGap{{Resource: "rolebindings", Namespace: "monitoring", Reason: string(request.KindForbidden)}},
+			want:     []string{"app", "monitoring"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := fixturePublisher()
+			if err := p.Publish(context.Background(), criticalSAs, tt.coverage); err != nil {
+				t.Fatal(err)
+			}
+			got := reportNamespaces(t, p)
+			if len(got) != len(tt.want) {
+				t.Fatalf("PolicyReport命名空间 %v, 期望 %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("PolicyReport命名空间 %v, 期望 %v", got, tt.want)
+				}
+			}
+			if _, err := p.dynamic.Resource(clusterPolicyReportGVR).Get(context.Background(), reportName, metaV1.GetOptions{}); err != nil {
+				t.Errorf("没有写入ClusterPolicyReport: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package operator
+
+import (
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// reportSource PolicyReport结果中的来源名称
+	reportSource = "k8sEPDS"
+	// reportName 本工具生成的PolicyReport/ClusterPolicyReport名称
+	reportName = "k8sepds-over-privilege"
+	// managedByLabel 用于识别本工具管理的报告
+	managedByLabel = "app.kubernetes.io/managed-by"
+)
+
+var (
+	policyReportGVR        = schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "policyreports"}
+	clusterPolicyReportGVR = schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "clusterpolicyreports"}
+)
+
+// BuildReports 根据发现项生成PolicyReport和ClusterPolicyReport
+// 命名空间级别的发现项写入SA所在命名空间的PolicyReport，集群级别的发现项写入ClusterPolicyReport
+// 参数:
+//   - findings: 发现项
+//   - owners: 发现项对应的工作负载，键为发现项的Pod(格式:namespace/name)
+//   - now: 报告时间
+//
+// 返回:
+//   - map[string]*unstructured.Unstructured: 以命名空间为键的PolicyReport
+//   - *unstructured.Unstructured: ClusterPolicyReport
+func BuildReports(findings []models.Finding, owners map[string]coreV1.ObjectReference, now time.Time) (map[string]*unstructured.Unstructured, *unstructured.Unstructured) {
+	findings = append([]models.Finding{}, findings...)
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].SA != findings[j].SA {
+			return findings[i].SA < findings[j].SA
+		}
+		return findings[i].Type < findings[j].Type
+	})
+	namespaced := map[string][]interface{}{}
+	cluster := []interface{}{}
+	for _, finding := range findings {
+		owner, exists := owners[finding.Namespace+"/"+finding.Pod]
+		if finding.Pod == "" || !exists {
+			owner = coreV1.ObjectReference{
+				APIVersion: "v1",
+				Kind:       "ServiceAccount",
+				Namespace:  finding.Namespace,
+				Name:       strings.TrimPrefix(finding.SA, finding.Namespace+"/"),
+			}
+		}
+		result := buildResult(finding, owner, now)
+		if finding.Level == "cluster" {
+			cluster = append(cluster, result)
+		} else {
+			namespaced[finding.Namespace] = append(namespaced[finding.Namespace], result)
+		}
+	}
+
+	reports := map[string]*unstructured.Unstructured{}
+	for namespace, results := range namespaced {
+		report := newReport("PolicyReport", results)
+		report.SetNamespace(namespace)
+		reports[namespace] = report
+	}
+	return reports, newReport("ClusterPolicyReport", cluster)
+}
+
+// buildResult 生成单个发现项的PolicyReport结果
+func buildResult(finding models.Finding, owner coreV1.ObjectReference, now time.Time) map[string]interface{} {
+	resource := map[string]interface{}{
+		"apiVersion": owner.APIVersion,
+		"kind":       owner.Kind,
+		"name":       owner.Name,
+	}
+	if owner.Namespace != "" {
+		resource["namespace"] = owner.Namespace
+	}
+	if owner.UID != "" {
+		resource["uid"] = string(owner.UID)
+	}
+	category := scan.Category(finding.Kind)
+	if category == "" {
+		category = "over-privilege"
+	}
+	return map[string]interface{}{
+		"source":   reportSource,
+		"policy":   "k8sepds-" + scan.DispatchName(finding.Type),
+		"rule":     finding.Type,
+		"category": category,
+		"result":   "fail",
+		"severity": finding.Severity,
+		"scored":   true,
+		"message": fmt.Sprintf("ServiceAccount %s has %s permission (%s scope) via %s",
+			finding.SA, finding.Type, finding.Level, strings.Join(finding.Roles, ",")),
+		"timestamp": map[string]interface{}{
+			"seconds": now.Unix(),
+			"nanos":   int64(0),
+		},
+		"resources": []interface{}{resource},
+		"properties": map[string]interface{}{
+			"serviceAccount": finding.SA,
+			"level":          finding.Level,
+			"kind":           finding.Kind,
+			"node":           finding.Node,
+			"mounted":        strconv.FormatBool(finding.Mounted),
+			"roles":          strings.Join(finding.Roles, ","),
+			"roleBindings":   strings.Join(finding.RoleBindings, ","),
+		},
+	}
+}
+
+// newReport 生成报告对象
+func newReport(kind string, results []interface{}) *unstructured.Unstructured {
+	report := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "wgpolicyk8s.io/v1alpha2",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name": reportName,
+			"labels": map[string]interface{}{
+				managedByLabel: reportSource,
+			},
+		},
+		"results": results,
+		"summary": map[string]interface{}{
+			"pass":  int64(0),
+			"fail":  int64(len(results)),
+			"warn":  int64(0),
+			"error": int64(0),
+			"skip":  int64(0),
+		},
+	}}
+	return report
+}
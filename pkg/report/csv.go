@@ -0,0 +1,47 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvHeader CSV报告的列，顺序固定以便表格工具按列导入
+var csvHeader = []string{
+	"cluster", "serviceAccount", "namespace", "pod", "node", "permission", "rule", "category",
+	"kind", "scope", "severity", "score", "mounted", "roles", "bindings", "incomplete",
+}
+
+// writeCSV 输出CSV报告，每个发现项一行，角色和绑定以分号分隔
+func writeCSV(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, finding := range report.Findings {
+		err := writer.Write([]string{
+			finding.Cluster,
+			finding.ServiceAccount,
+			finding.Namespace,
+			finding.Pod,
+			finding.Node,
+			finding.Permission,
+			finding.Rule,
+			finding.Category,
+			finding.Kind,
+			finding.Scope,
+			finding.Severity,
+			strconv.Itoa(finding.Score),
+			strconv.FormatBool(finding.Mounted),
+			strings.Join(finding.Roles, ";"),
+			strings.Join(finding.Bindings, ";"),
+			strconv.FormatBool(finding.Incomplete),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
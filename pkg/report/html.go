@@ -0,0 +1,136 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+// htmlTemplate 自包含的HTML报告，不引用外部资源，内联脚本提供过滤和按列排序
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.Format(time.DateTime) },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>k8sEPDS 扫描报告 - {{.Cluster}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", "Microsoft YaHei", sans-serif; margin: 24px; color: #222; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; cursor: pointer; user-select: none; }
+th.asc::after { content: " ▲"; }
+th.desc::after { content: " ▼"; }
+tr.critical td.severity { color: #fff; background: #b00020; }
+tr.high td.severity { background: #f4a3a3; }
+tr.medium td.severity { background: #fbe29f; }
+tr.low td.severity { background: #d7ecd9; }
+.clusters, .comparisons, .coverage { margin-bottom: 16px; }
+.incomplete { color: #b00020; }
+.summary span { display: inline-block; margin-right: 16px; }
+.filters { margin: 16px 0; }
+.filters input, .filters select { margin-right: 8px; padding: 4px; }
+</style>
+</head>
+<body>
+<h1>k8sEPDS 扫描报告</h1>
+<div class="summary">
+<span>集群: <code>{{.Cluster}}</code></span>
+<span>受控节点: <code>{{.Node}}</code></span>
+<span>扫描时间: {{time .GeneratedAt}}</span>
+</div>
+<div class="summary">
+<span>关键SA: {{.Summary.ServiceAccounts}}</span>
+<span>发现项: {{.Summary.Findings}}</span>
+<span>风险分: {{.Summary.Score}}</span>
+{{range $severity, $count := .Summary.BySeverity}}<span>{{$severity}}: {{$count}}</span>{{end}}
+</div>
+{{if .Clusters}}<h2>集群</h2>
+<table class="clusters">
+<tr><th>集群</th><th>关键SA</th><th>发现项</th><th>风险分</th><th>错误</th></tr>
+{{range .Clusters}}<tr><td>{{.Name}}</td><td>{{.ServiceAccounts}}</td><td>{{.Findings}}</td><td>{{.Score}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+<h2>跨集群差异</h2>
+<ul class="comparisons">
+{{if not .Compared}}<li>扫描成功的集群少于两个，无法比较</li>
+{{else}}{{range .Comparisons}}<li>{{.Describe}}</li>
+{{else}}<li>各集群的关键权限一致</li>
+{{end}}{{end}}</ul>
+{{end}}{{if .Coverage}}<h2>扫描覆盖</h2>
+<p class="incomplete">{{len .Coverage}} 项资源无法读取，{{.Summary.Incomplete}} 个发现项可能不完整(标记为 (?))</p>
+<table class="coverage">
+<tr>{{if .Clusters}}<th>集群</th>{{end}}<th>资源</th><th>命名空间</th><th>原因</th><th>受影响的SA</th><th>错误</th></tr>
+{{$multi := .Clusters}}{{range .Coverage}}<tr>{{if $multi}}<td>{{.Cluster}}</td>{{end}}<td>{{.Resource}}</td><td>{{.Namespace}}</td><td>{{.Reason}}</td><td>{{range $i, $sa := .Affected}}{{if $i}}<br>{{end}}{{$sa}}{{end}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+{{end}}<div class="filters">
+<input id="search" type="search" placeholder="过滤 SA / 权限 / Pod / 节点 / 角色">
+<select id="severity">
+<option value="">全部严重程度</option>
+<option value="critical">critical</option>
+<option value="high">high</option>
+<option value="medium">medium</option>
+<option value="low">low</option>
+</select>
+<select id="category">
+<option value="">全部分类</option>
+<option value="escalate">escalate</option>
+<option value="hijack">hijack</option>
+<option value="dos">dos</option>
+</select>
+</div>
+<table id="findings">
+<thead>
+<tr>{{if .Clusters}}<th>集群</th>{{end}}<th>SA</th><th>权限类型</th><th>分类</th><th>范围</th><th>严重程度</th><th data-type="number">风险分</th><th>Pod</th><th>节点</th><th>角色</th><th>绑定</th></tr>
+</thead>
+<tbody>
+{{$multi := .Clusters}}{{range .Findings}}<tr class="{{.Severity}}" data-severity="{{.Severity}}" data-category="{{.Category}}">
+{{if $multi}}<td>{{.Cluster}}</td>{{end}}<td>{{.ServiceAccount}}{{if .Incomplete}} <span class="incomplete" title="相关资源无法读取，权限可能不完整">(?)</span>{{end}}</td><td>{{.Permission}}</td><td>{{.Category}}</td><td>{{.Scope}}</td><td class="severity">{{.Severity}}</td><td>{{.Score}}</td><td>{{.Pod}}</td><td>{{.Node}}</td><td>{{range $i, $role := .Roles}}{{if $i}}<br>{{end}}{{$role}}{{end}}</td><td>{{range $i, $binding := .Bindings}}{{if $i}}<br>{{end}}{{$binding}}{{end}}</td>
+</tr>
+{{else}}<tr><td colspan="11">未发现关键ServiceAccount</td></tr>
+{{end}}</tbody>
+</table>
+<script>
+(function () {
+  var table = document.getElementById("findings");
+  var body = table.tBodies[0];
+  var search = document.getElementById("search");
+  var severity = document.getElementById("severity");
+  var category = document.getElementById("category");
+  function filter() {
+    var text = search.value.toLowerCase();
+    Array.prototype.forEach.call(body.rows, function (row) {
+      if (!row.dataset.severity) { return; }
+      var visible = row.textContent.toLowerCase().indexOf(text) !== -1 &&
+        (!severity.value || row.dataset.severity === severity.value) &&
+        (!category.value || row.dataset.category === category.value);
+      row.style.display = visible ? "" : "none";
+    });
+  }
+  search.addEventListener("input", filter);
+  severity.addEventListener("change", filter);
+  category.addEventListener("change", filter);
+  Array.prototype.forEach.call(table.tHead.rows[0].cells, function (th, index) {
+    th.addEventListener("click", function () {
+      var asc = !th.classList.contains("asc");
+      Array.prototype.forEach.call(table.tHead.rows[0].cells, function (cell) { cell.classList.remove("asc", "desc"); });
+      th.classList.add(asc ? "asc" : "desc");
+      var numeric = th.dataset.type === "number";
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[index].textContent, y = b.cells[index].textContent;
+        var result = numeric ? Number(x) - Number(y) : x.localeCompare(y);
+        return asc ? result : -result;
+      });
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
+
+// writeHTML 输出可离线打开的单文件HTML报告
+func writeHTML(w io.Writer, report Report) error {
+	return htmlTemplate.Execute(w, report)
+}
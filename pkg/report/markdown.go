@@ -0,0 +1,108 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// severityOrder 摘要中严重程度的输出顺序
+var severityOrder = []string{"critical", "high", "medium", "low"}
+
+// writeMarkdown 输出Markdown报告，包含摘要和发现项表格，适合贴到工单或PR中
+func writeMarkdown(w io.Writer, report Report) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "# k8sEPDS 扫描报告")
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "- 集群: `%s`\n", report.Cluster)
+	fmt.Fprintf(out, "- 受控节点: `%s`\n", report.Node)
+	fmt.Fprintf(out, "- 扫描时间: %s\n", report.GeneratedAt.Format(time.DateTime))
+	fmt.Fprintf(out, "- 关键SA: %d，发现项: %d，风险分: %d\n", report.Summary.ServiceAccounts, report.Summary.Findings, report.Summary.Score)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "| 严重程度 | 数量 |")
+	fmt.Fprintln(out, "| --- | --- |")
+	for _, severity := range severityOrder {
+		fmt.Fprintf(out, "| %s | %d |\n", severity, report.Summary.BySeverity[severity])
+	}
+	multi := len(report.Clusters) > 0
+	if multi {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "## 集群")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "| 集群 | 关键SA | 发现项 | 风险分 | 错误 |")
+		fmt.Fprintln(out, "| --- | --- | --- | --- | --- |")
+		for _, cluster := range report.Clusters {
+			fmt.Fprintf(out, "| %s | %d | %d | %d | %s |\n", markdownCell(cluster.Name), cluster.ServiceAccounts,
+				cluster.Findings, cluster.Score, markdownCell(cluster.Error))
+		}
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "## 跨集群差异")
+		fmt.Fprintln(out)
+		if !report.Compared() {
+			fmt.Fprintln(out, "扫描成功的集群少于两个，无法比较")
+		} else if len(report.Comparisons) == 0 {
+			fmt.Fprintln(out, "各集群的关键权限一致")
+		}
+		for _, comparison := range report.Comparisons {
+			fmt.Fprintln(out, "-", markdownCell(comparison.Describe()))
+		}
+	}
+	if len(report.Coverage) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "## 扫描覆盖")
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "%d 项资源无法读取，%d 个发现项可能不完整(标记为 (?))\n", len(report.Coverage), report.Summary.Incomplete)
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, clusterColumn(multi, "集群")+"| 资源 | 命名空间 | 原因 | 受影响的SA | 错误 |")
+		fmt.Fprintln(out, clusterColumn(multi, "---")+"| --- | --- | --- | --- | --- |")
+		for _, gap := range report.Coverage {
+			fmt.Fprintf(out, "%s| %s | %s | %s | %s | %s |\n", clusterColumn(multi, markdownCell(gap.Cluster)),
+				markdownCell(gap.Resource), markdownCell(gap.Namespace), gap.Reason, markdownList(gap.Affected()), markdownCell(gap.Message))
+		}
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "## 发现项")
+	fmt.Fprintln(out)
+	if len(report.Findings) == 0 {
+		fmt.Fprintln(out, "未发现关键ServiceAccount")
+		return out.Flush()
+	}
+	fmt.Fprintln(out, clusterColumn(multi, "集群")+"| SA | 权限类型 | 分类 | 范围 | 严重程度 | 风险分 | Pod | 节点 | 角色 | 绑定 |")
+	fmt.Fprintln(out, clusterColumn(multi, "---")+"| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |")
+	for _, finding := range report.Findings {
+		sa := markdownCell(finding.ServiceAccount)
+		if finding.Incomplete {
+			sa += " (?)"
+		}
+		fmt.Fprint(out, clusterColumn(multi, markdownCell(finding.Cluster)))
+		fmt.Fprintf(out, "| %s | %s | %s | %s | %s | %d | %s | %s | %s | %s |\n",
+			sa, markdownCell(finding.Permission), finding.Category,
+			finding.Scope, finding.Severity, finding.Score, markdownCell(finding.Pod), markdownCell(finding.Node),
+			markdownList(finding.Roles), markdownList(finding.Bindings))
+	}
+	return out.Flush()
+}
+
+// clusterColumn 合并报告中表格的集群列，单集群报告没有该列
+func clusterColumn(multi bool, cell string) string {
+	if !multi {
+		return ""
+	}
+	return "| " + cell + " "
+}
+
+// markdownCell 转义表格单元格中的竖线和尖括号
+func markdownCell(value string) string {
+	return strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;").Replace(value)
+}
+
+// markdownList 将列表放入一个表格单元格，每项一行
+func markdownList(items []string) string {
+	cells := make([]string, len(items))
+	for i, item := range items {
+		cells[i] = markdownCell(item)
+	}
+	return strings.Join(cells, "<br>")
+}
@@ -0,0 +1,216 @@
+package report
+
+import (
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ClusterSummary 合并报告中单个集群的统计
+type ClusterSummary struct {
+	Name            string `json:"name"`
+	ScanID          string `json:"scanId,omitempty"`
+	ServiceAccounts int    `json:"serviceAccounts"`
+	Findings        int    `json:"findings"`
+	Score           int    `json:"score"`
+	Error           string `json:"error,omitempty"` // 扫描失败的原因
+}
+
+// Presence 某个集群中SA具有该权限的情况
+type Presence struct {
+	Cluster  string   `json:"cluster"`
+	Severity string   `json:"severity"`
+	Scope    string   `json:"scope"`
+	Roles    []string `json:"roles"`
+}
+
+// Comparison 同一SA的同一种权限在各集群之间的差异
+// 只记录在部分集群中缺失或无法确定，或在各集群中范围不同的权限
+// 没有该权限的集群中，扫描时有影响该SA的覆盖缺口的集群记录在 Unknown 中，不视为缺失
+type Comparison struct {
+	ServiceAccount string     `json:"serviceAccount"`
+	Rule           string     `json:"rule"`
+	Score          int        `json:"score"` // 各集群中的最高风险分
+	Present        []Presence `json:"present"`
+	Absent         []string   `json:"absent"`
+	Unknown        []string   `json:"unknown"` // 相关资源无法读取，无法确定是否具有该权限的集群
+}
+
+// BuildMulti 合并多个集群的扫描记录，并比较各集群之间的差异
+// 参数:
+//   - records: 扫描成功的集群的扫描记录，Cluster 为集群名称
+//   - failed: 扫描失败的集群名称到错误原因的映射
+func BuildMulti(records []models.ScanRecord, failed map[string]string) Report {
+	report := Report{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Now(),
+		Summary:       Summary{BySeverity: map[string]int{}},
+		Findings:      []Finding{},
+		Coverage:      []Gap{},
+		Clusters:      []ClusterSummary{},
+		Comparisons:   []Comparison{},
+	}
+	names := []string{}
+	gaps := map[string][]models.CoverageGap{}
+	for _, record := range records {
+		single := Build(record)
+		names = append(names, record.Cluster)
+		gaps[record.Cluster] = record.Coverage
+		report.Node = record.Node
+		report.Clusters = append(report.Clusters, ClusterSummary{
+			Name:            record.Cluster,
+			ScanID:          record.ID,
+			ServiceAccounts: single.Summary.ServiceAccounts,
+			Findings:        single.Summary.Findings,
+			Score:           single.Summary.Score,
+		})
+		report.Findings = append(report.Findings, single.Findings...)
+		report.Coverage = append(report.Coverage, single.Coverage...)
+		report.Summary.ServiceAccounts += single.Summary.ServiceAccounts
+		report.Summary.Findings += single.Summary.Findings
+		report.Summary.Score += single.Summary.Score
+		report.Summary.Incomplete += single.Summary.Incomplete
+		for severity, count := range single.Summary.BySeverity {
+			report.Summary.BySeverity[severity] += count
+		}
+	}
+	failedNames := make([]string, 0, len(failed))
+	for name := range failed {
+		failedNames = append(failedNames, name)
+	}
+	sort.Strings(failedNames)
+	for _, name := range failedNames {
+		report.Clusters = append(report.Clusters, ClusterSummary{Name: name, Error: failed[name]})
+	}
+	report.Cluster = strings.Join(names, ",")
+	sortFindings(report.Findings)
+	report.Comparisons = compare(report.Findings, names, gaps)
+	return report
+}
+
+// compare 按 SA+归一化权限类型 比较各集群，返回存在差异的权限
+// 参数:
+//   - findings: 全部集群的发现项
+//   - clusters: 扫描成功的集群
+//   - gaps: 各集群扫描时的覆盖缺口
+func compare(findings []Finding, clusters []string, gaps map[string][]models.CoverageGap) []Comparison {
+	type key struct{ sa, rule string }
+	presences := map[key]map[string]*Presence{}
+	scores := map[key]int{}
+	keys := []key{}
+	for _, finding := range findings {
+		k := key{finding.ServiceAccount, finding.Rule}
+		if _, exists := presences[k]; !exists {
+			presences[k] = map[string]*Presence{}
+			keys = append(keys, k)
+		}
+		presence, exists := presences[k][finding.Cluster]
+		if !exists {
+			presence = &Presence{Cluster: finding.Cluster, Severity: finding.Severity, Scope: finding.Scope, Roles: []string{}}
+			presences[k][finding.Cluster] = presence
+		}
+		if severityRank(finding.Severity) > severityRank(presence.Severity) {
+			presence.Severity = finding.Severity
+		}
+		if finding.Scope == "cluster" {
+			presence.Scope = "cluster"
+		}
+		presence.Roles = mergeStrings(presence.Roles, finding.Roles)
+		scores[k] = max(scores[k], finding.Score)
+	}
+
+	result := []Comparison{}
+	for _, k := range keys {
+		comparison := Comparison{ServiceAccount: k.sa, Rule: k.rule, Score: scores[k], Present: []Presence{}, Absent: []string{}, Unknown: []string{}}
+		scopes := map[string]bool{}
+		for _, cluster := range clusters {
+			if presence, exists := presences[k][cluster]; exists {
+				comparison.Present = append(comparison.Present, *presence)
+				scopes[presence.Scope] = true
+			} else if affected(gaps[cluster], k.sa) {
+				comparison.Unknown = append(comparison.Unknown, cluster)
+			} else {
+				comparison.Absent = append(comparison.Absent, cluster)
+			}
+		}
+		if len(comparison.Absent) > 0 || len(comparison.Unknown) > 0 || len(scopes) > 1 {
+			result = append(result, comparison)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		if result[i].ServiceAccount != result[j].ServiceAccount {
+			return result[i].ServiceAccount < result[j].ServiceAccount
+		}
+		return result[i].Rule < result[j].Rule
+	})
+	return result
+}
+
+// affected 覆盖缺口是否影响SA的扫描结果
+func affected(gaps []models.CoverageGap, sa string) bool {
+	for _, gap := range gaps {
+		if scan.Affects(gap, sa) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compared 合并报告中是否至少有两个集群扫描成功，可以进行跨集群比较
+func (r Report) Compared() bool {
+	scanned := 0
+	for _, cluster := range r.Clusters {
+		if cluster.Error == "" {
+			scanned++
+		}
+	}
+	return scanned > 1
+}
+
+// Describe 差异的一行描述，如 "kube-system/a 的 createpods: prod(critical,cluster,cluster-admin) 具有，staging 没有"
+func (c Comparison) Describe() string {
+	present := make([]string, 0, len(c.Present))
+	for _, presence := range c.Present {
+		present = append(present, presence.Cluster+"("+presence.Severity+","+presence.Scope+","+strings.Join(presence.Roles, "+")+")")
+	}
+	text := c.ServiceAccount + " 的 " + c.Rule + ": " + strings.Join(present, "、") + " 具有"
+	if len(c.Absent) > 0 {
+		text += "，" + strings.Join(c.Absent, "、") + " 没有"
+	}
+	if len(c.Unknown) > 0 {
+		text += "，" + strings.Join(c.Unknown, "、") + " 无法确定(相关资源无法读取)"
+	}
+	return text
+}
+
+// severityRank 严重程度的排序
+func severityRank(severity string) int {
+	for i, s := range severityOrder {
+		if s == severity {
+			return len(severityOrder) - i
+		}
+	}
+	return 0
+}
+
+// mergeStrings 合并两个列表并去重，保持顺序
+func mergeStrings(a []string, b []string) []string {
+	for _, item := range b {
+		found := false
+		for _, existing := range a {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			a = append(a, item)
+		}
+	}
+	return a
+}
@@ -0,0 +1,213 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan"
+	"sort"
+	"time"
+)
+
+// SchemaVersion JSON报告的结构版本，字段只增不改，不兼容的修改需要升级版本
+const SchemaVersion = "k8sepds.report/v1"
+
+// Report 报告内容，JSON格式直接序列化该结构
+type Report struct {
+	SchemaVersion string    `json:"schemaVersion"`
+	ScanID        string    `json:"scanId"`
+	GeneratedAt   time.Time `json:"generatedAt"`
+	Cluster       string    `json:"cluster"`
+	Node          string    `json:"node"`
+	Summary       Summary   `json:"summary"`
+	Findings      []Finding `json:"findings"`
+	Coverage      []Gap     `json:"coverage"` // 无法读取的资源，为空表示扫描完整
+
+	// 以下字段只出现在多集群的合并报告中
+	Clusters    []ClusterSummary `json:"clusters,omitempty"`
+	Comparisons []Comparison     `json:"comparisons,omitempty"`
+}
+
+// Summary 发现项统计
+type Summary struct {
+	ServiceAccounts int            `json:"serviceAccounts"` // 关键SA数量
+	Findings        int            `json:"findings"`        // 发现项数量
+	Score           int            `json:"score"`           // 风险分之和
+	BySeverity      map[string]int `json:"bySeverity"`      // 各严重程度的发现项数量
+	Incomplete      int            `json:"incomplete"`      // 可能不完整的发现项数量
+}
+
+// Finding 报告中的发现项
+type Finding struct {
+	Cluster        string   `json:"cluster"`
+	ServiceAccount string   `json:"serviceAccount"` // namespace/name
+	Namespace      string   `json:"namespace"`
+	Pod            string   `json:"pod"`
+	Node           string   `json:"node"`
+	Permission     string   `json:"permission"` // 完整权限类型(如 createpods[default])
+	Rule           string   `json:"rule"`       // 归一化后的权限类型(如 createpods)
+	Category       string   `json:"category"`   // escalate/hijack/dos
+	Kind           string   `json:"kind"`       // anyescalate/restrictescalate 等
+	Scope          string   `json:"scope"`      // cluster/namespace
+	Severity       string   `json:"severity"`
+	Score          int      `json:"score"`
+	Mounted        bool     `json:"mounted"`
+	Roles          []string `json:"roles"`
+	Bindings       []string `json:"bindings"`
+	Incomplete     bool     `json:"incomplete"` // 相关资源无法读取，SA的权限可能不止于此
+}
+
+// Gap 扫描时无法读取的资源
+type Gap struct {
+	Cluster         string   `json:"cluster"`
+	Resource        string   `json:"resource"`  // 如 rolebindings、clusterroles/admin
+	Namespace       string   `json:"namespace"` // 集群范围的资源为空
+	Reason          string   `json:"reason"`    // forbidden/unauthorized/notfound/transport/server/error
+	Message         string   `json:"message"`
+	Global          bool     `json:"global"`          // 是否影响全部SA
+	ServiceAccounts []string `json:"serviceAccounts"` // 受影响的SA
+}
+
+// Affected 受影响的SA，影响全部SA时为 "*"
+func (g Gap) Affected() []string {
+	if g.Global {
+		return []string{"*"}
+	}
+	return g.ServiceAccounts
+}
+
+// Writer 将报告写入输出
+type Writer func(w io.Writer, report Report) error
+
+// Writers 支持的报告格式
+var Writers = map[string]Writer{
+	"json":     writeJSON,
+	"sarif":    writeSARIF,
+	"csv":      writeCSV,
+	"markdown": writeMarkdown,
+	"html":     writeHTML,
+}
+
+// Formats 返回支持的报告格式(已排序)
+func Formats() []string {
+	formats := make([]string, 0, len(Writers))
+	for format := range Writers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Build 根据扫描记录生成报告，发现项按风险分降序、SA和权限类型升序排列
+func Build(record models.ScanRecord) Report {
+	report := Report{
+		SchemaVersion: SchemaVersion,
+		ScanID:        record.ID,
+		GeneratedAt:   record.Time,
+		Cluster:       record.Cluster,
+		Node:          record.Node,
+		Summary:       Summary{BySeverity: map[string]int{}},
+		Findings:      []Finding{},
+	}
+	sas := map[string]bool{}
+	for _, finding := range record.Findings {
+		sas[finding.SA] = true
+		report.Summary.Score += finding.Score
+		report.Summary.BySeverity[finding.Severity]++
+		if finding.Incomplete {
+			report.Summary.Incomplete++
+		}
+		report.Findings = append(report.Findings, Finding{
+			Cluster:        finding.Cluster,
+			ServiceAccount: finding.SA,
+			Namespace:      finding.Namespace,
+			Pod:            finding.Pod,
+			Node:           finding.Node,
+			Permission:     finding.Type,
+			Rule:           scan.DispatchName(finding.Type),
+			Category:       scan.Category(finding.Kind),
+			Kind:           finding.Kind,
+			Scope:          finding.Level,
+			Severity:       finding.Severity,
+			Score:          finding.Score,
+			Mounted:        finding.Mounted,
+			Roles:          nonNil(finding.Roles),
+			Bindings:       nonNil(finding.RoleBindings),
+			Incomplete:     finding.Incomplete,
+		})
+	}
+	report.Coverage = Gaps(record.Cluster, record.Coverage)
+	report.Summary.ServiceAccounts = len(sas)
+	report.Summary.Findings = len(report.Findings)
+	sortFindings(report.Findings)
+	return report
+}
+
+// Gaps 将扫描时无法读取的资源转换为报告中的覆盖缺口
+func Gaps(cluster string, coverage []models.CoverageGap) []Gap {
+	gaps := []Gap{}
+	for _, gap := range coverage {
+		gaps = append(gaps, Gap{
+			Cluster:         cluster,
+			Resource:        gap.Resource,
+			Namespace:       gap.Namespace,
+			Reason:          gap.Reason,
+			Message:         gap.Message,
+			Global:          gap.Global,
+			ServiceAccounts: nonNil(gap.ServiceAccounts),
+		})
+	}
+	return gaps
+}
+
+// sortFindings 发现项按风险分降序、SA、权限类型和集群升序排列
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.ServiceAccount != b.ServiceAccount {
+			return a.ServiceAccount < b.ServiceAccount
+		}
+		if a.Permission != b.Permission {
+			return a.Permission < b.Permission
+		}
+		return a.Cluster < b.Cluster
+	})
+}
+
+// Write 以指定格式写出扫描记录的报告
+// 参数:
+//   - w: 输出
+//   - format: 报告格式(json/sarif/csv/markdown/html)
+//   - record: 扫描记录
+func Write(w io.Writer, format string, record models.ScanRecord) error {
+	return WriteReport(w, format, Build(record))
+}
+
+// WriteReport 以指定格式写出已生成的报告
+func WriteReport(w io.Writer, format string, report Report) error {
+	writer, ok := Writers[format]
+	if !ok {
+		return fmt.Errorf("不支持的报告格式: %s", format)
+	}
+	return writer(w, report)
+}
+
+// writeJSON 输出稳定结构的JSON报告
+func writeJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(report)
+}
+
+// nonNil 保证列表在JSON中输出为 [] 而不是 null
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
@@ -0,0 +1,172 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SARIF 2.1.0 的最小结构，只包含漏洞管理平台导入所需的字段
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications"`
+}
+
+type sarifNotification struct {
+	Level      string         `json:"level"`
+	Message    sarifMessage   `json:"message"`
+	Properties map[string]any `json:"properties"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string         `json:"id"`
+	ShortDescription sarifMessage   `json:"shortDescription"`
+	Properties       map[string]any `json:"properties,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLevels 严重程度到SARIF级别的映射
+var sarifLevels = map[string]string{
+	"critical": "error",
+	"high":     "error",
+	"medium":   "warning",
+	"low":      "note",
+}
+
+// writeSARIF 输出SARIF 2.1.0报告，每种归一化权限类型为一条规则，SA作为逻辑位置
+func writeSARIF(w io.Writer, report Report) error {
+	rules := map[string]sarifRule{}
+	results := []sarifResult{}
+	for _, finding := range report.Findings {
+		cluster := finding.Cluster
+		if cluster == "" {
+			cluster = report.Cluster
+		}
+		if _, exists := rules[finding.Rule]; !exists {
+			rules[finding.Rule] = sarifRule{
+				ID:               finding.Rule,
+				ShortDescription: sarifMessage{Text: fmt.Sprintf("ServiceAccount具有%s权限(%s)", finding.Rule, finding.Category)},
+				Properties:       map[string]any{"category": finding.Category},
+			}
+		}
+		level, ok := sarifLevels[finding.Severity]
+		if !ok {
+			level = "note"
+		}
+		results = append(results, sarifResult{
+			RuleID: finding.Rule,
+			Level:  level,
+			Message: sarifMessage{Text: fmt.Sprintf("%s 具有 %s 权限(范围 %s，角色 %s)",
+				finding.ServiceAccount, finding.Permission, finding.Scope, strings.Join(finding.Roles, ","))},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+				Name:               finding.ServiceAccount[strings.Index(finding.ServiceAccount, "/")+1:],
+				FullyQualifiedName: finding.ServiceAccount,
+				Kind:               "serviceAccount",
+			}}}},
+			PartialFingerprints: map[string]string{"serviceAccountPermission": cluster + "|" + finding.ServiceAccount + "|" + finding.Permission},
+			Properties: map[string]any{
+				"cluster":    cluster,
+				"namespace":  finding.Namespace,
+				"pod":        finding.Pod,
+				"node":       finding.Node,
+				"scope":      finding.Scope,
+				"kind":       finding.Kind,
+				"severity":   finding.Severity,
+				"score":      finding.Score,
+				"mounted":    finding.Mounted,
+				"roles":      finding.Roles,
+				"bindings":   finding.Bindings,
+				"incomplete": finding.Incomplete,
+			},
+		})
+	}
+	ruleIDs := make([]string, 0, len(rules))
+	for id := range rules {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Strings(ruleIDs)
+	driver := sarifDriver{Name: "k8sEPDS", Rules: []sarifRule{}}
+	for _, id := range ruleIDs {
+		driver.Rules = append(driver.Rules, rules[id])
+	}
+	// 无法读取的资源作为执行通知输出，扫描仍视为成功
+	invocation := sarifInvocation{ExecutionSuccessful: true, ToolExecutionNotifications: []sarifNotification{}}
+	for _, gap := range report.Coverage {
+		resource := gap.Resource
+		if gap.Namespace != "" {
+			resource = gap.Namespace + "/" + resource
+		}
+		// 引用的角色不存在时扫描结果仍然完整
+		level := "warning"
+		if gap.Reason == "notfound" {
+			level = "note"
+		}
+		invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, sarifNotification{
+			Level:   level,
+			Message: sarifMessage{Text: fmt.Sprintf("无法读取 %s(%s): %s", resource, gap.Reason, gap.Message)},
+			Properties: map[string]any{
+				"cluster":         gap.Cluster,
+				"resource":        gap.Resource,
+				"namespace":       gap.Namespace,
+				"reason":          gap.Reason,
+				"global":          gap.Global,
+				"serviceAccounts": gap.ServiceAccounts,
+			},
+		})
+	}
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Invocations: []sarifInvocation{invocation}, Results: results}},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(log)
+}
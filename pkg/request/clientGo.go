@@ -9,15 +9,13 @@ package request
 
 import (
 	"fmt"
-	"k8sEPDS/conf"
-	"net/http"
-	"net/url"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
-// GetClientSet 创建Kubernetes客户端实例
+// GetClientSet 创建Kubernetes客户端实例，与原始API请求共用同一集群和认证信息的HTTP连接
 // 参数:
 //   - token: 认证令牌，可选
 //
@@ -25,44 +23,35 @@ import (
 //   - *kubernetes.Clientset: Kubernetes客户端实例
 //   - error: 错误信息
 func GetClientSet(token string) (*kubernetes.Clientset, error) {
-	// 使用request包中已有的认证逻辑
-	opts := NewK8sRequestOption()
-	if err := setupAuthentication(opts); err != nil {
-		return nil, fmt.Errorf("认证设置失败: %w", err)
-	}
-
-	config := &rest.Config{
-		Host: fmt.Sprintf("https://%s", conf.Config.K8s.ApiServer),
-	}
-
-	// 设置认证信息
-	if token != "" {
-		config.BearerToken = token
-	} else {
-		if opts.Token != "" {
-			config.BearerToken = opts.Token
-		} else if opts.Cert != "" && opts.Key != "" {
-			config.TLSClientConfig.CertFile = opts.Cert
-			config.TLSClientConfig.KeyFile = opts.Key
-		}
-	}
-	// 设置代理
-	if conf.Config.K8s.ProxyAddress != "" {
-		proxyURL, err := url.Parse(conf.Config.K8s.ProxyAddress)
-		if err != nil {
-			return nil, fmt.Errorf("代理地址解析失败: %w", err)
-		}
-		config.Proxy = func(_ *http.Request) (*url.URL, error) {
-			return proxyURL, nil
-		}
+	config, httpClient, err := clientFor(token)
+	if err != nil {
+		return nil, err
 	}
-
-	// TLS配置
-	config.TLSClientConfig.Insecure = true
-	
-	clientset, err := kubernetes.NewForConfig(config)
+	clientset, err := kubernetes.NewForConfigAndClient(config, httpClient)
 	if err != nil {
         return nil, fmt.Errorf("创建客户端失败: %w", err)
     }
 	return clientset, nil
 }
+
+// GetDynamicClient 创建动态客户端实例，用于访问CRD等非内置资源
+// 参数:
+//   - token: 认证令牌，可选
+func GetDynamicClient(token string) (dynamic.Interface, error) {
+	config, httpClient, err := clientFor(token)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("创建动态客户端失败: %w", err)
+	}
+	return client, nil
+}
+
+// GetRestConfig 根据配置构建client-go的连接配置
+// 参数:
+//   - token: 认证令牌，可选，为空时使用配置中的认证信息
+func GetRestConfig(token string) (*rest.Config, error) {
+	return ConfigForToken(token)
+}
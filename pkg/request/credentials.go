@@ -0,0 +1,210 @@
+package request
+
+import (
+	"fmt"
+	"k8sEPDS/conf"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/vault"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	// kubeconfig中 auth-provider 为 oidc 时需要注册对应的认证插件
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
+)
+
+var (
+	credentialsMu         sync.Mutex
+	credentialsConfig     *rest.Config     // 已解析的连接配置
+	credentialsSource     models.K8SConfig // 解析连接配置时使用的K8s配置
+	credentialsGeneration uint64           // 连接配置的版本，重新解析后递增，用于区分复用的HTTP客户端
+)
+
+// ResolveConfig 根据K8s配置解析集群地址和认证信息，client-go和原始API请求共用该结果
+// 解析顺序:
+//  1. 配置了kubeconfig时，按上下文读取其中的集群地址、CA、客户端证书、Token、OIDC和exec凭据插件
+//  2. 配置了API服务器地址时，使用Token文件或管理员证书
+//  3. 运行在集群内时，使用Pod的ServiceAccount
+//
+// 返回:
+//   - *rest.Config: 连接配置的副本，调用方可以自由修改
+//   - error: 错误信息
+func ResolveConfig() (*rest.Config, error) {
+	config, _, err := resolveConfig()
+	return config, err
+}
+
+// resolveConfig 解析连接配置，同时返回连接配置的版本
+func resolveConfig() (*rest.Config, uint64, error) {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+	if credentialsConfig != nil && sameSource(credentialsSource, conf.Config.K8s) {
+		return rest.CopyConfig(credentialsConfig), credentialsGeneration, nil
+	}
+
+	config, err := ConfigFor(conf.Config.K8s)
+	if err != nil {
+		return nil, 0, err
+	}
+	credentialsConfig = config
+	credentialsSource = conf.Config.K8s
+	credentialsGeneration++
+	return rest.CopyConfig(config), credentialsGeneration, nil
+}
+
+// Cluster 当前配置连接的集群标识，用于区分扫描历史和Token库中不同集群的记录
+// 使用kubeconfig或集群内配置时为解析得到的API服务器地址(随上下文切换变化)，否则为配置的API服务器地址
+// 无法解析连接配置时返回配置的API服务器地址(可能为空)
+func Cluster() string {
+	k8s := conf.Config.K8s
+	if k8s.Kubeconfig == "" && k8s.ApiServer != "" {
+		return k8s.ApiServer
+	}
+	config, err := ResolveConfig()
+	if err != nil {
+		return k8s.ApiServer
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(config.Host, "https://"), "http://")
+}
+
+// ConfigFor 根据指定的K8s配置解析连接配置，不读取也不修改全局配置，用于同时访问多个集群
+// 解析顺序与 ResolveConfig 相同，默认验证API服务器证书，只有显式开启 Insecure 时才跳过验证
+func ConfigFor(k8s models.K8SConfig) (*rest.Config, error) {
+	var config *rest.Config
+	var err error
+	switch {
+	case k8s.Kubeconfig != "":
+		config, err = kubeconfigConfig(k8s.Kubeconfig, k8s.Context)
+	case k8s.ApiServer != "":
+		config, err = staticConfig(k8s)
+	default:
+		config, err = rest.InClusterConfig()
+		if err != nil {
+			err = fmt.Errorf("未配置API服务器地址或kubeconfig，且不在集群内运行: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k8s.Insecure {
+		config.TLSClientConfig.Insecure = true
+		config.TLSClientConfig.CAFile, config.TLSClientConfig.CAData = "", nil
+	} else if k8s.CAFile != "" {
+		config.TLSClientConfig.CAFile, config.TLSClientConfig.CAData = k8s.CAFile, nil
+	}
+	if k8s.Protobuf {
+		// 只影响client-go的内置资源客户端，动态客户端和原始API请求仍使用JSON
+		config.ContentType = runtime.ContentTypeProtobuf
+		config.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+	}
+	if k8s.DryRun {
+		// 演练结果以JSON输出，client-go的内置资源客户端也使用JSON
+		config.ContentType = runtime.ContentTypeJSON
+		config.AcceptContentTypes = runtime.ContentTypeJSON
+		config.Wrap(dryRunTransport)
+	}
+	if k8s.ProxyAddress != "" {
+		proxyURL, err := url.Parse(k8s.ProxyAddress)
+		if err != nil {
+			return nil, fmt.Errorf("代理地址解析失败: %w", err)
+		}
+		config.Proxy = http.ProxyURL(proxyURL)
+	}
+	return config, nil
+}
+
+// Protobuf 当前集群是否配置为使用protobuf编码读取内置资源
+func Protobuf() bool {
+	config, err := ResolveConfig()
+	return err == nil && config.ContentType == runtime.ContentTypeProtobuf
+}
+
+// ConfigForToken 使用指定的Token访问同一集群，保留集群地址、CA和代理，丢弃原有的认证信息
+// 参数:
+//   - token: 认证令牌，为空时返回配置中的认证信息
+func ConfigForToken(token string) (*rest.Config, error) {
+	config, err := ResolveConfig()
+	if err != nil || token == "" {
+		return config, err
+	}
+	anonymous := anonymousConfig(config)
+	anonymous.BearerToken = token
+	return anonymous, nil
+}
+
+// Contexts 列出kubeconfig中的全部上下文(已排序)
+// 参数:
+//   - paths: kubeconfig文件路径，多个文件用路径分隔符分隔
+func Contexts(paths string) ([]string, error) {
+	rules := &clientcmd.ClientConfigLoadingRules{Precedence: filepath.SplitList(paths)}
+	config, err := rules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("读取kubeconfig失败: %w", err)
+	}
+	contexts := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
+
+// kubeconfigConfig 从kubeconfig文件中读取指定上下文的连接配置
+// 参数:
+//   - paths: kubeconfig文件路径，多个文件用路径分隔符分隔并按顺序合并
+//   - context: 使用的上下文，为空时使用current-context
+func kubeconfigConfig(paths string, context string) (*rest.Config, error) {
+	rules := &clientcmd.ClientConfigLoadingRules{Precedence: filepath.SplitList(paths)}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("读取kubeconfig失败: %w", err)
+	}
+	return config, nil
+}
+
+// staticConfig 使用API服务器地址、Token文件或管理员证书构建连接配置，Token文件和证书密钥可以是凭据库引用
+func staticConfig(k8s models.K8SConfig) (*rest.Config, error) {
+	config := &rest.Config{Host: k8s.ApiServer}
+	if !strings.Contains(config.Host, "://") {
+		config.Host = "https://" + config.Host
+	}
+	if k8s.TokenFile != "" {
+		token, err := vault.ReadFile(k8s.TokenFile)
+		if err != nil && vault.IsRef(k8s.TokenFile) {
+			return nil, fmt.Errorf("读取Token失败: %w", err)
+		}
+		if err == nil && len(token) > 0 {
+			config.BearerToken = strings.TrimSpace(string(token))
+			return config, nil
+		}
+	}
+	if k8s.AdminCert == "" || k8s.AdminCertKey == "" {
+		return nil, fmt.Errorf("未配置有效的认证信息")
+	}
+	config.TLSClientConfig.CertFile = k8s.AdminCert
+	if !vault.IsRef(k8s.AdminCertKey) {
+		config.TLSClientConfig.KeyFile = k8s.AdminCertKey
+		return config, nil
+	}
+	key, err := vault.ReadFile(k8s.AdminCertKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取证书密钥失败: %w", err)
+	}
+	config.TLSClientConfig.KeyData = key
+	return config, nil
+}
+
+// sameSource 判断影响连接配置的K8s配置是否变化
+func sameSource(a models.K8SConfig, b models.K8SConfig) bool {
+	return a.ApiServer == b.ApiServer && a.ProxyAddress == b.ProxyAddress && a.TokenFile == b.TokenFile &&
+		a.Kubeconfig == b.Kubeconfig && a.Context == b.Context && a.AdminCert == b.AdminCert && a.AdminCertKey == b.AdminCertKey &&
+		a.CAFile == b.CAFile && a.Insecure == b.Insecure && a.Protobuf == b.Protobuf && a.DryRun == b.DryRun
+}
@@ -0,0 +1,57 @@
+package request
+
+import (
+	"k8sEPDS/conf"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// twoClusterKubeconfig 包含两个上下文的kubeconfig
+const twoClusterKubeconfig = `apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com:6443
+- name: staging
+  cluster:
+    server: https://staging.example.com:6443
+users:
+- name: admin
+  user:
+    token: fixture-token
+contexts:
+- name: prod
+  context: {cluster: prod, user: admin}
+- name: staging
+  context: {cluster: staging, user: admin}
+`
+
+func TestCluster(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(twoClusterKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	saved := conf.Config.K8s
+	t.Cleanup(func() { conf.Config.K8s = saved })
+
+	conf.Config.K8s.ApiServer, conf.Config.K8s.Kubeconfig, conf.Config.K8s.Context = "", path, ""
+	if got := Cluster(); got != "prod.example.com:6443" {
+		t.Errorf("Cluster() = %q, 期望 current-context 的集群", got)
+	}
+	// 切换上下文后集群标识随之变化，解析连接配置不修改全局配置
+	conf.Config.K8s.Context = "staging"
+	if got := Cluster(); got != "staging.example.com:6443" {
+		t.Errorf("切换上下文后 Cluster() = %q", got)
+	}
+	if conf.Config.K8s.ApiServer != "" {
+		t.Errorf("解析连接配置修改了 ApiServer: %q", conf.Config.K8s.ApiServer)
+	}
+
+	conf.Config.K8s.Kubeconfig, conf.Config.K8s.Context, conf.Config.K8s.ApiServer = "", "", "10.0.0.1:6443"
+	if got := Cluster(); got != "10.0.0.1:6443" {
+		t.Errorf("Cluster() = %q, 期望配置的API服务器地址", got)
+	}
+}
@@ -0,0 +1,116 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"k8sEPDS/conf"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+// dryRunExempt 演练模式中不添加 dryRun 的请求: 只做查询的Review API，以及不支持 dryRun 的连接类子资源
+var dryRunExempt = []string{
+	"/tokenreviews", "/subjectaccessreviews", "/selfsubjectaccessreviews", "/localsubjectaccessreviews",
+	"/selfsubjectrulesreviews", "/selfsubjectreviews",
+	"/exec", "/attach", "/portforward", "/proxy",
+}
+
+// DryRun 当前集群是否为演练模式，演练模式中的变更请求只由API服务器验证，不修改集群
+func DryRun() bool {
+	return conf.Config.K8s.DryRun
+}
+
+// anonymousConfig 去掉认证信息的连接配置，用于替换为指定的Token或证书
+// rest.AnonymousClientConfig 会丢弃 WrapTransport，演练模式中需要重新添加，否则以其他Token发送的变更请求会真正执行
+func anonymousConfig(config *rest.Config) *rest.Config {
+	anonymous := rest.AnonymousClientConfig(config)
+	if DryRun() {
+		anonymous.Wrap(dryRunTransport)
+	}
+	return anonymous
+}
+
+// dryRunTransport 为变更请求(POST/PUT/PATCH/DELETE)添加 dryRun=All，并输出API服务器(含准入控制)是否接受以及变更后的对象
+// client-go和原始API请求共用该传输层，利用模块不需要单独处理
+func dryRunTransport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if !mutating(req) {
+			return next.RoundTrip(req)
+		}
+		req = req.Clone(req.Context())
+		query := req.URL.Query()
+		query.Set("dryRun", "All")
+		req.URL.RawQuery = query.Encode()
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			fmt.Printf("[dry-run] %s %s: 请求失败: %s\n", req.Method, req.URL.Path, err.Error())
+			return resp, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return resp, err
+		}
+		printDryRun(req, resp.StatusCode, body)
+		return resp, nil
+	})
+}
+
+// roundTripperFunc 将函数转换为 http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip 执行请求
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// mutating 请求是否会修改集群
+func mutating(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return false
+	}
+	for _, suffix := range dryRunExempt {
+		if strings.HasSuffix(req.URL.Path, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// printDryRun 输出演练结果: 被接受时输出变更后的对象(去掉 managedFields)，被拒绝时输出API服务器返回的原因
+func printDryRun(req *http.Request, status int, body []byte) {
+	target := req.Method + " " + req.URL.Path
+	if status >= 400 {
+		message := gjson.GetBytes(body, "message").String()
+		if message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		fmt.Printf("[dry-run] %s: API服务器拒绝该变更 (%d %s): %s\n", target, status, http.StatusText(status), message)
+		return
+	}
+	fmt.Printf("[dry-run] %s: API服务器(含准入控制)接受该变更 (%d %s)，集群未被修改\n", target, status, http.StatusText(status))
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return
+	}
+	if metadata, ok := result["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "managedFields")
+	}
+	object, err := yaml.Marshal(result)
+	if err != nil {
+		return
+	}
+	fmt.Println("[dry-run] 变更后的对象:")
+	for _, line := range strings.Split(strings.TrimRight(string(object), "\n"), "\n") {
+		fmt.Println("    " + line)
+	}
+}
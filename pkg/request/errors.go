@@ -0,0 +1,132 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ErrorKind API请求错误的分类
+type ErrorKind string
+
+const (
+	KindForbidden    ErrorKind = "forbidden"    // 403，没有权限
+	KindUnauthorized ErrorKind = "unauthorized" // 401，认证失败
+	KindNotFound     ErrorKind = "notfound"     // 404，资源不存在
+	KindTransport    ErrorKind = "transport"    // 网络错误、证书验证失败或超时
+	KindServer       ErrorKind = "server"       // 429或5xx，服务端暂时不可用
+	KindOther        ErrorKind = "error"        // 其他错误
+)
+
+// 用于 errors.Is 判断错误分类，如 errors.Is(err, request.ErrForbidden)
+var (
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrTransport    = errors.New("transport")
+)
+
+// kindErrors 错误分类对应的 errors.Is 目标
+var kindErrors = map[ErrorKind]error{
+	KindForbidden:    ErrForbidden,
+	KindUnauthorized: ErrUnauthorized,
+	KindNotFound:     ErrNotFound,
+	KindTransport:    ErrTransport,
+}
+
+// APIError 原始API请求的错误
+type APIError struct {
+	Kind       ErrorKind // 错误分类
+	StatusCode int       // HTTP状态码，网络错误时为0
+	Method     string    // HTTP方法
+	Api        string    // API路径
+	Message    string    // API返回的错误信息(Status.message)或响应内容
+	Err        error     // 网络错误的原始错误
+	Continue   string    // 分页令牌过期(410)时API返回的继续令牌
+}
+
+// Error 错误描述
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s %s: %s", e.Method, e.Api, e.Err)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap 返回网络错误的原始错误
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is 按错误分类匹配 ErrForbidden、ErrNotFound 等
+func (e *APIError) Is(target error) bool {
+	return kindErrors[e.Kind] == target && target != nil
+}
+
+// newStatusError 根据HTTP响应创建错误，优先使用Kubernetes Status中的message
+func newStatusError(opts K8sRequestOption, status int, body []byte) *APIError {
+	message := strings.TrimSpace(string(body))
+	if parsed := gjson.GetBytes(body, "message"); parsed.Exists() {
+		message = parsed.String()
+	}
+	return &APIError{Kind: statusKind(status), StatusCode: status, Method: opts.Method, Api: opts.Api, Message: message,
+		Continue: gjson.GetBytes(body, "metadata.continue").String()}
+}
+
+// newTransportError 创建网络错误
+func newTransportError(opts K8sRequestOption, err error) *APIError {
+	return &APIError{Kind: KindTransport, Method: opts.Method, Api: opts.Api, Err: err}
+}
+
+// statusKind HTTP状态码对应的错误分类
+func statusKind(status int) ErrorKind {
+	switch {
+	case status == http.StatusForbidden:
+		return KindForbidden
+	case status == http.StatusUnauthorized:
+		return KindUnauthorized
+	case status == http.StatusNotFound:
+		return KindNotFound
+	case status == http.StatusTooManyRequests || status >= 500:
+		return KindServer
+	}
+	return KindOther
+}
+
+// ExpiredContinue 分页令牌过期(410)时返回API提供的继续令牌，其他错误返回空
+// 使用该令牌可以从下一项继续列出，但之后的各页不再来自同一快照
+// 同时支持原始API请求和client-go返回的错误
+func ExpiredContinue(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode == http.StatusGone {
+			return apiErr.Continue
+		}
+		return ""
+	}
+	var status k8sErrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code == http.StatusGone {
+		return status.Status().Continue
+	}
+	return ""
+}
+
+// KindOf 返回错误的分类，同时支持原始API请求和client-go返回的错误
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Kind
+	}
+	var status k8sErrors.APIStatus
+	if errors.As(err, &status) {
+		return statusKind(int(status.Status().Code))
+	}
+	return KindTransport
+}
@@ -0,0 +1,179 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// PageSize 分页列出资源时每页的数量，小于1时不分页
+var PageSize int64 = 500
+
+// List 分页列出资源，逐项调用 each
+func List(opts K8sRequestOption, each func(item gjson.Result) error) error {
+	return ListWithContext(context.Background(), opts, each)
+}
+
+// ListWithContext 按 limit/continue 分页列出资源，逐项调用 each
+// 每页的响应流式解析，只保留当前页的元素，内存占用与每页的数量相关而与资源总数无关(each 保留的元素除外)
+// 超时时间(Timeout)和重试对每一页单独生效，一页的全部元素读取成功后才调用 each，重试不会重复处理
+// continue 令牌过期(410)时使用API提供的令牌从下一项继续
+// 参数:
+//   - ctx: 上下文
+//   - opts: 请求选项，Api 为列表的路径，可以带查询参数
+//   - each: 处理列表中的一项，返回错误时停止列出并返回该错误
+func ListWithContext(ctx context.Context, opts K8sRequestOption, each func(item gjson.Result) error) error {
+	opts.Method = http.MethodGet
+	if err := validateOptions(&opts); err != nil {
+		return fmt.Errorf("验证请求选项失败: %w", err)
+	}
+	client, host, err := createHTTPClient(&opts)
+	if err != nil {
+		return fmt.Errorf("创建HTTP客户端失败: %w", err)
+	}
+
+	api := opts.Api
+	continueToken := ""
+	var items []json.RawMessage
+	for {
+		opts.Api = pageAPI(api, continueToken)
+		next := ""
+		err := withTimeout(ctx, opts.Timeout, func(ctx context.Context) error {
+			return executeRequest(ctx, client, host+opts.Api, opts, func(body io.Reader) error {
+				items = items[:0]
+				var err error
+				next, err = decodeList(body, func(item json.RawMessage) {
+					items = append(items, item)
+				})
+				return err
+			})
+		})
+		if err != nil {
+			if token := ExpiredContinue(err); continueToken != "" && token != "" {
+				continueToken = token
+				continue
+			}
+			return err
+		}
+		for _, item := range items {
+			if err := each(gjson.ParseBytes(item)); err != nil {
+				return err
+			}
+		}
+		if next == "" || PageSize < 1 {
+			return nil
+		}
+		continueToken = next
+	}
+}
+
+// pageAPI 为列表路径加上分页参数
+func pageAPI(api string, continueToken string) string {
+	if PageSize < 1 {
+		return api
+	}
+	query := url.Values{}
+	query.Set("limit", strconv.FormatInt(PageSize, 10))
+	if continueToken != "" {
+		query.Set("continue", continueToken)
+	}
+	separator := "?"
+	if strings.Contains(api, "?") {
+		separator = "&"
+	}
+	return api + separator + query.Encode()
+}
+
+// withTimeout 在超时时间内执行任务，超时时间为0时不限制
+func withTimeout(ctx context.Context, timeout time.Duration, task func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return task(ctx)
+}
+
+// decodeList 流式解析列表响应，逐个读取 items 中的元素，不把整个响应读入内存
+//
+// 返回:
+//   - string: metadata.continue，没有下一页时为空
+//   - error: 响应不是有效的列表时返回错误
+func decodeList(r io.Reader, each func(item json.RawMessage)) (string, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return "", err
+	}
+	continueToken := ""
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		switch token {
+		case "items":
+			if err := decodeItems(decoder, each); err != nil {
+				return "", err
+			}
+		case "metadata":
+			var metadata struct {
+				Continue string `json:"continue"`
+			}
+			if err := decoder.Decode(&metadata); err != nil {
+				return "", err
+			}
+			continueToken = metadata.Continue
+		default:
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return "", err
+	}
+	return continueToken, nil
+}
+
+// decodeItems 逐个读取 items 数组中的元素，items 为 null 时视为空列表
+func decodeItems(decoder *json.Decoder, each func(item json.RawMessage)) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("items 不是数组")
+	}
+	for decoder.More() {
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		each(item)
+	}
+	return expectDelim(decoder, ']')
+}
+
+// expectDelim 读取下一个分隔符并检查是否符合预期
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("响应不是有效的列表: 期望 %s，实际为 %v", delim, token)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"k8sEPDS/conf"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestDecodeList(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantItems    []string
+		wantContinue string
+		wantErr      bool
+	}{
+		{
+			name:         "列表",
+			body:         `{"kind":"PodList","apiVersion":"v1","metadata":{"resourceVersion":"1","continue":"next"},"items":[{"name":"a"},{"name":"b"}]}`,
+			wantItems:    []string{`{"name":"a"}`, `{"name":"b"}`},
+			wantContinue: "next",
+		},
+		{
+			name:         "metadata在items之后",
+			body:         `{"items":[{"name":"a"}],"metadata":{"continue":"next"}}`,
+			wantItems:    []string{`{"name":"a"}`},
+			wantContinue: "next",
+		},
+		{name: "items为null", body: `{"kind":"RoleBindingList","metadata":{},"items":null}`},
+		{name: "没有items", body: `{"kind":"RoleBindingList","metadata":{}}`},
+		{name: "items为空数组", body: `{"items":[]}`},
+		{name: "items不是数组", body: `{"items":{"name":"a"}}`, wantErr: true},
+		{name: "不是对象", body: `[{"name":"a"}]`, wantErr: true},
+		{name: "响应被截断", body: `{"items":[{"name":"a"},{"na`, wantItems: []string{`{"name":"a"}`}, wantErr: true},
+		{name: "空响应", body: ``, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := []string{}
+			next, err := decodeList(strings.NewReader(tt.body), func(item json.RawMessage) {
+				items = append(items, string(item))
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeList() 错误 %v, 期望出错 %v", err, tt.wantErr)
+			}
+			if strings.Join(items, ",") != strings.Join(tt.wantItems, ",") {
+				t.Errorf("元素 %v, 期望 %v", items, tt.wantItems)
+			}
+			if !tt.wantErr && next != tt.wantContinue {
+				t.Errorf("continue = %q, 期望 %q", next, tt.wantContinue)
+			}
+		})
+	}
+}
+
+// TestDecodeListStreaming 响应尚未读取完时已经读取到的元素就被处理
+func TestDecodeListStreaming(t *testing.T) {
+	reader, writer := io.Pipe()
+	first := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		count := 0
+		_, err := decodeList(reader, func(item json.RawMessage) {
+			if count++; count == 1 {
+				first <- string(item)
+			}
+		})
+		done <- err
+	}()
+
+	io.WriteString(writer, `{"kind":"PodList","items":[{"name":"a"},`)
+	select {
+	case item := <-first:
+		if item != `{"name":"a"}` {
+			t.Errorf("第一个元素 %s", item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("响应读取完之前没有处理已读取的元素")
+	}
+	io.WriteString(writer, `{"name":"b"}],"metadata":{}}`)
+	writer.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fixtureCluster 将全局配置指向测试服务器，测试结束后恢复
+func fixtureCluster(t *testing.T, server string) {
+	t.Helper()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("fixture-token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	saved := conf.Config.K8s
+	t.Cleanup(func() { conf.Config.K8s = saved })
+	conf.Config.K8s.ApiServer, conf.Config.K8s.TokenFile, conf.Config.K8s.Kubeconfig = server, tokenFile, ""
+	conf.Config.K8s.Protobuf, conf.Config.K8s.DryRun, conf.Config.K8s.ProxyAddress = false, false, ""
+}
+
+// pagedServer 按 continue 参数返回分页的测试服务器，pages 的键为continue令牌(第一页为空)，值为状态码和响应
+func pagedServer(t *testing.T, pages map[string]response) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fixture-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		mu.Unlock()
+		page, exists := pages[r.URL.Query().Get("continue")]
+		if !exists {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(page.status)
+		io.WriteString(w, page.body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+func TestListWithContext(t *testing.T) {
+	expired := `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Expired","code":410,` +
+		`"message":"The provided continue parameter is too old","metadata":{"continue":"c3"}}`
+	tests := []struct {
+		name        string
+		pages       map[string]response
+		wantNames   []string
+		wantQueries int
+		wantKind    ErrorKind // 为空表示期望成功
+	}{
+		{
+			name: "分页",
+			pages: map[string]response{
+				"":   {status: http.StatusOK, body: `{"metadata":{"continue":"c1"},"items":[{"name":"a"},{"name":"b"}]}`},
+				"c1": {status: http.StatusOK, body: `{"metadata":{},"items":[{"name":"c"}]}`},
+			},
+			wantNames:   []string{"a", "b", "c"},
+			wantQueries: 2,
+		},
+		{
+			name: "items为null",
+			pages: map[string]response{
+				"": {status: http.StatusOK, body: `{"kind":"RoleBindingList","metadata":{},"items":null}`},
+			},
+			wantNames:   []string{},
+			wantQueries: 1,
+		},
+		{
+			name: "continue令牌过期后使用API提供的令牌继续",
+			pages: map[string]response{
+				"":   {status: http.StatusOK, body: `{"metadata":{"continue":"c1"},"items":[{"name":"a"}]}`},
+				"c1": {status: http.StatusOK, body: `{"metadata":{"continue":"c2"},"items":[{"name":"b"}]}`},
+				"c2": {status: http.StatusGone, body: expired},
+				"c3": {status: http.StatusOK, body: `{"metadata":{},"items":[{"name":"c"}]}`},
+			},
+			wantNames:   []string{"a", "b", "c"},
+			wantQueries: 4,
+		},
+		{
+			name: "第一页返回410时不继续",
+			pages: map[string]response{
+				"":   {status: http.StatusGone, body: expired},
+				"c3": {status: http.StatusOK, body: `{"metadata":{},"items":[{"name":"c"}]}`},
+			},
+			wantNames:   []string{},
+			wantQueries: 1,
+			wantKind:    KindOther,
+		},
+		{
+			name: "读取失败的页不处理",
+			pages: map[string]response{
+				"":   {status: http.StatusOK, body: `{"metadata":{"continue":"c1"},"items":[{"name":"a"}]}`},
+				"c1": {status: http.StatusOK, body: `{"metadata":{},"items":[{"name":"b"},{"na`},
+			},
+			wantNames:   []string{"a"},
+			wantQueries: 2,
+			wantKind:    KindTransport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, queries := pagedServer(t, tt.pages)
+			fixtureCluster(t, server.URL)
+			names := []string{}
+			err := ListWithContext(context.Background(), K8sRequestOption{Api: "/api/v1/pods?labelSelector=app", RetryTimes: -1},
+				func(item gjson.Result) error {
+					names = append(names, item.Get("name").String())
+					return nil
+				})
+			if tt.wantKind == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantKind != "" && KindOf(err) != tt.wantKind {
+				t.Fatalf("错误 %v (%s), 期望 %s", err, KindOf(err), tt.wantKind)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("元素 %v, 期望 %v", names, tt.wantNames)
+			}
+			if len(*queries) != tt.wantQueries {
+				t.Errorf("请求 %d 次, 期望 %d 次: %v", len(*queries), tt.wantQueries, *queries)
+			}
+			for _, query := range *queries {
+				if !strings.Contains(query, "labelSelector=app") || !strings.Contains(query, "limit=500") {
+					t.Errorf("分页请求缺少查询参数: %s", query)
+				}
+			}
+		})
+	}
+}
+
+// TestListWithContextStop each 返回错误时停止列出并返回该错误
+func TestListWithContextStop(t *testing.T) {
+	server, queries := pagedServer(t, map[string]response{
+		"":   {status: http.StatusOK, body: `{"metadata":{"continue":"c1"},"items":[{"name":"a"},{"name":"b"}]}`},
+		"c1": {status: http.StatusOK, body: `{"metadata":{},"items":[{"name":"c"}]}`},
+	})
+	fixtureCluster(t, server.URL)
+	stop := errors.New("stop")
+	count := 0
+	err := List(K8sRequestOption{Api: "/api/v1/pods"}, func(gjson.Result) error {
+		count++
+		return stop
+	})
+	if !errors.Is(err, stop) || count != 1 || len(*queries) != 1 {
+		t.Errorf("错误 %v, 处理 %d 项, 请求 %d 次", err, count, len(*queries))
+	}
+}
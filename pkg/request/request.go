@@ -8,153 +8,157 @@
 package request
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
 	"io"
-	"k8sEPDS/conf"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+var (
+	apiErrorsMu sync.Mutex
+	apiErrors   = map[string]uint64{} // API请求错误计数(原因->次数)
+)
+
 type K8sRequestOption struct {
 	Token      string            // Token认证信息
 	Cert       string            // 客户端证书
 	Key        string            // 客户端密钥
-	Server     string            // API服务器地址
+	Server     string            // API服务器地址，为空时使用解析得到的集群地址
 	Api        string            // API路径
 	Method     string            // HTTP方法
 	PostData   string            // POST请求数据
 	Header     map[string]string // 自定义请求头
-	Timeout    time.Duration     // 请求超时时间
-	RetryTimes int               // 重试次数
+	Timeout    time.Duration     // 请求超时时间(含重试)，为0时不限制
+	RetryTimes int               // 可重试错误的重试次数，为0时使用默认值3，小于0时不重试
 }
 
+// defaultRetryTimes 未指定重试次数时的默认值
+const defaultRetryTimes = 3
+
 // NewK8sRequestOption 创建请求选项实例
 func NewK8sRequestOption() *K8sRequestOption {
 	return &K8sRequestOption{
-		Server:     conf.Config.K8s.ApiServer,
 		Method:     http.MethodGet,
 		Header:     make(map[string]string),
 		Timeout:    10 * time.Second,
-		RetryTimes: 3,
+		RetryTimes: defaultRetryTimes,
 	}
 }
 
 // ApiRequest 发送API请求
 func ApiRequest(opts K8sRequestOption) (string, error) {
+	return ApiRequestWithContext(context.Background(), opts)
+}
+
+// ApiRequestWithContext 发送API请求，context取消或超时时停止请求和重试
+// 参数:
+//   - ctx: 上下文
+//   - opts: 请求选项
+func ApiRequestWithContext(ctx context.Context, opts K8sRequestOption) (string, error) {
 	if err := validateOptions(&opts); err != nil {
 		return "", fmt.Errorf("验证请求选项失败: %w", err)
 	}
 
-	client, err := createHTTPClient(&opts)
+	client, host, err := createHTTPClient(&opts)
 	if err != nil {
 		return "", fmt.Errorf("创建HTTP客户端失败: %w", err)
 	}
-
-	return executeRequest(client, opts)
+	var body string
+	err = withTimeout(ctx, opts.Timeout, func(ctx context.Context) error {
+		return executeRequest(ctx, client, host+opts.Api, opts, func(r io.Reader) error {
+			data, err := io.ReadAll(r)
+			body = string(data)
+			return err
+		})
+	})
+	return body, err
 }
 
 // validateOptions 验证请求选项
 func validateOptions(opts *K8sRequestOption) error {
-	if opts.Server == "" {
-		opts.Server = conf.Config.K8s.ApiServer
-	}
-	if opts.Server == "" {
-		return fmt.Errorf("服务器地址未设置")
-	}
 	opts.Method = strings.ToUpper(opts.Method)
 	if !isValidMethod(opts.Method) {
 		return fmt.Errorf("不支持的HTTP方法: %s", opts.Method)
 	}
-	return nil
-}
-
-// setupAuthentication 设置认证信息
-func setupAuthentication(opts *K8sRequestOption) error {
-	// 1. 优先使用opts.Token
-	if opts.Token != "" {
-		return nil
+	if opts.RetryTimes == 0 {
+		opts.RetryTimes = defaultRetryTimes
 	}
-
-	// 2. 尝试从token文件读取
-	if conf.Config.K8s.TokenFile != "" {
-		tokenBytes, err := os.ReadFile(conf.Config.K8s.TokenFile)
-		if err == nil && len(tokenBytes) > 0 {
-			opts.Token = string(tokenBytes)
-			return nil
-		}
-	}
-
-	// 3. 使用证书认证
-	if opts.Cert == "" {
-		opts.Cert = conf.Config.K8s.AdminCert
-		opts.Key = conf.Config.K8s.AdminCertKey
-	}
-
-	if opts.Cert == "" || opts.Key == "" {
-		return fmt.Errorf("未配置有效的认证信息")
-	}
-
 	return nil
 }
 
-// createHTTPClient 创建HTTP客户端
-func createHTTPClient(opts *K8sRequestOption) (*http.Client, error) {
-	if err := setupAuthentication(opts); err != nil {
-		return nil, err
-	}
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
+// createHTTPClient 返回复用的HTTP客户端，认证信息与client-go共用 ResolveConfig 的解析结果
+// 指定了Token或证书时只替换认证信息，集群地址、CA和代理保持不变
+//
+// 返回:
+//   - *http.Client: 带认证的HTTP客户端
+//   - string: API服务器地址(含协议)
+//   - error: 错误信息
+func createHTTPClient(opts *K8sRequestOption) (*http.Client, string, error) {
+	config, generation, err := resolveConfig()
+	if err != nil {
+		return nil, "", err
 	}
-
-	// 如果使用证书认证
-	if opts.Token == "" {
-		cert, err := tls.LoadX509KeyPair(opts.Cert, opts.Key)
-		if err != nil {
-			return nil, fmt.Errorf("加载证书失败: %w", err)
+	key := clientKey{generation: generation, token: opts.Token, server: opts.Server}
+	if opts.Token != "" {
+		config = anonymousConfig(config)
+		config.BearerToken = opts.Token
+	} else if opts.Cert != "" && opts.Key != "" {
+		config = anonymousConfig(config)
+		config.TLSClientConfig.CertFile = opts.Cert
+		config.TLSClientConfig.KeyFile = opts.Key
+		key.cert, key.key = opts.Cert, opts.Key
+	}
+	if opts.Server != "" {
+		config.Host = opts.Server
+		if !strings.Contains(config.Host, "://") {
+			config.Host = "https://" + config.Host
 		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
-	transport := &http.Transport{
-		TLSClientConfig: tlsConfig,
+	client, err := sharedClient(key, config)
+	if err != nil {
+		return nil, "", err
 	}
-
-	return &http.Client{
-		Transport: transport,
-		Timeout:   opts.Timeout,
-	}, nil
+	return client, strings.TrimSuffix(config.Host, "/"), nil
 }
 
-// executeRequest 执行HTTP请求
-func executeRequest(client *http.Client, opts K8sRequestOption) (string, error) {
-	url := fmt.Sprintf("https://%s%s", opts.Server, opts.Api)
-
-	var lastErr error
-	for i := 0; i <= opts.RetryTimes; i++ {
-		resp, err := sendRequest(client, url, opts)
+// executeRequest 执行HTTP请求，只重试429、5xx和网络错误，优先按 Retry-After 等待
+// 参数:
+//   - read: 读取成功响应的内容，重试时会再次调用
+func executeRequest(ctx context.Context, client *http.Client, url string, opts K8sRequestOption, read func(body io.Reader) error) error {
+	retries := max(opts.RetryTimes, 0)
+	for attempt := 0; ; attempt++ {
+		status, retryAfter, err := sendRequest(ctx, client, url, opts, read)
 		if err == nil {
-			return resp, nil
+			return nil
+		}
+		if attempt >= retries || !retryable(opts.Method, status, err) {
+			if attempt > 0 {
+				return fmt.Errorf("请求失败(重试%d次): %w", attempt, err)
+			}
+			return err
+		}
+		if sleepErr := sleep(ctx, retryDelay(attempt, retryAfter)); sleepErr != nil {
+			return fmt.Errorf("请求失败(重试%d次): %w", attempt, err)
 		}
-		lastErr = err
-		time.Sleep(time.Second * time.Duration(i+1))
 	}
-
-	return "", fmt.Errorf("请求失败(重试%d次): %w", opts.RetryTimes, lastErr)
 }
 
-// sendRequest 发送单次请求
-func sendRequest(client *http.Client, url string, opts K8sRequestOption) (string, error) {
-	req, err := http.NewRequest(opts.Method, url, strings.NewReader(opts.PostData))
+// sendRequest 发送单次请求，成功时由 read 读取响应内容
+//
+// 返回:
+//   - int: HTTP状态码，网络错误时为0
+//   - string: 响应头 Retry-After 的值
+//   - error: 错误信息，类型为 *APIError
+func sendRequest(ctx context.Context, client *http.Client, url string, opts K8sRequestOption, read func(body io.Reader) error) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, opts.Method, url, strings.NewReader(opts.PostData))
 	if err != nil {
-		return "", err
+		return 0, "", err
 	}
 
-	// 设置Token认证
-	if opts.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+opts.Token)
-	}
 	// 设置其他请求头
 	for key, value := range opts.Header {
 		req.Header.Set(key, value)
@@ -162,20 +166,26 @@ func sendRequest(client *http.Client, url string, opts K8sRequestOption) (string
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		recordAPIError("transport")
+		return 0, "", newTransportError(opts, certificateHint(err))
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
 	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			recordAPIError("transport")
+			return 0, "", newTransportError(opts, fmt.Errorf("读取响应失败: %w", err))
+		}
+		recordAPIError(strconv.Itoa(resp.StatusCode))
+		return resp.StatusCode, resp.Header.Get("Retry-After"), newStatusError(opts, resp.StatusCode, body)
 	}
 
-	return string(body), nil
+	if err := read(resp.Body); err != nil {
+		recordAPIError("transport")
+		return 0, "", newTransportError(opts, fmt.Errorf("读取响应失败: %w", err))
+	}
+	return resp.StatusCode, "", nil
 }
 
 // isValidMethod 验证HTTP方法是否有效
@@ -189,3 +199,23 @@ func isValidMethod(method string) bool {
 	}
 	return validMethods[method]
 }
+
+// recordAPIError 记录一次API请求错误
+// 参数:
+//   - reason: 错误原因，HTTP状态码或 transport(网络错误)
+func recordAPIError(reason string) {
+	apiErrorsMu.Lock()
+	defer apiErrorsMu.Unlock()
+	apiErrors[reason]++
+}
+
+// APIErrorCounts 返回程序启动以来各原因的API请求错误次数
+func APIErrorCounts() map[string]uint64 {
+	apiErrorsMu.Lock()
+	defer apiErrorsMu.Unlock()
+	result := make(map[string]uint64, len(apiErrors))
+	for reason, count := range apiErrors {
+		result[reason] = count
+	}
+	return result
+}
@@ -0,0 +1,175 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// response 测试服务器依次返回的响应
+type response struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+// sequenceServer 依次返回指定响应的测试服务器，超出后重复最后一个响应，返回收到的请求数
+func sequenceServer(t *testing.T, responses ...response) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		index := int(requests.Add(1)) - 1
+		resp := responses[min(index, len(responses)-1)]
+		if resp.retryAfter != "" {
+			w.Header().Set("Retry-After", resp.retryAfter)
+		}
+		w.WriteHeader(resp.status)
+		io.WriteString(w, resp.body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestExecuteRequest(t *testing.T) {
+	ok := response{status: http.StatusOK, body: `{"kind":"PodList"}`}
+	tests := []struct {
+		name         string
+		method       string
+		retries      int
+		responses    []response
+		wantRequests int32
+		wantKind     ErrorKind // 为空表示期望成功
+		minElapsed   time.Duration
+	}{
+		{
+			name:         "403不重试",
+			method:       http.MethodGet,
+			retries:      3,
+			responses:    []response{{status: http.StatusForbidden, body: `{"kind":"Status","message":"pods is forbidden"}`}, ok},
+			wantRequests: 1,
+			wantKind:     KindForbidden,
+		},
+		{
+			name:         "429按Retry-After秒数等待后重试",
+			method:       http.MethodGet,
+			retries:      3,
+			responses:    []response{{status: http.StatusTooManyRequests, retryAfter: "1"}, ok},
+			wantRequests: 2,
+			minElapsed:   time.Second,
+		},
+		{
+			name:         "429按Retry-After日期等待后重试",
+			method:       http.MethodGet,
+			retries:      3,
+			responses:    []response{{status: http.StatusTooManyRequests, retryAfter: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)}, ok},
+			wantRequests: 2,
+		},
+		{
+			name:         "POST遇到429同样重试",
+			method:       http.MethodPost,
+			retries:      3,
+			responses:    []response{{status: http.StatusTooManyRequests, retryAfter: "0"}, ok},
+			wantRequests: 2,
+		},
+		{
+			name:         "POST遇到503不重试",
+			method:       http.MethodPost,
+			retries:      3,
+			responses:    []response{{status: http.StatusServiceUnavailable, retryAfter: "0"}, ok},
+			wantRequests: 1,
+			wantKind:     KindServer,
+		},
+		{
+			name:         "GET遇到503重试",
+			method:       http.MethodGet,
+			retries:      3,
+			responses:    []response{{status: http.StatusServiceUnavailable, retryAfter: "0"}, ok},
+			wantRequests: 2,
+		},
+		{
+			name:         "重试次数用尽",
+			method:       http.MethodGet,
+			retries:      2,
+			responses:    []response{{status: http.StatusServiceUnavailable, retryAfter: "0"}},
+			wantRequests: 3,
+			wantKind:     KindServer,
+		},
+		{
+			name:         "重试次数为0时不重试",
+			method:       http.MethodGet,
+			retries:      0,
+			responses:    []response{{status: http.StatusTooManyRequests, retryAfter: "0"}, ok},
+			wantRequests: 1,
+			wantKind:     KindServer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := sequenceServer(t, tt.responses...)
+			var body string
+			start := time.Now()
+			err := executeRequest(context.Background(), server.Client(), server.URL+"/api/v1/pods",
+				K8sRequestOption{Method: tt.method, Api: "/api/v1/pods", RetryTimes: tt.retries},
+				func(r io.Reader) error {
+					data, err := io.ReadAll(r)
+					body = string(data)
+					return err
+				})
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("请求 %d 次, 期望 %d 次", got, tt.wantRequests)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minElapsed {
+				t.Errorf("耗时 %s, 期望至少等待 %s", elapsed, tt.minElapsed)
+			}
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if body != ok.body {
+					t.Errorf("响应 %q", body)
+				}
+				return
+			}
+			if KindOf(err) != tt.wantKind {
+				t.Errorf("错误分类 %q, 期望 %q: %v", KindOf(err), tt.wantKind, err)
+			}
+		})
+	}
+}
+
+// TestExecuteRequestCanceled 等待重试时context结束立即返回，context已结束时不再发送请求
+func TestExecuteRequestCanceled(t *testing.T) {
+	server, requests := sequenceServer(t, response{status: http.StatusServiceUnavailable, retryAfter: "30"})
+	opts := K8sRequestOption{Method: http.MethodGet, Api: "/api/v1/pods", RetryTimes: 3}
+	read := func(io.Reader) error { return nil }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := executeRequest(ctx, server.Client(), server.URL, opts, read)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("context结束后仍在等待重试: %s", elapsed)
+	}
+	if err == nil || KindOf(err) != KindServer {
+		t.Errorf("错误 %v, 期望返回最后一次的503", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("请求 %d 次, 期望 1 次", got)
+	}
+
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	err = executeRequest(canceled, server.Client(), server.URL, opts, read)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("错误 %v, 期望 context.Canceled", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("context已结束时不应发送请求，共请求 %d 次", got)
+	}
+}
@@ -0,0 +1,158 @@
+package request
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// 重试的等待时间
+const (
+	retryBaseDelay = 500 * time.Millisecond // 第一次重试前的等待时间，之后每次翻倍
+	retryMaxDelay  = 30 * time.Second       // 单次等待时间上限，Retry-After 超过该值时也按该值等待
+)
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[clientKey]*http.Client{} // 复用的HTTP客户端，同一集群和认证信息共用连接
+)
+
+// clientKey 复用HTTP客户端的键，连接配置重新解析后版本变化，旧的客户端不再使用
+type clientKey struct {
+	generation uint64
+	token      string
+	cert       string
+	key        string
+	server     string
+}
+
+// sharedClient 返回连接配置对应的HTTP客户端，相同的键复用同一个客户端及其连接池
+// 参数:
+//   - key: 复用的键
+//   - config: 连接配置，Timeout 会被忽略，超时由请求的context控制
+func sharedClient(key clientKey, config *rest.Config) (*http.Client, error) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if client, exists := clients[key]; exists {
+		return client, nil
+	}
+	for existing := range clients {
+		if existing.generation != key.generation {
+			clients[existing].CloseIdleConnections()
+			delete(clients, existing)
+		}
+	}
+	config = rest.CopyConfig(config)
+	config.Timeout = 0
+	client, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	clients[key] = client
+	return client, nil
+}
+
+// clientFor 解析当前集群的连接配置，使用指定Token访问时替换认证信息，并返回复用的HTTP客户端
+// 参数:
+//   - token: 认证令牌，为空时使用配置中的认证信息
+func clientFor(token string) (*rest.Config, *http.Client, error) {
+	config, generation, err := resolveConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if token != "" {
+		config = anonymousConfig(config)
+		config.BearerToken = token
+	}
+	client, err := sharedClient(clientKey{generation: generation, token: token}, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, client, nil
+}
+
+// retryable 判断请求失败后是否可以重试
+// 429和5xx可以重试；网络错误只有在请求幂等或连接尚未建立时才重试，避免重复提交POST/PATCH
+// 参数:
+//   - method: HTTP方法
+//   - status: HTTP状态码，网络错误时为0
+//   - err: 请求的错误
+func retryable(method string, status int, err error) bool {
+	if status == 0 {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || isCertificateError(err) {
+			return false
+		}
+		if idempotent(method) {
+			return true
+		}
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	return status >= 500 && status != http.StatusNotImplemented && idempotent(method)
+}
+
+// idempotent 判断HTTP方法是否幂等
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryDelay 第attempt次重试前的等待时间，优先使用响应中的 Retry-After
+// 参数:
+//   - attempt: 已失败的次数，从0开始
+//   - retryAfter: 响应头 Retry-After 的值，秒数或HTTP日期
+func retryDelay(attempt int, retryAfter string) time.Duration {
+	if retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, retryMaxDelay)
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return min(max(time.Until(date), 0), retryMaxDelay)
+		}
+	}
+	return min(retryBaseDelay<<attempt, retryMaxDelay)
+}
+
+// sleep 等待指定时间，context结束时提前返回
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isCertificateError 判断是否为证书验证失败
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var verification *tls.CertificateVerificationError
+	return errors.As(err, &unknownAuthority) || errors.As(err, &hostname) || errors.As(err, &invalid) || errors.As(err, &verification)
+}
+
+// certificateHint 证书验证失败时提示配置CA证书
+func certificateHint(err error) error {
+	if isCertificateError(err) {
+		return fmt.Errorf("%w (请配置 k8s.caFile 指定CA证书，或在测试环境中显式设置 k8s.insecure=true)", err)
+	}
+	return err
+}
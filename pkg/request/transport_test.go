@@ -0,0 +1,70 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	tests := []struct {
+		method string
+		status int
+		err    error
+		want   bool
+	}{
+		{http.MethodGet, http.StatusForbidden, nil, false},
+		{http.MethodGet, http.StatusUnauthorized, nil, false},
+		{http.MethodGet, http.StatusNotFound, nil, false},
+		{http.MethodGet, http.StatusTooManyRequests, nil, true},
+		{http.MethodPost, http.StatusTooManyRequests, nil, true},
+		{http.MethodGet, http.StatusServiceUnavailable, nil, true},
+		{http.MethodDelete, http.StatusInternalServerError, nil, true},
+		{http.MethodGet, http.StatusNotImplemented, nil, false},
+		{http.MethodPost, http.StatusServiceUnavailable, nil, false},
+		{http.MethodPatch, http.StatusInternalServerError, nil, false},
+		{http.MethodGet, 0, readErr, true},
+		{http.MethodPost, 0, readErr, false},
+		{http.MethodPost, 0, fmt.Errorf("请求失败: %w", dialErr), true},
+		{http.MethodGet, 0, context.Canceled, false},
+		{http.MethodGet, 0, fmt.Errorf("请求失败: %w", context.DeadlineExceeded), false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.method, tt.status, tt.err); got != tt.want {
+			t.Errorf("retryable(%s, %d, %v) = %v, 期望 %v", tt.method, tt.status, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{name: "第一次重试", attempt: 0, min: retryBaseDelay, max: retryBaseDelay},
+		{name: "指数退避", attempt: 2, min: 4 * retryBaseDelay, max: 4 * retryBaseDelay},
+		{name: "退避上限", attempt: 20, min: retryMaxDelay, max: retryMaxDelay},
+		{name: "Retry-After秒数", attempt: 3, retryAfter: "2", min: 2 * time.Second, max: 2 * time.Second},
+		{name: "Retry-After为0", retryAfter: "0", min: 0, max: 0},
+		{name: "Retry-After超过上限", retryAfter: "3600", min: retryMaxDelay, max: retryMaxDelay},
+		{name: "Retry-After HTTP日期", retryAfter: time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), min: 8 * time.Second, max: 10 * time.Second},
+		{name: "Retry-After 过去的日期", retryAfter: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), min: 0, max: 0},
+		{name: "Retry-After 无效时退避", attempt: 1, retryAfter: "soon", min: 2 * retryBaseDelay, max: 2 * retryBaseDelay},
+		{name: "Retry-After 负数时退避", retryAfter: "-1", min: retryBaseDelay, max: retryBaseDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.attempt, tt.retryAfter); got < tt.min || got > tt.max {
+				t.Errorf("retryDelay(%d, %q) = %s, 期望 %s ~ %s", tt.attempt, tt.retryAfter, got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package scan
+
+import (
+	"k8sEPDS/models"
+	"strings"
+)
+
+// PermissionKinds 归一化后的权限类型到权限分类的映射
+// any 表示可以影响任意组件，restrict 表示只能影响特定命名空间或资源
+var PermissionKinds = map[string]string{
+	//createrolebinding*2、patchrolebinding*2、patchrole*2
+	"impersonate":               "anyescalate",
+	"createclusterrolebindings": "anyescalate",
+	"patchclusterroles":         "anyescalate",
+	"createtokens":              "anyescalate",
+	"createpods":                "anyescalate",
+	"createpodcontrollers":      "anyescalate",
+	"patchpodcontrollers":       "anyescalate",
+	"createwebhookconfig":       "anyescalate",
+	"patchwebhookconfig":        "anyescalate",
+	"createrolebindings":        "restrictescalate",
+	"patchclusterrolebindings":  "restrictescalate",
+	"patchrolebindings":         "restrictescalate",
+	"patchroles":                "restrictescalate",
+	"createsecrets":             "restrictescalate",
+	"getsecrets":                "restrictescalate",
+	"execpods":                  "restrictescalate",
+	"execpods2":                 "restrictescalate",
+	"patchpods":                 "restrictescalate",
+	"watchsecrets":              "restrictescalate",
+	"patchnodes":                "anyhijack",
+	"deletenodes":               "anyhijack",
+	"deletepods":                "restricthijack",
+	"createpodeviction":         "restricthijack",
+}
+
+// resourceReplacements 将具体的资源名称归一化为利用模块使用的名称
+var resourceReplacements = map[string]string{
+	"daemonsets":                      "podcontrollers",
+	"deployments":                     "podcontrollers",
+	"statefulsets":                    "podcontrollers",
+	"replicasets":                     "podcontrollers",
+	"jobs":                            "podcontrollers",
+	"cronjobs":                        "podcontrollers",
+	"replicationcontrollers":          "podcontrollers",
+	"mutatingwebhookconfigurations":   "webhookconfig",
+	"validatingwebhookconfigurations": "webhookconfig",
+}
+
+// severities 权限分类对应的严重程度和风险分
+var severities = map[string]struct {
+	Severity string
+	Score    int
+}{
+	"anyescalate":      {"critical", 10},
+	"restrictescalate": {"high", 7},
+	"anyhijack":        {"high", 6},
+	"restricthijack":   {"medium", 4},
+}
+
+// DispatchName 去掉权限类型的范围后缀并归一化资源名称
+// 例如 "createdeployments[default]" -> "createpodcontrollers"
+func DispatchName(permType string) string {
+	result := permType
+	if strings.Contains(result, "(") {
+		result = result[:strings.Index(result, "(")]
+	} else if strings.Contains(result, "[") {
+		result = result[:strings.Index(result, "[")]
+	}
+	for old, new := range resourceReplacements {
+		result = strings.Replace(result, old, new, -1)
+	}
+	return result
+}
+
+// Scope 获取单个权限类型的范围
+// 带 [命名空间] 后缀的权限由RoleBinding授予，只在该命名空间内有效，其余由ClusterRoleBinding授予，为集群范围
+// 例如 "createpods[default]" -> "namespace"，"createpods"、"getsecrets(admin-token)" -> "cluster"
+// 关键SA的 Level 是其全部权限中最大的范围，不能用来判断单个权限
+func Scope(permType string) string {
+	if strings.HasSuffix(permType, "]") && strings.Contains(permType, "[") {
+		return "namespace"
+	}
+	return "cluster"
+}
+
+// Kind 获取权限类型的分类
+// 命名空间级别且不在 kube-system 下的权限会被降级为 restrict
+// 参数:
+//   - level: 该权限的范围(cluster/namespace)，见 Scope
+//   - permType: 高危权限类型
+//
+// 返回:
+//   - string: 权限分类(如anyescalate、restricthijack)
+func Kind(level string, permType string) string {
+	kind := PermissionKinds[DispatchName(permType)]
+	if level == "namespace" && !strings.Contains(permType, "kube-system") {
+		return "restrict" + Category(kind)
+	}
+	return kind
+}
+
+// Category 获取权限分类所属的攻击类别(escalate/hijack/dos)
+func Category(kind string) string {
+	if strings.Contains(kind, "escalate") {
+		return "escalate"
+	} else if strings.Contains(kind, "hijack") {
+		return "hijack"
+	} else if strings.Contains(kind, "dos") {
+		return "dos"
+	}
+	return ""
+}
+
+// Severity 获取权限分类的严重程度和风险分
+func Severity(kind string) (string, int) {
+	if severity, ok := severities[kind]; ok {
+		return severity.Severity, severity.Score
+	}
+	return "low", 1
+}
+
+// Findings 将关键SA按权限类型展开为发现项，每个发现项的范围和严重程度由该权限本身的范围决定
+func Findings(criticalSAs []models.CriticalSA) []models.Finding {
+	result := []models.Finding{}
+	for _, criticalSA := range criticalSAs {
+		namespace := SANamespace(criticalSA.SA0.Name)
+		for _, permType := range criticalSA.Type {
+			level := Scope(permType)
+			kind := Kind(level, permType)
+			severity, score := Severity(kind)
+			result = append(result, models.Finding{
+				SA:           criticalSA.SA0.Name,
+				Namespace:    namespace,
+				Pod:          criticalSA.SA0.SAPod.Name,
+				Node:         criticalSA.SA0.SAPod.NodeName,
+				Type:         permType,
+				Kind:         kind,
+				Level:        level,
+				Severity:     severity,
+				Score:        score,
+				Mounted:      criticalSA.SA0.IsMounted,
+				Roles:        criticalSA.Roles,
+				RoleBindings: criticalSA.SA0.RoleBindings,
+			})
+		}
+	}
+	return result
+}
+
+// FilterFindings 按命名空间、权限类型和严重程度过滤发现项，空条件表示不过滤
+// 权限类型既可以是完整类型(如 createpods[default])，也可以是归一化后的名称(如 createpods)
+func FilterFindings(findings []models.Finding, namespace string, permType string, severity string) []models.Finding {
+	result := []models.Finding{}
+	for _, finding := range findings {
+		if namespace != "" && finding.Namespace != namespace {
+			continue
+		}
+		if permType != "" && finding.Type != permType && DispatchName(finding.Type) != permType {
+			continue
+		}
+		if severity != "" && finding.Severity != severity {
+			continue
+		}
+		result = append(result, finding)
+	}
+	return result
+}
+
+// SANamespace 获取SA完整名称(格式:namespace/name)中的命名空间
+func SANamespace(saName string) string {
+	namespace, _, _ := strings.Cut(saName, "/")
+	return namespace
+}
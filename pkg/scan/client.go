@@ -0,0 +1,137 @@
+package scan
+
+import (
+	"context"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/scan/utils"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ScanClient 使用指定的客户端扫描集群，返回全部SA、其中的关键SA和无法读取的资源
+// 与 ScanCluster 的检测逻辑一致，但不依赖全局配置，可以同时扫描多个集群
+// 部分资源无法读取时继续扫描，绑定关系完全无法读取时返回错误
+// 参数:
+//   - ctx: 上下文
+//   - clientset: 被扫描集群的客户端
+//   - node: 受控节点名称
+func ScanClient(ctx context.Context, clientset kubernetes.Interface, node string) (map[string]*models.SA, []models.CriticalSA, []models.CoverageGap, error) {
+	coverage := &Coverage{}
+	index, err := BuildClientIndex(ctx, clientset, coverage, node)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sas := index.SaBinding(coverage)
+	index.MarkMounted(sas)
+	return sas, GetCriticalSA(sas, node), coverage.Gaps(), nil
+}
+
+// BuildClientIndex 使用指定的客户端读取集群资源并构建索引，无法读取的资源记录到 coverage 中
+// 列表按 request.PageSize 分页读取，逐页转换为内部模型并归并到索引中
+// 参数:
+//   - ctx: 上下文
+//   - clientset: 被扫描集群的客户端
+//   - coverage: 覆盖缺口收集
+//   - node: 受控节点名称，索引保留该节点上的Pod用于 PodByUID，为空时不保留
+func BuildClientIndex(ctx context.Context, clientset kubernetes.Interface, coverage *Coverage, node string) (*Index, error) {
+	rbac := clientset.RbacV1()
+	return buildIndex(coverage, indexSource{
+		namespaces: func() ([]string, error) {
+			names := []string{}
+			err := listPaged(ctx, clientset.CoreV1().Namespaces().List, func(list *coreV1.NamespaceList) {
+				for i := range list.Items {
+					names = append(names, list.Items[i].Name)
+				}
+			})
+			return names, err
+		},
+		clusterRoles: func(each func(models.Role)) error {
+			return listPaged(ctx, rbac.ClusterRoles().List, func(list *rbacV1.ClusterRoleList) {
+				for i := range list.Items {
+					each(utils.ConvertClusterRole(&list.Items[i]))
+				}
+			})
+		},
+		roles: func(namespace string, each func(models.Role)) error {
+			return listPaged(ctx, rbac.Roles(namespace).List, func(list *rbacV1.RoleList) {
+				for i := range list.Items {
+					each(utils.ConvertRole(&list.Items[i]))
+				}
+			})
+		},
+		getRole: func(role string) ([]models.Rule, error) {
+			if namespace, name, namespaced := strings.Cut(role, "/"); namespaced {
+				obj, err := rbac.Roles(namespace).Get(ctx, name, metaV1.GetOptions{})
+				if err != nil {
+					return nil, err
+				}
+				return utils.ConvertRules(obj.Rules), nil
+			}
+			obj, err := rbac.ClusterRoles().Get(ctx, role, metaV1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return utils.ConvertRules(obj.Rules), nil
+		},
+		clusterRoleBindings: func(each func(models.RoleBinding)) error {
+			return listPaged(ctx, rbac.ClusterRoleBindings().List, func(list *rbacV1.ClusterRoleBindingList) {
+				for i := range list.Items {
+					each(utils.ConvertClusterRoleBinding(&list.Items[i]))
+				}
+			})
+		},
+		roleBindings: func(namespace string, each func(models.RoleBinding)) error {
+			return listPaged(ctx, rbac.RoleBindings(namespace).List, func(list *rbacV1.RoleBindingList) {
+				for i := range list.Items {
+					each(utils.ConvertRoleBinding(&list.Items[i]))
+				}
+			})
+		},
+		serviceAccounts: func(namespace string, each func(models.ServiceAccount)) error {
+			return listPaged(ctx, clientset.CoreV1().ServiceAccounts(namespace).List, func(list *coreV1.ServiceAccountList) {
+				for i := range list.Items {
+					each(utils.ConvertServiceAccount(&list.Items[i]))
+				}
+			})
+		},
+		pods: func(namespace string, saAutomount func(namespace string, name string) *bool, each func(models.Pod)) error {
+			return listPaged(ctx, clientset.CoreV1().Pods(namespace).List, func(list *coreV1.PodList) {
+				for i := range list.Items {
+					pod := &list.Items[i]
+					each(utils.ConvertPod(pod, saAutomount(pod.Namespace, pod.Spec.ServiceAccountName)))
+				}
+			})
+		},
+	}, node, Concurrency)
+}
+
+// listPaged 按 request.PageSize 分页列出资源，逐页交给 handle 处理，只保留当前页的对象
+// continue 令牌过期(410)时使用API提供的令牌从下一项继续
+// 参数:
+//   - list: client-go的List方法
+//   - handle: 处理一页
+func listPaged[L interface{ GetContinue() string }](ctx context.Context, list func(ctx context.Context, opts metaV1.ListOptions) (L, error),
+	handle func(page L)) error {
+	opts := metaV1.ListOptions{Limit: max(request.PageSize, 0)}
+	for {
+		page, err := list(ctx, opts)
+		if err != nil {
+			if token := request.ExpiredContinue(err); opts.Continue != "" && token != "" {
+				opts.Continue = token
+				continue
+			}
+			return err
+		}
+		handle(page)
+		next := page.GetContinue()
+		if next == "" || opts.Limit == 0 {
+			return nil
+		}
+		opts.Continue = next
+	}
+}
@@ -0,0 +1,216 @@
+package scan
+
+import (
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/request"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Coverage 收集扫描时无法读取的资源，可以并发使用，nil 表示不收集
+type Coverage struct {
+	mu   sync.Mutex
+	gaps []models.CoverageGap
+}
+
+// Add 记录一个无法读取的资源
+// 参数:
+//   - resource: 资源(如 rolebindings、clusterroles/admin)
+//   - namespace: 资源所在的命名空间，集群范围的资源为空
+//   - err: 读取资源时的错误
+//   - global: 是否影响全部发现项
+//   - sas: 受影响的SA
+func (c *Coverage) Add(resource string, namespace string, err error, global bool, sas []string) {
+	if c == nil || err == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gaps = append(c.gaps, models.CoverageGap{
+		Resource:        resource,
+		Namespace:       namespace,
+		Reason:          string(request.KindOf(err)),
+		Message:         err.Error(),
+		Global:          global,
+		ServiceAccounts: sas,
+	})
+}
+
+// Gaps 按资源和命名空间排序的覆盖缺口
+func (c *Coverage) Gaps() []models.CoverageGap {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	gaps := append([]models.CoverageGap{}, c.gaps...)
+	sort.SliceStable(gaps, func(i, j int) bool {
+		if gaps[i].Resource != gaps[j].Resource {
+			return gaps[i].Resource < gaps[j].Resource
+		}
+		return gaps[i].Namespace < gaps[j].Namespace
+	})
+	return gaps
+}
+
+// Affects 判断覆盖缺口是否使SA的发现项可能不完整，资源不存在(notfound)时扫描结果仍然完整
+func Affects(gap models.CoverageGap, sa string) bool {
+	if gap.Reason == string(request.KindNotFound) {
+		return false
+	}
+	if gap.Global {
+		return true
+	}
+	for _, name := range gap.ServiceAccounts {
+		if name == sa {
+			return true
+		}
+	}
+	return false
+}
+
+// GlobalGaps 影响全部发现项的覆盖缺口(如无法列出绑定或Pod)
+// 有这类缺口时扫描结果不可信，没有发现项也不代表集群中没有高危权限
+func GlobalGaps(gaps []models.CoverageGap) []models.CoverageGap {
+	result := []models.CoverageGap{}
+	for _, gap := range gaps {
+		if gap.Global && gap.Reason != string(request.KindNotFound) {
+			result = append(result, gap)
+		}
+	}
+	return result
+}
+
+// Complete 判断覆盖缺口是否都不影响扫描结果(只有引用的角色不存在)
+func Complete(gaps []models.CoverageGap) bool {
+	for _, gap := range gaps {
+		if gap.Reason != string(request.KindNotFound) {
+			return false
+		}
+	}
+	return true
+}
+
+// MarkIncomplete 标记受覆盖缺口影响的发现项
+func MarkIncomplete(findings []models.Finding, gaps []models.CoverageGap) {
+	for i := range findings {
+		for _, gap := range gaps {
+			if Affects(gap, findings[i].SA) {
+				findings[i].Incomplete = true
+				break
+			}
+		}
+	}
+}
+
+// bindRoles 根据绑定关系构建SA权限模型，并记录无法读取的角色及绑定到该角色的SA
+// 参数:
+//   - coverage: 覆盖缺口收集
+//   - clusterrolebindingList: ClusterRoleBinding列表
+//   - rolebindingList: RoleBinding列表
+//   - getRules: 根据角色名称(格式: namespace/name 或 name)获取角色规则
+func bindRoles(coverage *Coverage, clusterrolebindingList []models.RoleBinding, rolebindingList []models.RoleBinding,
+	getRules func(role string) ([]models.Rule, error)) map[string]*models.SA {
+	failed := map[string]error{}
+	sas := BuildSaBinding(clusterrolebindingList, rolebindingList, func(role string) []models.Rule {
+		if _, exists := failed[role]; exists {
+			return nil
+		}
+		rules, err := getRules(role)
+		if err != nil {
+			failed[role] = err
+		}
+		return rules
+	})
+
+	roles := make([]string, 0, len(failed))
+	for role := range failed {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		resource, namespace := "clusterroles/"+role, ""
+		if ns, name, found := strings.Cut(role, "/"); found {
+			resource, namespace = "roles/"+name, ns
+		}
+		coverage.Add(resource, namespace, failed[role], false, boundSAs(role, clusterrolebindingList, rolebindingList))
+	}
+	return sas
+}
+
+// boundSAs 绑定到指定角色的SA(已排序)
+func boundSAs(role string, bindingLists ...[]models.RoleBinding) []string {
+	names := map[string]bool{}
+	for _, bindings := range bindingLists {
+		for _, binding := range bindings {
+			if binding.RoleRef != role {
+				continue
+			}
+			for _, sa := range binding.Subject {
+				names[sa] = true
+			}
+		}
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// listByNamespace 列出全部命名空间中的资源，集群范围的列出被拒绝(403)时改为并发地逐个命名空间列出
+// 读取到的资源逐个交给 each，逐个命名空间列出时 each 会被并发调用；列出出错前已读取的部分不会撤回
+// 无法读取的命名空间记录为覆盖缺口
+// 参数:
+//   - coverage: 覆盖缺口收集，nil 表示不记录
+//   - limit: 同时发出的请求数量限制
+//   - resource: 资源名称(如 rolebindings、pods)
+//   - list: 逐个读取命名空间中的资源，命名空间为空时读取全部
+//   - namespaces: 列出命名空间
+//   - each: 处理一项资源
+//
+// 返回:
+//   - bool: 是否至少读取到一部分资源
+//   - map[string]error: 无法读取的命名空间及原因，键为空表示全部命名空间都无法读取
+func listByNamespace[T any](coverage *Coverage, limit limiter, resource string, list func(namespace string, each func(T)) error,
+	namespaces func() ([]string, error), each func(T)) (bool, map[string]error) {
+	failed := map[string]error{}
+	var err error
+	limit.do(func() { err = list("", each) })
+	if err == nil {
+		return true, failed
+	}
+	if request.KindOf(err) != request.KindForbidden {
+		coverage.Add(resource, "", err, true, nil)
+		failed[""] = err
+		return false, failed
+	}
+	names, nsErr := namespaces()
+	if nsErr != nil {
+		coverage.Add(resource, "", err, true, nil)
+		failed[""] = err
+		return false, failed
+	}
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, namespace := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit.do(func() { errs[i] = list(namespace, each) })
+		}()
+	}
+	wg.Wait()
+	readable := false
+	for i, namespace := range names {
+		if errs[i] != nil {
+			coverage.Add(resource, namespace, errs[i], true, nil)
+			failed[namespace] = errs[i]
+			continue
+		}
+		readable = true
+	}
+	return readable, failed
+}
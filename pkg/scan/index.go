@@ -0,0 +1,287 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/request"
+	"k8sEPDS/pkg/scan/utils"
+	"strings"
+	"sync"
+)
+
+// Concurrency 构建集群索引时同时发出的API请求数量上限
+var Concurrency = 8
+
+// indexSource 构建集群索引时读取资源的方式，命名空间为空表示全部命名空间
+// 列表类资源逐项交给 each，构建索引时只保留扫描需要的部分，不保留完整的列表
+type indexSource struct {
+	namespaces          func() ([]string, error)
+	clusterRoles        func(each func(models.Role)) error
+	roles               func(namespace string, each func(models.Role)) error
+	getRole             func(role string) ([]models.Rule, error) // 无法列出角色时逐个读取，参数格式与 utils.GetRulesFromRole 一致
+	clusterRoleBindings func(each func(models.RoleBinding)) error
+	roleBindings        func(namespace string, each func(models.RoleBinding)) error
+	serviceAccounts     func(namespace string, each func(models.ServiceAccount)) error
+	pods                func(namespace string, saAutomount func(namespace string, name string) *bool, each func(models.Pod)) error
+}
+
+// Index 一次扫描中读取的集群资源及其索引
+// 每种资源只列出一次，之后的角色规则、绑定、Pod和SA查询都在内存中完成，可以并发使用
+// 列表逐页读取并归并到索引中，绑定只保留主体为SA的部分，Pod只保留每个SA的一个Pod和受控节点上的Pod，
+// 内存占用与SA、角色和绑定的数量相关，与Pod数量无关
+type Index struct {
+	ClusterRoleBindings []models.RoleBinding // 主体包含SA的ClusterRoleBinding
+	RoleBindings        []models.RoleBinding // 主体包含SA的RoleBinding
+
+	clusterRoles    map[string][]models.Rule         // ClusterRole名称 -> 规则
+	roles           map[string][]models.Rule         // namespace/name -> 规则
+	bySubject       map[string][]models.RoleBinding  // SA(namespace/name) -> 绑定
+	serviceAccounts map[string]models.ServiceAccount // namespace/name -> SA
+	saPods          map[string]models.Pod            // SA(namespace/name) -> 最后列出的使用该SA的Pod
+	node            string                           // 受控节点名称
+	nodePods        map[string]models.Pod            // UID -> 受控节点上的Pod
+
+	clusterRolesErr error                                    // 无法列出ClusterRole的原因
+	roleErrs        map[string]error                         // 无法列出Role的命名空间，键为空表示全部命名空间
+	getRole         func(role string) ([]models.Rule, error) // 无法列出角色时逐个读取
+	mu              sync.Mutex
+	fetched         map[string]fetchedRole // 逐个读取的角色
+}
+
+// fetchedRole 逐个读取的角色规则
+type fetchedRole struct {
+	rules []models.Rule
+	err   error
+}
+
+// BuildIndex 使用全局配置读取集群资源并构建索引，无法读取的资源记录到 coverage 中
+// 配置了protobuf编码时通过client-go读取，否则通过原始API请求分页读取
+// 参数:
+//   - coverage: 覆盖缺口收集
+//   - node: 受控节点名称，索引保留该节点上的Pod用于 PodByUID，为空时不保留
+//
+// 返回:
+//   - *Index: 集群索引，出错时也包含能够读取的部分
+//   - error: ClusterRoleBinding和RoleBinding都无法读取时返回错误
+func BuildIndex(coverage *Coverage, node string) (*Index, error) {
+	if request.Protobuf() {
+		if clientset, err := request.GetClientSet(""); err == nil {
+			return BuildClientIndex(context.Background(), clientset, coverage, node)
+		}
+	}
+	return buildIndex(coverage, indexSource{
+		namespaces:          utils.GetNamespaces,
+		clusterRoles:        utils.ListClusterRoles,
+		roles:               utils.ListRoles,
+		getRole:             utils.GetRulesFromRole,
+		clusterRoleBindings: utils.ListClusterRoleBindings,
+		roleBindings:        utils.ListRoleBindings,
+		serviceAccounts:     utils.ListServiceAccounts,
+		pods:                utils.ListPods,
+	}, node, Concurrency)
+}
+
+// buildIndex 并发读取集群资源并构建索引
+// 参数:
+//   - coverage: 覆盖缺口收集
+//   - source: 读取资源的方式
+//   - node: 受控节点名称
+//   - concurrency: 同时发出的请求数量上限
+func buildIndex(coverage *Coverage, source indexSource, node string, concurrency int) (*Index, error) {
+	index := &Index{
+		clusterRoles:    map[string][]models.Rule{},
+		roles:           map[string][]models.Rule{},
+		bySubject:       map[string][]models.RoleBinding{},
+		serviceAccounts: map[string]models.ServiceAccount{},
+		saPods:          map[string]models.Pod{},
+		node:            node,
+		nodePods:        map[string]models.Pod{},
+		roleErrs:        map[string]error{},
+		getRole:         source.getRole,
+		fetched:         map[string]fetchedRole{},
+	}
+	limit := newLimiter(concurrency)
+	namespaces := sync.OnceValues(func() (names []string, err error) {
+		limit.do(func() { names, err = source.namespaces() })
+		return names, err
+	})
+
+	var wg sync.WaitGroup
+	run := func(task func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task()
+		}()
+	}
+	// 逐个命名空间列出时同一种资源会被并发写入索引，每种资源使用各自的锁
+	// 角色列表读取失败时不记录覆盖缺口，由 bindRoles 按角色记录受影响的SA
+	run(func() {
+		limit.do(func() {
+			index.clusterRolesErr = source.clusterRoles(func(role models.Role) {
+				index.clusterRoles[role.Name] = role.Rules
+			})
+		})
+	})
+	run(func() {
+		var mu sync.Mutex
+		_, index.roleErrs = listByNamespace(nil, limit, "roles", source.roles, namespaces, func(role models.Role) {
+			mu.Lock()
+			defer mu.Unlock()
+			index.roles[role.Namespace+"/"+role.Name] = role.Rules
+		})
+	})
+	var clusterBindingsErr error
+	run(func() {
+		limit.do(func() {
+			clusterBindingsErr = source.clusterRoleBindings(func(binding models.RoleBinding) {
+				if len(binding.Subject) != 0 {
+					index.ClusterRoleBindings = append(index.ClusterRoleBindings, binding)
+				}
+			})
+		})
+		coverage.Add("clusterrolebindings", "", clusterBindingsErr, true, nil)
+	})
+	bindingsReadable := false
+	run(func() {
+		var mu sync.Mutex
+		bindingsReadable, _ = listByNamespace(coverage, limit, "rolebindings", source.roleBindings, namespaces, func(binding models.RoleBinding) {
+			if len(binding.Subject) == 0 {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			index.RoleBindings = append(index.RoleBindings, binding)
+		})
+	})
+	// 解析Pod的Token挂载状态需要SA的设置，Pod在SA读取完成后再读取
+	accountsDone := make(chan struct{})
+	run(func() {
+		defer close(accountsDone)
+		var mu sync.Mutex
+		listByNamespace(coverage, limit, "serviceaccounts", source.serviceAccounts, namespaces, func(account models.ServiceAccount) {
+			mu.Lock()
+			defer mu.Unlock()
+			index.serviceAccounts[account.Namespace+"/"+account.Name] = account
+		})
+	})
+	run(func() {
+		<-accountsDone
+		var mu sync.Mutex
+		listByNamespace(coverage, limit, "pods", func(namespace string, each func(models.Pod)) error {
+			return source.pods(namespace, index.automount, each)
+		}, namespaces, func(pod models.Pod) {
+			mu.Lock()
+			defer mu.Unlock()
+			index.addPod(pod)
+		})
+	})
+	wg.Wait()
+
+	for _, bindings := range [][]models.RoleBinding{index.ClusterRoleBindings, index.RoleBindings} {
+		for _, binding := range bindings {
+			for _, sa := range binding.Subject {
+				index.bySubject[sa] = append(index.bySubject[sa], binding)
+			}
+		}
+	}
+	if clusterBindingsErr != nil && !bindingsReadable {
+		return index, fmt.Errorf("获取ClusterRoleBinding失败: %w", clusterBindingsErr)
+	}
+	return index, nil
+}
+
+// addPod 将Pod归并到索引中，只保留每个SA最后列出的Pod(与 MarkMounted 一致)和受控节点上的Pod
+func (index *Index) addPod(pod models.Pod) {
+	index.saPods[pod.Namespace+"/"+pod.ServiceAccount] = pod
+	if index.node != "" && pod.NodeName == index.node && pod.Uid != "" {
+		index.nodePods[pod.Uid] = pod
+	}
+}
+
+// SaBinding 根据索引中的绑定关系和角色构建SA权限模型，无法读取的角色记录到 coverage 中
+func (index *Index) SaBinding(coverage *Coverage) map[string]*models.SA {
+	return bindRoles(coverage, index.ClusterRoleBindings, index.RoleBindings, index.Rules)
+}
+
+// MarkMounted 根据索引中的Pod标记被挂载的ServiceAccount，结果与使用完整Pod列表调用 MarkMounted 相同
+func (index *Index) MarkMounted(sas map[string]*models.SA) {
+	for key, sa := range sas {
+		if pod, exists := index.saPods[key]; exists {
+			sa.IsMounted = true
+			sa.SAPod = pod
+		}
+	}
+}
+
+// Rules 获取角色规则，参数格式与 utils.GetRulesFromRole 一致
+// 角色列表无法读取时逐个读取角色(可能只有get权限)，结果缓存在索引中
+func (index *Index) Rules(role string) ([]models.Rule, error) {
+	namespace, _, namespaced := strings.Cut(role, "/")
+	var listErr error
+	if namespaced {
+		if rules, exists := index.roles[role]; exists {
+			return rules, nil
+		}
+		if listErr = index.roleErrs[namespace]; listErr == nil {
+			listErr = index.roleErrs[""]
+		}
+	} else {
+		if rules, exists := index.clusterRoles[role]; exists {
+			return rules, nil
+		}
+		listErr = index.clusterRolesErr
+	}
+	if listErr == nil {
+		return nil, &request.APIError{Kind: request.KindNotFound, StatusCode: 404, Message: "角色不存在: " + role}
+	}
+	if index.getRole == nil {
+		return nil, listErr
+	}
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if cached, exists := index.fetched[role]; exists {
+		return cached.rules, cached.err
+	}
+	rules, err := index.getRole(role)
+	index.fetched[role] = fetchedRole{rules: rules, err: err}
+	return rules, err
+}
+
+// Bindings 绑定到SA(格式: namespace/name)的ClusterRoleBinding和RoleBinding
+func (index *Index) Bindings(sa string) []models.RoleBinding {
+	return index.bySubject[sa]
+}
+
+// ServiceAccount 根据名称(格式: namespace/name)获取SA
+func (index *Index) ServiceAccount(name string) (models.ServiceAccount, bool) {
+	sa, exists := index.serviceAccounts[name]
+	return sa, exists
+}
+
+// PodByUID 根据UID获取受控节点上的Pod，构建索引时没有指定受控节点或Pod不在该节点上时返回 false
+func (index *Index) PodByUID(uid string) (models.Pod, bool) {
+	pod, exists := index.nodePods[uid]
+	return pod, exists
+}
+
+// automount 获取SA的 automountServiceAccountToken 设置，SA不存在或未设置时为 nil
+func (index *Index) automount(namespace string, name string) *bool {
+	return index.serviceAccounts[namespace+"/"+name].AutomountToken
+}
+
+// limiter 限制同时发出的请求数量
+type limiter chan struct{}
+
+// newLimiter 创建同时最多执行 n 个任务的限制器，n 小于1时按1处理
+func newLimiter(n int) limiter {
+	return make(limiter, max(n, 1))
+}
+
+// do 等待空闲后执行任务
+func (l limiter) do(task func()) {
+	l <- struct{}{}
+	defer func() { <-l }()
+	task()
+}
@@ -0,0 +1,121 @@
+package scan
+
+import (
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/request"
+	"testing"
+)
+
+// fixtureSource 包含大量Pod的集群，Pod平均分布在 node0..node9 上，全部使用 app/sa0..app/sa4
+// forbidden 为 true 时集群范围的列出返回403，只能逐个命名空间列出
+func fixtureSource(pods int, forbidden bool) indexSource {
+	denied := &request.APIError{Kind: request.KindForbidden, StatusCode: 403}
+	namespaced := func(namespace string) error {
+		if forbidden && namespace == "" {
+			return denied
+		}
+		return nil
+	}
+	return indexSource{
+		namespaces: func() ([]string, error) { return []string{"app", "kube-system"}, nil },
+		clusterRoles: func(each func(models.Role)) error {
+			each(models.Role{Name: "admin", Rules: []models.Rule{{Resourcs: []string{"secrets"}, Verbs: []string{"get"}}}})
+			return nil
+		},
+		roles: func(namespace string, each func(models.Role)) error { return namespaced(namespace) },
+		clusterRoleBindings: func(each func(models.RoleBinding)) error {
+			each(models.RoleBinding{Name: "users", RoleRef: "admin"})
+			each(models.RoleBinding{Name: "sa0-admin", RoleRef: "admin", Subject: []string{"app/sa0"}})
+			return nil
+		},
+		roleBindings: func(namespace string, each func(models.RoleBinding)) error {
+			if err := namespaced(namespace); err != nil {
+				return err
+			}
+			if namespace == "" || namespace == "app" {
+				each(models.RoleBinding{Namespace: "app", Name: "sa1-admin", RoleRef: "admin", Subject: []string{"app/sa1"}})
+			}
+			return nil
+		},
+		serviceAccounts: func(namespace string, each func(models.ServiceAccount)) error { return namespaced(namespace) },
+		pods: func(namespace string, saAutomount func(string, string) *bool, each func(models.Pod)) error {
+			if err := namespaced(namespace); err != nil {
+				return err
+			}
+			if namespace != "" && namespace != "app" {
+				return nil
+			}
+			for i := 0; i < pods; i++ {
+				each(models.Pod{
+					Namespace:      "app",
+					Name:           fmt.Sprintf("pod-%d", i),
+					Uid:            fmt.Sprintf("uid-%d", i),
+					NodeName:       fmt.Sprintf("node%d", i%10),
+					ServiceAccount: fmt.Sprintf("sa%d", i%5),
+				})
+			}
+			return nil
+		},
+	}
+}
+
+// TestBuildIndexCompact 索引只保留每个SA的一个Pod和受控节点上的Pod，不随Pod数量增长
+func TestBuildIndexCompact(t *testing.T) {
+	for _, forbidden := range []bool{false, true} {
+		t.Run(fmt.Sprintf("逐个命名空间列出=%t", forbidden), func(t *testing.T) {
+			coverage := &Coverage{}
+			index, err := buildIndex(coverage, fixtureSource(10000, forbidden), "node3", 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(index.saPods) != 5 {
+				t.Errorf("保留了 %d 个SA的Pod, 期望 5 个", len(index.saPods))
+			}
+			if len(index.nodePods) != 1000 {
+				t.Errorf("保留了 %d 个受控节点上的Pod, 期望 1000 个", len(index.nodePods))
+			}
+			if len(index.ClusterRoleBindings) != 1 || len(index.RoleBindings) != 1 {
+				t.Errorf("应只保留主体为SA的绑定: %+v %+v", index.ClusterRoleBindings, index.RoleBindings)
+			}
+			if _, exists := index.PodByUID("uid-13"); !exists {
+				t.Error("受控节点上的Pod应能按UID查找")
+			}
+			if _, exists := index.PodByUID("uid-14"); exists {
+				t.Error("其他节点上的Pod不应保留")
+			}
+
+			sas := index.SaBinding(coverage)
+			index.MarkMounted(sas)
+			for _, name := range []string{"app/sa0", "app/sa1"} {
+				sa := sas[name]
+				if sa == nil || !sa.IsMounted || sa.SAPod.ServiceAccount != name[len("app/"):] {
+					t.Errorf("%s 没有标记为被挂载: %+v", name, sa)
+				}
+			}
+			if gaps := coverage.Gaps(); len(gaps) != 0 {
+				t.Errorf("不应有覆盖缺口: %+v", gaps)
+			}
+		})
+	}
+}
+
+// TestMarkMountedMatchesList 索引标记的Pod与使用完整Pod列表调用 MarkMounted 的结果一致
+func TestMarkMountedMatchesList(t *testing.T) {
+	source := fixtureSource(100, false)
+	index, err := buildIndex(nil, source, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pods := []models.Pod{}
+	source.pods("", nil, func(pod models.Pod) { pods = append(pods, pod) })
+
+	fromIndex, fromList := index.SaBinding(nil), index.SaBinding(nil)
+	index.MarkMounted(fromIndex)
+	MarkMounted(fromList, pods)
+	for name, sa := range fromList {
+		if got := fromIndex[name]; got.IsMounted != sa.IsMounted || got.SAPod.Name != sa.SAPod.Name {
+			t.Errorf("%s: 索引 %+v, 列表 %+v", name, got.SAPod, sa.SAPod)
+		}
+	}
+}
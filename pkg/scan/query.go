@@ -0,0 +1,102 @@
+package scan
+
+import (
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/scan/utils"
+	"sort"
+	"strings"
+)
+
+// Grant SA通过某个角色获得的一条资源权限
+type Grant struct {
+	SA           string   // ServiceAccount完整名称(格式:namespace/name)
+	Role         string   // 授予权限的角色(Role为 namespace/name，ClusterRole为 name)
+	Resource     string   // 资源类型(如pods、pods/exec，* 表示全部)
+	ResourceName string   // 限定的资源名称，为空表示不限
+	Namespace    string   // 生效的命名空间，为空表示集群级别
+	Verbs        []string // 允许的操作
+}
+
+// WhoCan 查询哪些SA可以对资源执行指定操作
+// 参数:
+//   - sas: GetSaBinding/GetSA 得到的SA
+//   - verb: 操作(如get、create)
+//   - resource: 资源类型(如secrets、pods/exec)
+//   - namespace: 命名空间，为空表示查询任意命名空间
+//
+// 返回:
+//   - []Grant: 满足条件的授权，按SA名称排序
+func WhoCan(sas map[string]*models.SA, verb string, resource string, namespace string) []Grant {
+	result := []Grant{}
+	for _, sa := range sas {
+		for _, grant := range WhatCan(sa) {
+			if !utils.Contains(grant.Verbs, verb) && !utils.Contains(grant.Verbs, "*") {
+				continue
+			}
+			if grant.Resource != resource && grant.Resource != "*" {
+				continue
+			}
+			if namespace != "" && grant.Namespace != "" && grant.Namespace != namespace {
+				continue
+			}
+			result = append(result, grant)
+		}
+	}
+	sortGrants(result)
+	return result
+}
+
+// WhatCan 列出SA通过各个角色获得的全部权限
+func WhatCan(sa *models.SA) []Grant {
+	result := []Grant{}
+	for role, permissions := range sa.Roles {
+		for key, verbs := range permissions {
+			resource, resourceName, namespace := ParsePermission(key)
+			result = append(result, Grant{
+				SA:           sa.Name,
+				Role:         role,
+				Resource:     resource,
+				ResourceName: resourceName,
+				Namespace:    namespace,
+				Verbs:        verbs,
+			})
+		}
+	}
+	sortGrants(result)
+	return result
+}
+
+// ParsePermission 解析权限映射中的资源键
+// 例如 "secrets(token)[default]" -> "secrets", "token", "default"
+func ParsePermission(key string) (resource string, resourceName string, namespace string) {
+	resource = key
+	if i := strings.Index(resource, "["); i != -1 && strings.HasSuffix(resource, "]") {
+		namespace = resource[i+1 : len(resource)-1]
+		resource = resource[:i]
+	}
+	if i := strings.Index(resource, "("); i != -1 && strings.HasSuffix(resource, ")") {
+		resourceName = resource[i+1 : len(resource)-1]
+		resource = resource[:i]
+	}
+	return resource, resourceName, namespace
+}
+
+// sortGrants 按SA、角色、资源排序，保证输出稳定
+func sortGrants(grants []Grant) {
+	sort.Slice(grants, func(i, j int) bool {
+		a, b := grants[i], grants[j]
+		if a.SA != b.SA {
+			return a.SA < b.SA
+		}
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.ResourceName != b.ResourceName {
+			return a.ResourceName < b.ResourceName
+		}
+		return a.Namespace < b.Namespace
+	})
+}
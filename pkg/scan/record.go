@@ -0,0 +1,47 @@
+package scan
+
+import (
+	"k8sEPDS/models"
+	"time"
+)
+
+// NewScanRecord 根据扫描结果生成扫描记录
+// 参数:
+//   - criticalSAs: GetCriticalSA 的扫描结果
+//   - coverage: 扫描时无法读取的资源，受影响的发现项标记为可能不完整
+//   - cluster: 被扫描集群(API服务器地址)
+//   - node: 扫描时的受控节点名称
+//
+// 返回:
+//   - models.ScanRecord: 以当前时间为标识的扫描记录，包含展开后的发现项和风险分
+func NewScanRecord(criticalSAs []models.CriticalSA, coverage []models.CoverageGap, cluster string, node string) models.ScanRecord {
+	now := time.Now()
+	record := models.ScanRecord{
+		ID:          now.Format("20060102-150405"),
+		Time:        now,
+		Cluster:     cluster,
+		Node:        node,
+		CriticalSAs: criticalSAs,
+		Findings:    Findings(criticalSAs),
+		Coverage:    coverage,
+	}
+	MarkIncomplete(record.Findings, coverage)
+	for i := range record.Findings {
+		record.Findings[i].Cluster = cluster
+		record.Score += record.Findings[i].Score
+	}
+	return record
+}
+
+// RestoreScanRecord 根据旧版本保存的扫描记录(只有关键SA)重新展开发现项和风险分，保留原有的标识和扫描时间
+// 参数:
+//   - record: 旧版本的扫描记录
+//   - cluster: 记录中没有集群时使用的集群(API服务器地址)
+func RestoreScanRecord(record models.ScanRecord, cluster string) models.ScanRecord {
+	if record.Cluster != "" {
+		cluster = record.Cluster
+	}
+	restored := NewScanRecord(record.CriticalSAs, record.Coverage, cluster, record.Node)
+	restored.ID, restored.Time = record.ID, record.Time
+	return restored
+}
@@ -1,9 +1,11 @@
 package scan
 
 import (
+	"context"
 	"fmt"
 	"k8sEPDS/models"
 	"k8sEPDS/pkg/scan/utils"
+	"k8sEPDS/pkg/tokensource"
 	"strings"
 )
 
@@ -14,14 +16,19 @@ func GetSA(sas map[string]*models.SA) map[string]*models.SA {
 	if err != nil {
 		fmt.Println("[Get pods] failed: ", err.Error())
 	}
+	MarkMounted(result, pods)
+	return result
+}
+
+// MarkMounted 根据Pod列表标记被挂载的ServiceAccount
+func MarkMounted(sas map[string]*models.SA, pods []models.Pod) {
 	for _, pod := range pods {
 		key := pod.Namespace + "/" + pod.ServiceAccount
-		if sa, exists := result[key]; exists {
+		if sa, exists := sas[key]; exists {
 			sa.IsMounted = true
 			sa.SAPod = pod
 		}
 	}
-	return result
 }
 // Filter high-privilege SA and mark whether the high-privilege SA is in the controlled node.
 func GetCriticalSA(SAs map[string]*models.SA, ControledNode string) []models.CriticalSA {
@@ -278,14 +285,43 @@ func GetCriticalSA(SAs map[string]*models.SA, ControledNode string) []models.Cri
 	return result
 }
 
+// ScanCluster 扫描当前配置的集群，返回全部SA、其中的关键SA和无法读取的资源
+// 部分资源无法读取时继续使用能够读取的数据扫描，受影响的发现项在扫描记录中标记为可能不完整
+// ClusterRoleBinding和RoleBinding都完全无法读取时返回错误，此时没有可用的扫描结果
+func ScanCluster(node string) (map[string]*models.SA, []models.CriticalSA, []models.CoverageGap, error) {
+	coverage := &Coverage{}
+	index, err := BuildIndex(coverage, node)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sas := index.SaBinding(coverage)
+	index.MarkMounted(sas)
+	return sas, GetCriticalSA(sas, node), coverage.Gaps(), nil
+}
+
 // Get SAs (all, whether mounted in the Pod or not)
-func GetSaBinding() map[string]*models.SA {
+// 无法读取的绑定和角色记录到 coverage 中，coverage 为 nil 时忽略；绑定完全无法读取时返回错误
+func GetSaBinding(coverage *Coverage) (map[string]*models.SA, error) {
+	index, err := BuildIndex(coverage, "")
+	if err != nil {
+		return nil, err
+	}
+	return index.SaBinding(coverage), nil
+}
+
+// BuildSaBinding 根据绑定关系和角色规则构建SA权限模型
+// 参数:
+//   - clusterrolebindingList: ClusterRoleBinding列表
+//   - rolebindingList: RoleBinding列表
+//   - getRules: 根据角色名称(格式: namespace/name 或 name)获取角色规则
+//
+// 返回:
+//   - map[string]*models.SA: 以 namespace/name 为键的SA
+func BuildSaBinding(clusterrolebindingList []models.RoleBinding, rolebindingList []models.RoleBinding, getRules func(role string) []models.Rule) map[string]*models.SA {
 	var SaBindingMap = map[string]map[string][]string{}
 	result := make(map[string]*models.SA)
-	clusterrolebindingList,_ := utils.GetClusterRoleBindings()
-	rolebindingList,_ := utils.GetRolesBindings()
 	for _, clusterrolebinding := range clusterrolebindingList {
-		rules,_ := utils.GetRulesFromRole(clusterrolebinding.RoleRef)
+		rules := getRules(clusterrolebinding.RoleRef)
 		for _, sa := range clusterrolebinding.Subject {
 			if _, ok := SaBindingMap[sa]; !ok {
 				SaBindingMap[sa] = make(map[string][]string)
@@ -295,9 +331,15 @@ func GetSaBinding() map[string]*models.SA {
 					Name:         sa,
 					RoleBindings: []string{},
 					Roles:        map[string]map[string][]string{},
+					Grants:       map[string]models.Grant{},
 				}
 			}
 			result[sa].RoleBindings = append(result[sa].RoleBindings, clusterrolebinding.Name)
+			grant, ok := result[sa].Grants[clusterrolebinding.Name]
+			if !ok {
+				grant = models.Grant{Role: clusterrolebinding.RoleRef, Permission: map[string][]string{}}
+				result[sa].Grants[clusterrolebinding.Name] = grant
+			}
 			for _, rule := range rules {
 				for _, res := range rule.Resourcs {
 					if _, ok := result[sa].Roles[clusterrolebinding.RoleRef]; !ok {
@@ -305,6 +347,7 @@ func GetSaBinding() map[string]*models.SA {
 					}
 					for _, verb := range rule.Verbs {
 						result[sa].Roles[clusterrolebinding.RoleRef][res] = append(result[sa].Roles[clusterrolebinding.RoleRef][res], verb)
+						grant.Permission[res] = append(grant.Permission[res], verb)
 						SaBindingMap[sa][res] = append(SaBindingMap[sa][res], verb)
 					}
 				}
@@ -314,7 +357,7 @@ func GetSaBinding() map[string]*models.SA {
 	}
 
 	for _, rolebinding := range rolebindingList {
-		rules,_ := utils.GetRulesFromRole(rolebinding.RoleRef)
+		rules := getRules(rolebinding.RoleRef)
 		for _, sa := range rolebinding.Subject {
 			if _, ok := SaBindingMap[sa];!ok{
 				SaBindingMap[sa] = make(map[string][]string)
@@ -324,9 +367,15 @@ func GetSaBinding() map[string]*models.SA {
 					Name:         sa,
 					RoleBindings: []string{},
 					Roles:        map[string]map[string][]string{},
+					Grants:       map[string]models.Grant{},
 				}
 			}
 			result[sa].RoleBindings = append(result[sa].RoleBindings, rolebinding.Name)
+			grant, ok := result[sa].Grants[rolebinding.Name]
+			if !ok {
+				grant = models.Grant{Role: rolebinding.RoleRef, Permission: map[string][]string{}}
+				result[sa].Grants[rolebinding.Name] = grant
+			}
 			for _, rule := range rules {
 				for _, res := range rule.Resourcs {
 					res = res + "[" + rolebinding.Namespace + "]" // Pod(pod1)[default]
@@ -335,6 +384,7 @@ func GetSaBinding() map[string]*models.SA {
 					}
 					for _, verb := range rule.Verbs {
 						result[sa].Roles[rolebinding.RoleRef][res] = append(result[sa].Roles[rolebinding.RoleRef][res], verb)
+						grant.Permission[res] = append(grant.Permission[res], verb)
 						SaBindingMap[sa][res] = append(SaBindingMap[sa][res], verb) //+"["+rolebinding.Namespace+"]"
 					}
 				}
@@ -348,12 +398,15 @@ func GetSaBinding() map[string]*models.SA {
 //ClusterRole1: res
 
 // Get the token of the specified SA in the controlled node.
-func GetCriticalSAToken(sa models.CriticalSA, ssh models.SSHConfig) (string, error) { //  /var/lib/kubelet/pods
-	filePath := "/var/lib/kubelet/pods/" + sa.SA0.SAPod.Uid + "/volumes/kubernetes.io*/*/token"
-	token, err := utils.ReadRemoteFile(ssh, filePath)
+// 按受控节点配置的Token读取方式(ssh/local/exec/cri)读取SA所用Pod挂载的Token
+func GetCriticalSAToken(sa models.CriticalSA, ssh models.SSHConfig) (string, error) {
+	source, err := tokensource.For(ssh)
 	if err != nil {
 		return "", err
-	} else {
-		return token, nil
 	}
+	token, err := source.Token(context.Background(), sa.SA0.SAPod)
+	if err != nil {
+		return "", fmt.Errorf("通过%s读取Token失败: %w", source.Name(), err)
+	}
+	return token, nil
 }
@@ -3,7 +3,6 @@ package utils
 import (
 	"fmt"
 	"k8sEPDS/pkg/request"
-	"k8sEPDS/models"
 	"strings"
 
 	"github.com/tidwall/gjson"
@@ -33,22 +32,3 @@ func CheckSaTokenMounted(saName string) bool {
 	}
 	return true
 }
-
-func CheckPatch(criticalSA *models.CriticalSA, ControledNode string) {
-	opts := request.K8sRequestOption{
-		Api:    "/api/v1/pods",
-		Method: "GET",
-	}
-	resp, _ := request.ApiRequest(opts)
-	pods := gjson.Get(resp, "items").Array()
-	for _, pod := range pods {
-		if pod.Get("metadata.name").String() == criticalSA.SA0.SAPod.Name {
-			criticalSA.SA0.SAPod.NodeName = pod.Get("spec.nodeName").String()
-		}
-	}
-
-	if ControledNode == criticalSA.SA0.SAPod.NodeName {
-		criticalSA.InNode = true
-	}
-
-}
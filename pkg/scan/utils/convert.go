@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"fmt"
+	apis "k8sEPDS/models"
+
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+)
+
+// ConvertRoleBinding 将RoleBinding对象转换为内部模型
+// 引用Role时RoleRef格式为 namespace/name，引用ClusterRole时为 name，与 GetRulesFromRole 的参数格式一致
+func ConvertRoleBinding(binding *rbacV1.RoleBinding) apis.RoleBinding {
+	roleRef := binding.RoleRef.Name
+	if binding.RoleRef.Kind == "Role" {
+		roleRef = binding.Namespace + "/" + binding.RoleRef.Name
+	}
+	return apis.RoleBinding{
+		Namespace: binding.Namespace,
+		Name:      binding.Name,
+		RoleRef:   roleRef,
+		Subject:   convertSubjects(binding.Subjects),
+	}
+}
+
+// ConvertClusterRoleBinding 将ClusterRoleBinding对象转换为内部模型
+func ConvertClusterRoleBinding(binding *rbacV1.ClusterRoleBinding) apis.RoleBinding {
+	return apis.RoleBinding{
+		Name:    binding.Name,
+		RoleRef: binding.RoleRef.Name,
+		Subject: convertSubjects(binding.Subjects),
+	}
+}
+
+// convertSubjects 提取绑定主体中的ServiceAccount(格式: namespace/name)
+func convertSubjects(subjects []rbacV1.Subject) []string {
+	var result []string
+	for _, subject := range subjects {
+		if subject.Kind == "ServiceAccount" {
+			result = append(result, fmt.Sprintf("%s/%s", subject.Namespace, subject.Name))
+		}
+	}
+	return result
+}
+
+// ConvertRules 将PolicyRule转换为内部规则模型，格式与 parseRules 一致
+func ConvertRules(rules []rbacV1.PolicyRule) []apis.Rule {
+	ruleList := make([]apis.Rule, 0, len(rules))
+	for _, rule := range rules {
+		newRule := apis.Rule{
+			Resourcs: make([]string, 0),
+			Verbs:    append(make([]string, 0, len(rule.Verbs)), rule.Verbs...),
+		}
+		for _, res := range rule.Resources {
+			if len(rule.ResourceNames) != 0 {
+				for _, resName := range rule.ResourceNames {
+					newRule.Resourcs = append(newRule.Resourcs, fmt.Sprintf("%s(%s)", res, resName))
+				}
+			} else {
+				newRule.Resourcs = append(newRule.Resourcs, res)
+			}
+		}
+		ruleList = append(ruleList, newRule)
+	}
+	return ruleList
+}
+
+// ConvertPod 将Pod对象转换为内部模型，与 parseKubePod 的解析结果一致
+// 参数:
+//   - pod: Pod对象
+//   - saAutomount: Pod未设置 automountServiceAccountToken 时所用SA的设置，nil 表示默认挂载
+func ConvertPod(pod *coreV1.Pod, saAutomount *bool) apis.Pod {
+	newPod := apis.Pod{
+		Namespace:      pod.Namespace,
+		Name:           pod.Name,
+		Uid:            string(pod.UID),
+		NodeName:       pod.Spec.NodeName,
+		ServiceAccount: pod.Spec.ServiceAccountName,
+		TokenMounted:   true,
+	}
+	if pod.Spec.AutomountServiceAccountToken != nil {
+		newPod.TokenMounted = *pod.Spec.AutomountServiceAccountToken
+	} else if saAutomount != nil {
+		newPod.TokenMounted = *saAutomount
+	}
+	if len(pod.OwnerReferences) != 0 {
+		newPod.ControllBy = make([]string, 0)
+		for _, owner := range pod.OwnerReferences {
+			newPod.ControllBy = append(newPod.ControllBy, owner.Kind)
+		}
+	}
+	return newPod
+}
+
+// ConvertRole 将Role对象转换为内部模型
+func ConvertRole(role *rbacV1.Role) apis.Role {
+	return apis.Role{Namespace: role.Namespace, Name: role.Name, Rules: ConvertRules(role.Rules)}
+}
+
+// ConvertClusterRole 将ClusterRole对象转换为内部模型
+func ConvertClusterRole(role *rbacV1.ClusterRole) apis.Role {
+	return apis.Role{Name: role.Name, Rules: ConvertRules(role.Rules)}
+}
+
+// ConvertServiceAccount 将ServiceAccount对象转换为内部模型
+func ConvertServiceAccount(sa *coreV1.ServiceAccount) apis.ServiceAccount {
+	return apis.ServiceAccount{Namespace: sa.Namespace, Name: sa.Name, AutomountToken: sa.AutomountServiceAccountToken}
+}
@@ -5,36 +5,67 @@ import (
 	apis "k8sEPDS/models"
 	"k8sEPDS/pkg/request"
 	"strings"
+	"time"
 
 	"github.com/tidwall/gjson"
 )
 
-// k8sRequest 封装Kubernetes API请求
-func k8sRequest(api string) ([]gjson.Result, error) {
-    opts := request.K8sRequestOption{
-        Api:    api,
-        Method: "GET",
-    }
-    resp, err := request.ApiRequest(opts)
-    if err != nil {
-        return nil, fmt.Errorf("API请求失败: %w", err)
-    }
-    return gjson.Get(resp, "items").Array(), nil
+// pageTimeout 分页列出资源时每一页的超时时间
+const pageTimeout = time.Minute
+
+// k8sList 分页列出资源，逐项转换为内部模型，返回全部资源
+func k8sList[T any](api string, parse func(item gjson.Result) T) ([]T, error) {
+	result := []T{}
+	err := k8sEach(api, parse, func(item T) {
+		result = append(result, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// k8sEach 分页列出资源，逐项转换为内部模型后交给 each，不保留原始响应和已处理的项
+// 原始响应每次只保留一页，内存占用由 each 保留的内容决定，与资源数量无关
+func k8sEach[T any](api string, parse func(item gjson.Result) T, each func(item T)) error {
+	opts := request.K8sRequestOption{
+		Api:     api,
+		Method:  "GET",
+		Timeout: pageTimeout,
+	}
+	err := request.List(opts, func(item gjson.Result) error {
+		each(parse(item))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("API请求失败: %w", err)
+	}
+	return nil
+}
+
+// text 复制字段的字符串值，解析结果不引用原始列表项，避免保留 managedFields 等无关内容占用的内存
+func text(value gjson.Result) string {
+	return strings.Clone(value.String())
 }
 
 // parseKubePod 解析Pod数据
-func parseKubePod(pod gjson.Result) apis.Pod {
+// 参数:
+//   - pod: Pod数据
+//   - saAutomount: Pod未设置 automountServiceAccountToken 时所用SA的设置，nil 表示默认挂载
+func parseKubePod(pod gjson.Result, saAutomount *bool) apis.Pod {
     newPod := apis.Pod{
-        Namespace:      pod.Get("metadata.namespace").String(),
-        Name:           pod.Get("metadata.name").String(),
-        Uid:            pod.Get("metadata.uid").String(),
-        NodeName:       pod.Get("spec.nodeName").String(),
-        ServiceAccount: pod.Get("spec.serviceAccountName").String(),
+        Namespace:      text(pod.Get("metadata.namespace")),
+        Name:           text(pod.Get("metadata.name")),
+        Uid:            text(pod.Get("metadata.uid")),
+        NodeName:       text(pod.Get("spec.nodeName")),
+        ServiceAccount: text(pod.Get("spec.serviceAccountName")),
     }
 
     // 设置Token挂载状态
     if tokenMounted := pod.Get("spec.automountServiceAccountToken"); tokenMounted.Exists() {
         newPod.TokenMounted = tokenMounted.Bool()
+    } else if saAutomount != nil {
+        newPod.TokenMounted = *saAutomount
     } else {
         newPod.TokenMounted = true
     }
@@ -43,7 +74,7 @@ func parseKubePod(pod gjson.Result) apis.Pod {
     if owners := pod.Get("metadata.ownerReferences"); owners.Exists() {
         newPod.ControllBy = make([]string, 0)
         for _, owner := range owners.Array() {
-            newPod.ControllBy = append(newPod.ControllBy, owner.Get("kind").String())
+            newPod.ControllBy = append(newPod.ControllBy, text(owner.Get("kind")))
         }
     }
 
@@ -56,26 +87,98 @@ func parseKubePod(pod gjson.Result) apis.Pod {
 //   - []apis.Pod: Pod列表
 //   - error: 错误信息
 func GetPods() ([]apis.Pod, error) {
-	pods, err := k8sRequest("/api/v1/pods")
-    if err != nil {
-        return nil, err
-    }
+	return getPods("/api/v1/pods", nil)
+}
+
+// GetPodsIn 获取指定命名空间中的Pod，没有列出全部Pod的权限时使用
+func GetPodsIn(namespace string) ([]apis.Pod, error) {
+	return getPods("/api/v1/namespaces/"+namespace+"/pods", nil)
+}
+
+// ListPods 逐个读取命名空间中的Pod，命名空间为空时读取全部Pod
+// 参数:
+//   - namespace: 命名空间
+//   - saAutomount: 根据命名空间和SA名称获取SA的 automountServiceAccountToken 设置
+//   - each: 处理一个Pod
+func ListPods(namespace string, saAutomount func(namespace string, name string) *bool, each func(pod apis.Pod)) error {
+	return k8sEach(namespacedAPI("/api/v1", namespace, "pods"), podParser(saAutomount), each)
+}
+
+// GetNamespaces 获取所有命名空间的名称
+func GetNamespaces() ([]string, error) {
+	return k8sList("/api/v1/namespaces", func(namespace gjson.Result) string {
+		return text(namespace.Get("metadata.name"))
+	})
+}
+
+// getPods 获取Pod列表，未设置 automountServiceAccountToken 的Pod视为挂载
+func getPods(api string, saAutomount func(namespace string, name string) *bool) ([]apis.Pod, error) {
+	return k8sList(api, podParser(saAutomount))
+}
 
-    podList := make([]apis.Pod, 0, len(pods))
+// podParser 解析Pod，saAutomount 为 nil 时未设置 automountServiceAccountToken 的Pod视为挂载
+func podParser(saAutomount func(namespace string, name string) *bool) func(pod gjson.Result) apis.Pod {
+	return func(pod gjson.Result) apis.Pod {
+		var automount *bool
+		if saAutomount != nil {
+			automount = saAutomount(pod.Get("metadata.namespace").String(), pod.Get("spec.serviceAccountName").String())
+		}
+		return parseKubePod(pod, automount)
+	}
+}
+
+// ListServiceAccounts 逐个读取命名空间中的ServiceAccount，命名空间为空时读取全部
+func ListServiceAccounts(namespace string, each func(sa apis.ServiceAccount)) error {
+	return k8sEach(namespacedAPI("/api/v1", namespace, "serviceaccounts"), func(item gjson.Result) apis.ServiceAccount {
+		serviceAccount := apis.ServiceAccount{
+			Namespace: text(item.Get("metadata.namespace")),
+			Name:      text(item.Get("metadata.name")),
+		}
+		if automount := item.Get("automountServiceAccountToken"); automount.Exists() {
+			value := automount.Bool()
+			serviceAccount.AutomountToken = &value
+		}
+		return serviceAccount
+	}, each)
+}
+
+// ListClusterRoles 逐个读取ClusterRole及其规则
+func ListClusterRoles(each func(role apis.Role)) error {
+	return k8sEach("/apis/rbac.authorization.k8s.io/v1/clusterroles", parseRole, each)
+}
+
+// ListRoles 逐个读取命名空间中的Role及其规则，命名空间为空时读取全部
+func ListRoles(namespace string, each func(role apis.Role)) error {
+	return k8sEach(namespacedAPI("/apis/rbac.authorization.k8s.io/v1", namespace, "roles"), parseRole, each)
+}
+
+// parseRole 解析Role或ClusterRole数据
+func parseRole(item gjson.Result) apis.Role {
+	return apis.Role{
+		Namespace: text(item.Get("metadata.namespace")),
+		Name:      text(item.Get("metadata.name")),
+		Rules:     parseRules(item.Get("rules").Array()),
+	}
+}
 
-	for _, pod := range pods {
-		newPod := parseKubePod(pod)
-		podList = append(podList, newPod)
+// namespacedAPI 构建命名空间资源的API路径，命名空间为空时为全部命名空间的路径
+func namespacedAPI(group string, namespace string, resource string) string {
+	if namespace == "" {
+		return group + "/" + resource
 	}
-	return podList, nil
+	return group + "/namespaces/" + namespace + "/" + resource
 }
 
 // parseRoleBinding 解析RoleBinding数据
 func parseRoleBinding(binding gjson.Result, namespace string) apis.RoleBinding {
     newBinding := apis.RoleBinding{
         Namespace: namespace,
-        Name:      binding.Get("metadata.name").String(),
-        RoleRef:   binding.Get("roleRef.name").String(),
+        Name:      text(binding.Get("metadata.name")),
+        RoleRef:   text(binding.Get("roleRef.name")),
+    }
+    // 引用Role时RoleRef格式为 namespace/name，与 ConvertRoleBinding 一致
+    if binding.Get("roleRef.kind").String() == "Role" {
+        newBinding.RoleRef = namespace + "/" + newBinding.RoleRef
     }
 
     if subjects := binding.Get("subjects"); subjects.Exists() {
@@ -95,31 +198,37 @@ func parseRoleBinding(binding gjson.Result, namespace string) apis.RoleBinding {
 // 返回:
 //   - []apis.RoleBinding: ClusterRoleBinding列表
 func GetClusterRoleBindings() ([]apis.RoleBinding,error) {
-	bindings, err := k8sRequest("/apis/rbac.authorization.k8s.io/v1/clusterrolebindings")
-    if err != nil {
-        return nil, err
-    }
-
-	bindingList := make([]apis.RoleBinding, 0, len(bindings))
-    for _, binding := range bindings {
-        newBinding := parseRoleBinding(binding, binding.Get("metadata.namespace").String())
-        bindingList = append(bindingList, newBinding)
-    }
-    return bindingList, nil
+	return getRoleBindings("/apis/rbac.authorization.k8s.io/v1/clusterrolebindings")
 }
 
+// GetRolesBindings 获取所有命名空间中的RoleBinding
 func GetRolesBindings() ([]apis.RoleBinding, error) {
-	bindings, err := k8sRequest("/apis/rbac.authorization.k8s.io/v1/rolebindings")
-    if err != nil {
-        return nil, err
-    }
+	return getRoleBindings("/apis/rbac.authorization.k8s.io/v1/rolebindings")
+}
 
-    bindingList := make([]apis.RoleBinding, 0, len(bindings))
-    for _, binding := range bindings {
-        newBinding := parseRoleBinding(binding, binding.Get("metadata.namespace").String())
-        bindingList = append(bindingList, newBinding)
-    }
-    return bindingList, nil
+// GetRolesBindingsIn 获取指定命名空间中的RoleBinding，没有列出全部RoleBinding的权限时使用
+func GetRolesBindingsIn(namespace string) ([]apis.RoleBinding, error) {
+	return getRoleBindings("/apis/rbac.authorization.k8s.io/v1/namespaces/" + namespace + "/rolebindings")
+}
+
+// ListClusterRoleBindings 逐个读取ClusterRoleBinding
+func ListClusterRoleBindings(each func(binding apis.RoleBinding)) error {
+	return k8sEach("/apis/rbac.authorization.k8s.io/v1/clusterrolebindings", parseAnyRoleBinding, each)
+}
+
+// ListRoleBindings 逐个读取命名空间中的RoleBinding，命名空间为空时读取全部
+func ListRoleBindings(namespace string, each func(binding apis.RoleBinding)) error {
+	return k8sEach(namespacedAPI("/apis/rbac.authorization.k8s.io/v1", namespace, "rolebindings"), parseAnyRoleBinding, each)
+}
+
+// getRoleBindings 获取RoleBinding列表
+func getRoleBindings(api string) ([]apis.RoleBinding, error) {
+	return k8sList(api, parseAnyRoleBinding)
+}
+
+// parseAnyRoleBinding 解析RoleBinding或ClusterRoleBinding数据，命名空间取自对象本身
+func parseAnyRoleBinding(binding gjson.Result) apis.RoleBinding {
+	return parseRoleBinding(binding, text(binding.Get("metadata.namespace")))
 }
 
 // GetRulesFromRole 获取Role的规则
@@ -172,13 +281,13 @@ func parseRules(rules []gjson.Result) []apis.Rule {
                     newRule.Resourcs = append(newRule.Resourcs, resource)
                 }
             } else {
-                newRule.Resourcs = append(newRule.Resourcs, res.String())
+                newRule.Resourcs = append(newRule.Resourcs, text(res))
             }
         }
 
         // 解析动作
         for _, verb := range rule.Get("verbs").Array() {
-            newRule.Verbs = append(newRule.Verbs, verb.String())
+            newRule.Verbs = append(newRule.Verbs, text(verb))
         }
 
         ruleList = append(ruleList, newRule)
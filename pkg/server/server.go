@@ -0,0 +1,224 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/history"
+	"k8sEPDS/pkg/scan"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Options API服务配置
+type Options struct {
+	Token          string // 访问令牌，请求需携带 Authorization: Bearer <token>
+	Cluster        string // 被扫描集群(API服务器地址)，写入扫描记录
+	ControlledNode string // 受控节点名称
+	HistoryPath    string // 扫描历史数据库路径，为空时不保存扫描记录
+}
+
+// Scanner 执行一次扫描，返回全部SA、其中的关键SA和无法读取的资源
+type Scanner func(node string) (map[string]*models.SA, []models.CriticalSA, []models.CoverageGap, error)
+
+// Server 扫描结果的HTTP JSON API
+type Server struct {
+	opts    Options
+	scanner Scanner
+	scanMu  sync.Mutex // 保证同一时间只有一次扫描
+	mu      sync.RWMutex
+	sas     map[string]*models.SA
+	record  *models.ScanRecord
+}
+
+// SADetail 单个SA的完整权限信息
+type SADetail struct {
+	SA       *models.SA         // SA权限模型
+	Critical *models.CriticalSA // 关键SA信息，不是关键SA时为空
+	Findings []models.Finding   // 该SA的发现项
+	Grants   []scan.Grant       // 该SA的全部授权
+}
+
+// NewServer 创建API服务
+// 参数:
+//   - opts: 服务配置
+//   - scanner: 扫描实现，为空时使用 scan.ScanCluster
+func NewServer(opts Options, scanner Scanner) *Server {
+	if scanner == nil {
+		scanner = scan.ScanCluster
+	}
+	return &Server{opts: opts, scanner: scanner}
+}
+
+// Handler 返回带令牌认证的路由
+//
+//	POST /api/v1/scans                      触发一次扫描
+//	GET  /api/v1/scans/latest               最近一次扫描记录
+//	GET  /api/v1/findings                   发现项，支持 namespace/type/severity 过滤
+//	GET  /api/v1/serviceaccounts/{ns}/{name} SA的完整权限信息
+//	GET  /api/v1/who-can                    查询可以对资源执行操作的SA(verb/resource/namespace)
+//	GET  /api/v1/what-can                   查询SA的全部授权(sa=namespace/name)
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/scans", s.handleScan)
+	mux.HandleFunc("GET /api/v1/scans/latest", s.handleLatest)
+	mux.HandleFunc("GET /api/v1/findings", s.handleFindings)
+	mux.HandleFunc("GET /api/v1/serviceaccounts/{namespace}/{name}", s.handleSA)
+	mux.HandleFunc("GET /api/v1/who-can", s.handleWhoCan)
+	mux.HandleFunc("GET /api/v1/what-can", s.handleWhatCan)
+	return s.authenticate(mux)
+}
+
+// Scan 执行一次扫描并替换当前结果，扫描失败时保留上一次的结果
+func (s *Server) Scan() (models.ScanRecord, error) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+	sas, criticalSAs, coverage, err := s.scanner(s.opts.ControlledNode)
+	if err != nil {
+		return models.ScanRecord{}, err
+	}
+	record := scan.NewScanRecord(criticalSAs, coverage, s.opts.Cluster, s.opts.ControlledNode)
+	s.mu.Lock()
+	s.sas = sas
+	s.record = &record
+	s.mu.Unlock()
+	if s.opts.HistoryPath != "" {
+		if err := saveScan(s.opts.HistoryPath, record); err != nil {
+			fmt.Println("[X] 保存扫描记录失败:", err.Error())
+		}
+	}
+	return record, nil
+}
+
+// saveScan 将扫描记录保存到扫描历史数据库
+func saveScan(path string, record models.ScanRecord) error {
+	db, err := history.Open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.SaveScan(record)
+}
+
+// authenticate 校验 Authorization: Bearer <token>
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			writeError(rw, http.StatusUnauthorized, "未授权")
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// snapshot 获取当前扫描结果，尚未扫描时返回 false
+func (s *Server) snapshot() (map[string]*models.SA, *models.ScanRecord, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sas, s.record, s.record != nil
+}
+
+func (s *Server) handleScan(rw http.ResponseWriter, r *http.Request) {
+	record, err := s.Scan()
+	if err != nil {
+		writeError(rw, http.StatusBadGateway, "扫描失败: "+err.Error())
+		return
+	}
+	writeJSON(rw, http.StatusOK, record)
+}
+
+func (s *Server) handleLatest(rw http.ResponseWriter, r *http.Request) {
+	_, record, ok := s.snapshot()
+	if !ok {
+		writeError(rw, http.StatusNotFound, "尚未执行扫描")
+		return
+	}
+	writeJSON(rw, http.StatusOK, record)
+}
+
+func (s *Server) handleFindings(rw http.ResponseWriter, r *http.Request) {
+	_, record, ok := s.snapshot()
+	if !ok {
+		writeError(rw, http.StatusNotFound, "尚未执行扫描")
+		return
+	}
+	query := r.URL.Query()
+	result := scan.FilterFindings(record.Findings, query.Get("namespace"), query.Get("type"), query.Get("severity"))
+	writeJSON(rw, http.StatusOK, result)
+}
+
+func (s *Server) handleSA(rw http.ResponseWriter, r *http.Request) {
+	sas, record, ok := s.snapshot()
+	if !ok {
+		writeError(rw, http.StatusNotFound, "尚未执行扫描")
+		return
+	}
+	name := r.PathValue("namespace") + "/" + r.PathValue("name")
+	sa, exists := sas[name]
+	if !exists {
+		writeError(rw, http.StatusNotFound, "ServiceAccount不存在或没有任何绑定: "+name)
+		return
+	}
+	detail := SADetail{SA: sa, Findings: []models.Finding{}, Grants: scan.WhatCan(sa)}
+	for i := range record.CriticalSAs {
+		if record.CriticalSAs[i].SA0.Name == name {
+			detail.Critical = &record.CriticalSAs[i]
+			break
+		}
+	}
+	for _, finding := range record.Findings {
+		if finding.SA == name {
+			detail.Findings = append(detail.Findings, finding)
+		}
+	}
+	writeJSON(rw, http.StatusOK, detail)
+}
+
+func (s *Server) handleWhoCan(rw http.ResponseWriter, r *http.Request) {
+	sas, _, ok := s.snapshot()
+	if !ok {
+		writeError(rw, http.StatusNotFound, "尚未执行扫描")
+		return
+	}
+	query := r.URL.Query()
+	verb, resource := query.Get("verb"), query.Get("resource")
+	if verb == "" || resource == "" {
+		writeError(rw, http.StatusBadRequest, "缺少参数 verb 或 resource")
+		return
+	}
+	writeJSON(rw, http.StatusOK, scan.WhoCan(sas, verb, resource, query.Get("namespace")))
+}
+
+func (s *Server) handleWhatCan(rw http.ResponseWriter, r *http.Request) {
+	sas, _, ok := s.snapshot()
+	if !ok {
+		writeError(rw, http.StatusNotFound, "尚未执行扫描")
+		return
+	}
+	name := r.URL.Query().Get("sa")
+	if name == "" {
+		writeError(rw, http.StatusBadRequest, "缺少参数 sa(格式:namespace/name)")
+		return
+	}
+	sa, exists := sas[name]
+	if !exists {
+		writeError(rw, http.StatusNotFound, "ServiceAccount不存在或没有任何绑定: "+name)
+		return
+	}
+	writeJSON(rw, http.StatusOK, scan.WhatCan(sa))
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(rw http.ResponseWriter, status int, body any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(body)
+}
+
+// writeError 输出JSON格式的错误信息
+func writeError(rw http.ResponseWriter, status int, message string) {
+	writeJSON(rw, status, map[string]string{"error": message})
+}
@@ -0,0 +1,119 @@
+package sshclient
+
+import (
+	"errors"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/vault"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/term"
+)
+
+var (
+	signersMu sync.Mutex
+	signers   = map[string]ssh.Signer{} // 私钥文件 -> 解析后的私钥，加密的私钥每次运行只需输入一次口令
+)
+
+// authMethods 按 ssh-agent、私钥、密码的顺序组合认证方式，服务器依次尝试
+// 返回:
+//   - []ssh.AuthMethod: 认证方式
+//   - func(): 释放ssh-agent连接，连接建立后调用
+//   - error: 没有可用的认证方式或读取凭据失败时返回错误
+func authMethods(config models.SSHConfig) ([]ssh.AuthMethod, func(), error) {
+	methods := []ssh.AuthMethod{}
+	release := func() {}
+	if config.Agent {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, nil, fmt.Errorf("已启用ssh-agent认证，但未设置环境变量 SSH_AUTH_SOCK")
+		}
+		agentConn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, nil, fmt.Errorf("连接ssh-agent失败: %w", err)
+		}
+		release = func() { agentConn.Close() }
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers))
+	}
+	if config.PrivateKeyFile != "" {
+		signer, err := privateKey(config)
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if config.Password != "" {
+		password, err := vault.Resolve(config.Password)
+		if err != nil {
+			release()
+			return nil, nil, fmt.Errorf("读取SSH密码失败: %w", err)
+		}
+		methods = append(methods, ssh.Password(password))
+	}
+	if len(methods) == 0 {
+		return nil, nil, fmt.Errorf("未配置SSH认证方式(ssh-agent、私钥或密码)")
+	}
+	return methods, release, nil
+}
+
+// privateKey 读取并解析私钥，私钥加密时使用配置的口令，未配置时在终端输入
+func privateKey(config models.SSHConfig) (ssh.Signer, error) {
+	signersMu.Lock()
+	defer signersMu.Unlock()
+	if signer, exists := signers[config.PrivateKeyFile]; exists {
+		return signer, nil
+	}
+	file := config.PrivateKeyFile
+	if !vault.IsRef(file) {
+		var err error
+		if file, err = expandHome(file); err != nil {
+			return nil, err
+		}
+	}
+	content, err := vault.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取私钥文件失败: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(content)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase, err := keyPassphrase(config)
+		if err != nil {
+			return nil, err
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(content, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("解密私钥失败: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %w", err)
+	}
+	signers[config.PrivateKeyFile] = signer
+	return signer, nil
+}
+
+// keyPassphrase 私钥口令，依次取自配置(可以引用凭据库)和终端输入
+func keyPassphrase(config models.SSHConfig) (string, error) {
+	if config.Passphrase != "" {
+		passphrase, err := vault.Resolve(config.Passphrase)
+		if err != nil {
+			return "", fmt.Errorf("读取私钥口令失败: %w", err)
+		}
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("私钥 %s 已加密，请配置 ssh.passphrase", config.PrivateKeyFile)
+	}
+	fmt.Fprintf(os.Stderr, "[输入] 私钥 %s 的口令: ", config.PrivateKeyFile)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("读取私钥口令失败: %w", err)
+	}
+	return string(passphrase), nil
+}
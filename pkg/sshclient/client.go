@@ -0,0 +1,381 @@
+package sshclient
+
+import (
+	"fmt"
+	"io"
+	"k8sEPDS/models"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// dialTimeout 建立TCP连接和SSH握手的超时时间
+	dialTimeout = 15 * time.Second
+	// maxFileSize 读取远程文件的大小上限
+	maxFileSize = 1 << 20
+)
+
+// conn 连接池中的一个连接，经过跳板机时包含到各跳板机的连接
+// 使用中的连接以引用计数标记，连接失效或被移出连接池时由最后一个使用者关闭，refs 和 retired 由 poolMu 保护
+type conn struct {
+	key     string        // 连接池的键
+	client  *ssh.Client   // 到目标主机的连接
+	hops    []*ssh.Client // 到跳板机的连接，按连接顺序
+	setup   sync.Mutex    // 保护SFTP会话的创建
+	sftp    *sftp.Client  // 在目标连接上打开的SFTP会话，首次读取文件时创建
+	refs    int           // 正在使用该连接的调用方数量
+	retired bool          // 是否已移出连接池
+}
+
+// slot 连接池中一个键的位置
+// 同一键的连接检查和建立由 mu 串行化，不持有 poolMu，不同主机的连接互不阻塞；conn 由 poolMu 保护
+type slot struct {
+	mu   sync.Mutex
+	conn *conn
+}
+
+// close 关闭SFTP会话和全部连接
+func (c *conn) close() {
+	if c.sftp != nil {
+		c.sftp.Close()
+	}
+	c.client.Close()
+	for i := len(c.hops) - 1; i >= 0; i-- {
+		c.hops[i].Close()
+	}
+}
+
+// alive 通过keepalive请求检查连接是否可用
+func (c *conn) alive() bool {
+	_, _, err := c.client.SendRequest("keepalive@openssh.com", true, nil)
+	return err == nil
+}
+
+var (
+	poolMu sync.Mutex
+	pool   = map[string]*slot{} // 连接键 -> 连接，本次运行内复用
+)
+
+// File 读取的远程文件
+type File struct {
+	Path    string
+	Content string
+	Err     error // 读取失败的原因
+}
+
+// ReadFile 通过SFTP读取远程文件，路径不经过shell
+// 路径可以包含通配符(语法同 path.Match)，匹配多个文件时按名称排序后返回第一个可以读取的文件
+// 连接在本次运行内复用，连接失效时重新连接
+// 参数:
+//   - config: SSH连接配置
+//   - pattern: 文件路径或通配符
+func ReadFile(config models.SSHConfig, pattern string) (string, error) {
+	files, err := ReadFiles(config, pattern)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("远程文件不存在: %s", pattern)
+	}
+	var lastErr error
+	for _, file := range files {
+		if file.Err == nil {
+			return file.Content, nil
+		}
+		lastErr = file.Err
+	}
+	return "", fmt.Errorf("读取远程文件失败: %w", lastErr)
+}
+
+// ReadFiles 通过SFTP读取与通配符匹配的全部远程文件，按路径排序
+// 单个文件读取失败时记录在该文件的 Err 中，查找文件失败时返回错误
+// 参数:
+//   - config: SSH连接配置
+//   - pattern: 文件路径或通配符
+func ReadFiles(config models.SSHConfig, pattern string) ([]File, error) {
+	c, err := sftpConn(config)
+	if err != nil {
+		return nil, err
+	}
+	defer release(c)
+	paths, err := c.sftp.Glob(pattern)
+	if err != nil {
+		drop(c)
+		return nil, fmt.Errorf("查找远程文件失败: %w", err)
+	}
+	sort.Strings(paths)
+	files := make([]File, 0, len(paths))
+	for _, path := range paths {
+		content, err := readFile(c.sftp, path)
+		files = append(files, File{Path: path, Content: content, Err: err})
+	}
+	return files, nil
+}
+
+// readFile 读取单个远程文件
+func readFile(client *sftp.Client, file string) (string, error) {
+	f, err := client.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, maxFileSize))
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Dial 获取到目标主机的SSH连接，连接由连接池管理，调用方不要关闭
+// 返回:
+//   - *ssh.Client: 到目标主机的连接
+//   - func(): 使用完毕后调用，在此之前连接不会被其他调用方关闭
+//   - error: 错误信息
+func Dial(config models.SSHConfig) (*ssh.Client, func(), error) {
+	c, err := get(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.client, func() { release(c) }, nil
+}
+
+// CloseAll 关闭连接池中的全部连接，正在使用的连接在使用完毕后关闭
+func CloseAll() {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	for _, s := range pool {
+		if s.conn != nil {
+			retire(s.conn)
+		}
+	}
+}
+
+// sftpConn 获取打开了SFTP会话的连接，使用完毕后调用 release
+func sftpConn(config models.SSHConfig) (*conn, error) {
+	c, err := get(config)
+	if err != nil {
+		return nil, err
+	}
+	c.setup.Lock()
+	defer c.setup.Unlock()
+	if c.sftp == nil {
+		client, err := sftp.NewClient(c.client)
+		if err != nil {
+			release(c)
+			return nil, fmt.Errorf("打开SFTP会话失败(目标主机需要启用sftp子系统): %w", err)
+		}
+		c.sftp = client
+	}
+	return c, nil
+}
+
+// release 释放对连接的引用，连接已移出连接池且没有其他使用者时关闭连接
+func release(c *conn) {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	c.refs--
+	if c.retired && c.refs == 0 {
+		c.close()
+	}
+}
+
+// retire 将连接移出连接池，没有使用者时立即关闭，否则由最后一个使用者关闭，调用方需要持有 poolMu
+func retire(c *conn) {
+	if s, exists := pool[c.key]; exists && s.conn == c {
+		s.conn = nil
+	}
+	if c.retired {
+		return
+	}
+	c.retired = true
+	if c.refs == 0 {
+		c.close()
+	}
+}
+
+// get 从连接池获取连接并增加引用，使用完毕后调用 release
+// 不存在或已失效时重新连接，keepalive检查和建立连接时只持有该键的 slot.mu
+func get(config models.SSHConfig) (*conn, error) {
+	key := poolKey(config)
+	poolMu.Lock()
+	s, exists := pool[key]
+	if !exists {
+		s = &slot{}
+		pool[key] = s
+	}
+	poolMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	poolMu.Lock()
+	c := s.conn
+	if c != nil {
+		c.refs++
+	}
+	poolMu.Unlock()
+	if c != nil {
+		if c.alive() {
+			return c, nil
+		}
+		drop(c)
+		release(c)
+	}
+
+	c, err := dial(config)
+	if err != nil {
+		return nil, err
+	}
+	c.key, c.refs = key, 1
+	poolMu.Lock()
+	s.conn = c
+	poolMu.Unlock()
+	return c, nil
+}
+
+// drop 将出错的连接移出连接池，下次使用时重新连接
+func drop(c *conn) {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	retire(c)
+}
+
+// poolKey 连接池的键，同一用户经过同样的跳板机连接同一主机时复用连接
+func poolKey(config models.SSHConfig) string {
+	return config.Username + "@" + address(config.Host, config.Port) + " via " + config.ProxyJump
+}
+
+// dial 依次连接跳板机和目标主机，每一跳使用相同的认证方式和主机密钥校验
+func dial(config models.SSHConfig) (*conn, error) {
+	hops, err := route(config)
+	if err != nil {
+		return nil, err
+	}
+	auth, release, err := authMethods(config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	hostKey, err := hostKeyCallback(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{}
+	var client *ssh.Client
+	for i, h := range hops {
+		clientConfig := &ssh.ClientConfig{
+			User:            h.user,
+			Auth:            auth,
+			HostKeyCallback: hostKey,
+			Timeout:         dialTimeout,
+		}
+		if client == nil {
+			client, err = ssh.Dial("tcp", h.addr, clientConfig)
+		} else {
+			client, err = dialThrough(client, h.addr, clientConfig)
+		}
+		if err != nil {
+			if i < len(hops)-1 {
+				err = fmt.Errorf("连接跳板机 %s 失败: %w", h.addr, err)
+			} else {
+				err = fmt.Errorf("连接 %s 失败: %w", h.addr, err)
+			}
+			for j := len(c.hops) - 1; j >= 0; j-- {
+				c.hops[j].Close()
+			}
+			return nil, err
+		}
+		if i < len(hops)-1 {
+			c.hops = append(c.hops, client)
+		}
+	}
+	c.client = client
+	return c, nil
+}
+
+// dialThrough 通过已建立的连接转发到下一跳并完成SSH握手
+func dialThrough(via *ssh.Client, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	netConn, err := via.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, config)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// hop 连接路径上的一跳
+type hop struct {
+	user string
+	addr string // host:port
+}
+
+// route 连接路径: ProxyJump中的跳板机依次在前，目标主机在最后
+// 跳板机未指定用户和端口时使用目标主机的用户名和22端口
+func route(config models.SSHConfig) ([]hop, error) {
+	hops := []hop{}
+	for _, jump := range strings.Split(config.ProxyJump, ",") {
+		if jump = strings.TrimSpace(jump); jump == "" {
+			continue
+		}
+		user, host := config.Username, jump
+		if at := strings.LastIndex(jump, "@"); at >= 0 {
+			user, host = jump[:at], jump[at+1:]
+		}
+		port := 22
+		if h, p, found := cutPort(host); found {
+			value, err := strconv.Atoi(p)
+			if err != nil || value <= 0 || value > 65535 {
+				return nil, fmt.Errorf("跳板机端口无效: %s", jump)
+			}
+			host, port = h, value
+		}
+		if host == "" {
+			return nil, fmt.Errorf("跳板机地址无效: %s", jump)
+		}
+		hops = append(hops, hop{user: user, addr: address(host, port)})
+	}
+	if config.Host == "" {
+		return nil, fmt.Errorf("SSH 主机地址不能为空")
+	}
+	return append(hops, hop{user: config.Username, addr: address(config.Host, config.Port)}), nil
+}
+
+// cutPort 拆分 host:port 或 [host]:port，没有端口时 found 为 false
+func cutPort(hostport string) (host string, port string, found bool) {
+	if strings.HasPrefix(hostport, "[") {
+		end := strings.Index(hostport, "]")
+		if end < 0 {
+			return hostport, "", false
+		}
+		host = hostport[1:end]
+		rest := hostport[end+1:]
+		if port, found = strings.CutPrefix(rest, ":"); found {
+			return host, port, true
+		}
+		return host, "", false
+	}
+	if strings.Count(hostport, ":") != 1 {
+		return hostport, "", false
+	}
+	host, port, _ = strings.Cut(hostport, ":")
+	return host, port, true
+}
+
+// address 拼接连接地址，端口未设置时使用22
+func address(host string, port int) string {
+	if port <= 0 {
+		port = 22
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -0,0 +1,115 @@
+package sshclient
+
+import (
+	"errors"
+	"fmt"
+	"k8sEPDS/models"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// 主机密钥校验方式，与配置项 ssh.hostKeyPolicy 的取值一致
+const (
+	PolicyTOFU     = "tofu"     // 主机不在 known_hosts 中时信任并记录，密钥变化时拒绝连接
+	PolicyStrict   = "strict"   // 只允许 known_hosts 中记录的主机
+	PolicyInsecure = "insecure" // 不校验主机密钥
+)
+
+// knownHostsMu 串行写入 known_hosts，避免并发连接时重复记录或写坏文件
+var knownHostsMu sync.Mutex
+
+// hostKeyCallback 根据配置的校验方式创建主机密钥校验函数
+func hostKeyCallback(config models.SSHConfig) (ssh.HostKeyCallback, error) {
+	policy := strings.ToLower(config.HostKeyPolicy)
+	switch policy {
+	case "", PolicyTOFU, PolicyStrict:
+	case PolicyInsecure:
+		return ssh.InsecureIgnoreHostKey(), nil
+	default:
+		return nil, fmt.Errorf("不支持的主机密钥校验方式: %s", config.HostKeyPolicy)
+	}
+	file, err := knownHostsFile(config)
+	if err != nil {
+		return nil, err
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		knownHostsMu.Lock()
+		defer knownHostsMu.Unlock()
+		// 每次重新读取，同一次运行中首次信任的主机对之后的连接生效
+		check, err := knownhosts.New(file)
+		if err != nil {
+			return fmt.Errorf("读取 known_hosts 失败: %w", err)
+		}
+		err = check(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		fingerprint := ssh.FingerprintSHA256(key)
+		if len(keyErr.Want) > 0 {
+			return fmt.Errorf("主机 %s 的密钥(%s %s)与 %s 中的记录不一致，可能遭到中间人攻击，确认后请删除旧记录", hostname, key.Type(), fingerprint, file)
+		}
+		if policy == PolicyStrict {
+			return fmt.Errorf("主机 %s 不在 %s 中(%s %s)", hostname, file, key.Type(), fingerprint)
+		}
+		if err := appendKnownHost(file, hostname, key); err != nil {
+			return err
+		}
+		fmt.Printf("[!] 首次连接 %s，已信任主机密钥 %s %s 并记录到 %s\n", hostname, key.Type(), fingerprint, file)
+		return nil
+	}, nil
+}
+
+// knownHostsFile known_hosts文件路径，文件不存在时创建空文件
+func knownHostsFile(config models.SSHConfig) (string, error) {
+	file := config.KnownHosts
+	if file == "" {
+		file = filepath.Join("~", ".ssh", "known_hosts")
+	}
+	file, err := expandHome(file)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			return "", fmt.Errorf("创建 known_hosts 目录失败: %w", err)
+		}
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return "", fmt.Errorf("创建 known_hosts 失败: %w", err)
+		}
+		f.Close()
+	}
+	return file, nil
+}
+
+// appendKnownHost 将主机密钥追加到 known_hosts
+func appendKnownHost(file string, hostname string, key ssh.PublicKey) error {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("写入 known_hosts 失败: %w", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, knownhosts.Line([]string{hostname}, key)); err != nil {
+		return fmt.Errorf("写入 known_hosts 失败: %w", err)
+	}
+	return nil
+}
+
+// expandHome 将路径开头的 ~ 展开为用户主目录
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
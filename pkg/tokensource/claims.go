@@ -0,0 +1,109 @@
+package tokensource
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// legacyIssuer 旧版本基于Secret的SA Token的签发者
+const legacyIssuer = "kubernetes/serviceaccount"
+
+// Claims ServiceAccount Token(JWT)中的声明，只解码不校验签名
+type Claims struct {
+	Issuer            string    `json:"iss"`
+	Audience          []string  `json:"aud"`
+	Subject           string    `json:"sub"` // system:serviceaccount:<namespace>:<name>
+	Namespace         string    `json:"namespace"`
+	ServiceAccount    string    `json:"serviceAccount"`
+	ServiceAccountUID string    `json:"serviceAccountUid"`
+	Pod               string    `json:"pod,omitempty"` // 绑定的Pod，旧版本Token为空
+	PodUID            string    `json:"podUid,omitempty"`
+	Node              string    `json:"node,omitempty"`   // 绑定的节点，1.30之前的集群签发的Token为空
+	Secret            string    `json:"secret,omitempty"` // 旧版本Token所属的Secret
+	IssuedAt          time.Time `json:"iat"`
+	NotBefore         time.Time `json:"nbf"`
+	Expiry            time.Time `json:"exp"`    // 未设置时为零值(旧版本Token不过期)
+	Legacy            bool      `json:"legacy"` // 是否为旧版本基于Secret的Token
+}
+
+// SA ServiceAccount的完整名称(格式: namespace/name)
+func (claims Claims) SA() string {
+	return claims.Namespace + "/" + claims.ServiceAccount
+}
+
+// Expired Token在指定时间是否已过期，未设置过期时间时不过期
+func (claims Claims) Expired(now time.Time) bool {
+	return !claims.Expiry.IsZero() && !now.Before(claims.Expiry)
+}
+
+// DecodeClaims 解码ServiceAccount Token中的声明，不校验签名
+// 同时支持投射卷中的绑定Token和旧版本基于Secret的Token
+// 返回:
+//   - Claims: Token中的声明
+//   - error: 不是JWT或不是ServiceAccount Token时返回错误
+func DecodeClaims(token string) (Claims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("不是JWT格式的Token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return Claims{}, fmt.Errorf("解码Token失败: %w", err)
+	}
+	if !gjson.ValidBytes(payload) {
+		return Claims{}, fmt.Errorf("Token中的声明不是有效的JSON")
+	}
+	result := gjson.ParseBytes(payload)
+	claims := Claims{
+		Issuer:    result.Get("iss").String(),
+		Subject:   result.Get("sub").String(),
+		IssuedAt:  unixTime(result.Get("iat")),
+		NotBefore: unixTime(result.Get("nbf")),
+		Expiry:    unixTime(result.Get("exp")),
+	}
+	// aud 可以是字符串或数组
+	if aud := result.Get("aud"); aud.IsArray() {
+		for _, audience := range aud.Array() {
+			claims.Audience = append(claims.Audience, audience.String())
+		}
+	} else if aud.Exists() {
+		claims.Audience = []string{aud.String()}
+	}
+
+	if k8s := result.Get(`kubernetes\.io`); k8s.Exists() {
+		claims.Namespace = k8s.Get("namespace").String()
+		claims.ServiceAccount = k8s.Get("serviceaccount.name").String()
+		claims.ServiceAccountUID = k8s.Get("serviceaccount.uid").String()
+		claims.Pod = k8s.Get("pod.name").String()
+		claims.PodUID = k8s.Get("pod.uid").String()
+		claims.Node = k8s.Get("node.name").String()
+	} else if claims.Issuer == legacyIssuer {
+		claims.Legacy = true
+		claims.Namespace = result.Get(`kubernetes\.io/serviceaccount/namespace`).String()
+		claims.ServiceAccount = result.Get(`kubernetes\.io/serviceaccount/service-account\.name`).String()
+		claims.ServiceAccountUID = result.Get(`kubernetes\.io/serviceaccount/service-account\.uid`).String()
+		claims.Secret = result.Get(`kubernetes\.io/serviceaccount/secret\.name`).String()
+	}
+	// 其他签发方式只能从 sub 中解析SA
+	if claims.Namespace == "" || claims.ServiceAccount == "" {
+		if rest, ok := strings.CutPrefix(claims.Subject, "system:serviceaccount:"); ok {
+			claims.Namespace, claims.ServiceAccount, _ = strings.Cut(rest, ":")
+		}
+	}
+	if claims.Namespace == "" || claims.ServiceAccount == "" {
+		return claims, fmt.Errorf("不是ServiceAccount Token: sub=%q", claims.Subject)
+	}
+	return claims, nil
+}
+
+// unixTime 将JWT中的时间戳(秒)转换为时间，不存在时为零值
+func unixTime(value gjson.Result) time.Time {
+	if !value.Exists() || value.Int() == 0 {
+		return time.Time{}
+	}
+	return time.Unix(value.Int(), 0)
+}
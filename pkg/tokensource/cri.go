@@ -0,0 +1,221 @@
+package tokensource
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+const (
+	// criExecTimeout 在容器中执行命令的超时时间(秒)
+	criExecTimeout = 10
+	// criPodUIDLabel kubelet为容器设置的Pod UID标签
+	criPodUIDLabel = "io.kubernetes.pod.uid"
+)
+
+// CRI 通过容器运行时(containerd)的CRI socket，在Pod的容器中读取挂载的Token
+// 适用于能访问节点上的运行时socket(如容器挂载了 /run/containerd/containerd.sock)但无法登录节点的情况
+type CRI struct {
+	Socket string // CRI socket路径，为空时使用 DefaultCRISocket
+}
+
+// Name 读取方式的名称
+func (source CRI) Name() string {
+	return TypeCRI
+}
+
+// Token 按Pod UID查找运行中的容器，依次在容器中执行 cat 读取Token
+func (source CRI) Token(ctx context.Context, pod models.Pod) (string, error) {
+	if !uidPattern.MatchString(pod.Uid) {
+		return "", fmt.Errorf("Pod %s/%s 的UID无效: %q", pod.Namespace, pod.Name, pod.Uid)
+	}
+	runtime, closeConn, err := source.connect()
+	if err != nil {
+		return "", err
+	}
+	defer closeConn()
+
+	containers, err := runningContainers(ctx, runtime, map[string]string{criPodUIDLabel: pod.Uid})
+	if err != nil {
+		return "", err
+	}
+	if len(containers) == 0 {
+		return "", fmt.Errorf("运行时中没有Pod %s/%s 的运行中容器", pod.Namespace, pod.Name)
+	}
+	var lastErr error
+	for _, container := range containers {
+		_, output, err := containerToken(ctx, runtime, container.Id)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if token, err := tokenOutput(output, pod); err == nil {
+			return token, nil
+		} else {
+			lastErr = err
+		}
+	}
+	return "", fmt.Errorf("在Pod %s/%s 的容器中读取Token失败: %w", pod.Namespace, pod.Name, lastErr)
+}
+
+// Harvest 在节点上全部运行中的容器里读取挂载的投射卷和Secret卷，保留其中的ServiceAccount Token，
+// 同一Pod的多个容器读到相同Token时只保留一个
+func (source CRI) Harvest(ctx context.Context) ([]NodeToken, error) {
+	runtime, closeConn, err := source.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer closeConn()
+	return criHarvest(ctx, runtime)
+}
+
+// criHarvest 在全部运行中的容器里读取投射卷和Secret卷中的文件
+// 投射卷中的Token可以挂载为任意文件名(如 istio-token)，因此读取卷中的全部文件，按 harvestToken 的规则保留
+func criHarvest(ctx context.Context, runtime runtimeapi.RuntimeServiceClient) ([]NodeToken, error) {
+	containers, err := runningContainers(ctx, runtime, nil)
+	if err != nil {
+		return nil, err
+	}
+	tokens := []NodeToken{}
+	seen := map[string]bool{}
+	for _, container := range containers {
+		podUID := container.Labels[criPodUIDLabel]
+		if podUID == "" {
+			continue
+		}
+		for _, dir := range criVolumeDirs(ctx, runtime, container.Id) {
+			for _, file := range criVolumeFiles(ctx, runtime, container.Id, dir) {
+				output, err := criCat(ctx, runtime, container.Id, file)
+				token, keep := harvestToken(file, output, err)
+				if !keep {
+					continue
+				}
+				if err == nil {
+					if seen[podUID+"\n"+token.Token] {
+						continue
+					}
+					seen[podUID+"\n"+token.Token] = true
+				}
+				token.Path = container.Id + ":" + file
+				token.PodUID = podUID
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// connect 连接CRI socket
+func (source CRI) connect() (runtimeapi.RuntimeServiceClient, func(), error) {
+	socket := strings.TrimPrefix(source.Socket, "unix://")
+	if socket == "" {
+		socket = DefaultCRISocket
+	}
+	conn, err := grpc.NewClient("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接CRI socket %s 失败: %w", socket, err)
+	}
+	return runtimeapi.NewRuntimeServiceClient(conn), func() { conn.Close() }, nil
+}
+
+// runningContainers 列出运行中的容器
+// 参数:
+//   - labels: 容器标签过滤条件，为空时列出全部
+func runningContainers(ctx context.Context, runtime runtimeapi.RuntimeServiceClient, labels map[string]string) ([]*runtimeapi.Container, error) {
+	containers, err := runtime.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{
+			State:         &runtimeapi.ContainerStateValue{State: runtimeapi.ContainerState_CONTAINER_RUNNING},
+			LabelSelector: labels,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("列出容器失败: %w", err)
+	}
+	return containers.Containers, nil
+}
+
+// containerToken 在容器中执行 cat 读取挂载的Token
+// 返回:
+//   - string: Token在容器中的路径
+//   - string: 命令输出
+//   - error: 执行失败或命令退出码不为0时返回错误
+func containerToken(ctx context.Context, runtime runtimeapi.RuntimeServiceClient, containerID string) (string, string, error) {
+	file := criTokenPath(ctx, runtime, containerID)
+	output, err := criCat(ctx, runtime, containerID, file)
+	return file, output, err
+}
+
+// criCat 在容器中执行 cat 读取文件
+func criCat(ctx context.Context, runtime runtimeapi.RuntimeServiceClient, containerID string, file string) (string, error) {
+	resp, err := runtime.ExecSync(ctx, &runtimeapi.ExecSyncRequest{
+		ContainerId: containerID,
+		Cmd:         []string{"cat", file},
+		Timeout:     criExecTimeout,
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.ExitCode != 0 {
+		return "", fmt.Errorf("退出码 %d: %s", resp.ExitCode, strings.TrimSpace(string(resp.Stderr)))
+	}
+	return string(resp.Stdout), nil
+}
+
+// criTokenPath 根据容器的挂载信息查找Token在容器中的路径，无法确定时使用默认路径
+func criTokenPath(ctx context.Context, runtime runtimeapi.RuntimeServiceClient, containerID string) string {
+	status, err := runtime.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: containerID})
+	if err == nil && status.Status != nil {
+		for _, mount := range status.Status.Mounts {
+			// 自动挂载的投射卷(kube-api-access-*)和旧版本的Token Secret卷
+			if strings.Contains(mount.HostPath, "/volumes/kubernetes.io~projected/kube-api-access-") ||
+				(strings.Contains(mount.HostPath, "/volumes/kubernetes.io~secret/") && strings.Contains(mount.HostPath, "-token-")) {
+				return path.Join(mount.ContainerPath, "token")
+			}
+		}
+	}
+	return path.Join(containerTokenDir, "token")
+}
+
+// criVolumeDirs 根据容器的挂载信息找出全部投射卷和Secret卷在容器中的目录，无法读取挂载信息时使用默认目录
+func criVolumeDirs(ctx context.Context, runtime runtimeapi.RuntimeServiceClient, containerID string) []string {
+	status, err := runtime.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: containerID})
+	if err != nil || status.Status == nil {
+		return []string{containerTokenDir}
+	}
+	dirs := []string{}
+	for _, mount := range status.Status.Mounts {
+		if strings.Contains(mount.HostPath, "/volumes/kubernetes.io~projected/") ||
+			strings.Contains(mount.HostPath, "/volumes/kubernetes.io~secret/") {
+			dirs = append(dirs, mount.ContainerPath)
+		}
+	}
+	return dirs
+}
+
+// criVolumeFiles 在容器中执行 ls 列出卷目录中的文件，不包括以 . 开头的原子更新目录，
+// 无法列出时只读取目录中的 token 文件
+func criVolumeFiles(ctx context.Context, runtime runtimeapi.RuntimeServiceClient, containerID string, dir string) []string {
+	resp, err := runtime.ExecSync(ctx, &runtimeapi.ExecSyncRequest{
+		ContainerId: containerID,
+		Cmd:         []string{"ls", "-1", dir},
+		Timeout:     criExecTimeout,
+	})
+	if err != nil || resp.ExitCode != 0 {
+		return []string{path.Join(dir, "token")}
+	}
+	files := []string{}
+	for _, name := range strings.Split(string(resp.Stdout), "\n") {
+		if name = strings.TrimSpace(name); name != "" && !strings.HasPrefix(name, ".") {
+			files = append(files, path.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,110 @@
+package tokensource
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// fakeRuntime 模拟CRI运行时，容器中的文件按 容器ID:路径 保存
+type fakeRuntime struct {
+	runtimeapi.RuntimeServiceClient
+	containers []*runtimeapi.Container
+	mounts     map[string][]*runtimeapi.Mount
+	files      map[string]string
+}
+
+func (runtime fakeRuntime) ListContainers(ctx context.Context, in *runtimeapi.ListContainersRequest, opts ...grpc.CallOption) (*runtimeapi.ListContainersResponse, error) {
+	return &runtimeapi.ListContainersResponse{Containers: runtime.containers}, nil
+}
+
+func (runtime fakeRuntime) ContainerStatus(ctx context.Context, in *runtimeapi.ContainerStatusRequest, opts ...grpc.CallOption) (*runtimeapi.ContainerStatusResponse, error) {
+	return &runtimeapi.ContainerStatusResponse{Status: &runtimeapi.ContainerStatus{Mounts: runtime.mounts[in.ContainerId]}}, nil
+}
+
+func (runtime fakeRuntime) ExecSync(ctx context.Context, in *runtimeapi.ExecSyncRequest, opts ...grpc.CallOption) (*runtimeapi.ExecSyncResponse, error) {
+	prefix := in.ContainerId + ":"
+	switch in.Cmd[0] {
+	case "ls":
+		names := []string{}
+		for key := range runtime.files {
+			if file, ok := strings.CutPrefix(key, prefix); ok && path.Dir(file) == in.Cmd[2] {
+				names = append(names, path.Base(file))
+			}
+		}
+		return &runtimeapi.ExecSyncResponse{Stdout: []byte(strings.Join(names, "\n") + "\n")}, nil
+	case "cat":
+		if content, exists := runtime.files[prefix+in.Cmd[1]]; exists {
+			return &runtimeapi.ExecSyncResponse{Stdout: []byte(content)}, nil
+		}
+		return &runtimeapi.ExecSyncResponse{ExitCode: 1, Stderr: []byte("No such file or directory")}, nil
+	}
+	return nil, fmt.Errorf("不支持的命令 %v", in.Cmd)
+}
+
+func TestCRIHarvest(t *testing.T) {
+	const uid = "0f3c1e6a-1111-2222-3333-444455556666"
+	hostVolumes := "/var/lib/kubelet/pods/" + uid + "/volumes/"
+	runtime := fakeRuntime{
+		containers: []*runtimeapi.Container{
+			{Id: "app", Labels: map[string]string{criPodUIDLabel: uid}},
+			{Id: "sidecar", Labels: map[string]string{criPodUIDLabel: uid}},
+			{Id: "static"},
+		},
+		mounts: map[string][]*runtimeapi.Mount{
+			"app": {
+				{ContainerPath: containerTokenDir, HostPath: hostVolumes + "kubernetes.io~projected/kube-api-access-x1"},
+				{ContainerPath: "/var/run/secrets/tokens", HostPath: hostVolumes + "kubernetes.io~projected/istio-token"},
+				{ContainerPath: "/etc/legacy", HostPath: hostVolumes + "kubernetes.io~secret/legacy"},
+				{ContainerPath: "/etc/config", HostPath: hostVolumes + "kubernetes.io~configmap/config"},
+			},
+			"sidecar": {
+				{ContainerPath: containerTokenDir, HostPath: hostVolumes + "kubernetes.io~projected/kube-api-access-x1"},
+			},
+		},
+		files: map[string]string{
+			"app:" + containerTokenDir + "/token":     fixtureJWT("system:serviceaccount:app:default"),
+			"app:" + containerTokenDir + "/ca.crt":    "-----BEGIN CERTIFICATE-----",
+			"app:" + containerTokenDir + "/namespace": "app",
+			"app:" + containerTokenDir + "/..data":    "",
+			"app:/var/run/secrets/tokens/istio-token": fixtureJWT("system:serviceaccount:app:mesh"),
+			"app:/etc/legacy/credential":              fixtureJWT("system:serviceaccount:app:legacy"),
+			"app:/etc/legacy/password":                "a.b.c",
+			"app:/etc/config/token":                   fixtureJWT("system:serviceaccount:app:config"),
+			"sidecar:" + containerTokenDir + "/token": fixtureJWT("system:serviceaccount:app:default"),
+			"static:" + containerTokenDir + "/token":  fixtureJWT("system:serviceaccount:app:static"),
+		},
+	}
+
+	tokens, err := criHarvest(context.Background(), runtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"app:" + containerTokenDir + "/token":     "app/default",
+		"app:/var/run/secrets/tokens/istio-token": "app/mesh",
+		"app:/etc/legacy/credential":              "app/legacy",
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("找到 %d 个Token, 期望 %d 个: %+v", len(tokens), len(want), tokens)
+	}
+	for _, token := range tokens {
+		sa, exists := want[token.Path]
+		if !exists {
+			t.Errorf("不应保留 %s", token.Path)
+			continue
+		}
+		if token.PodUID != uid || token.Err != nil {
+			t.Errorf("%s: PodUID=%q Err=%v", token.Path, token.PodUID, token.Err)
+		}
+		claims, err := DecodeClaims(token.Token)
+		if err != nil || claims.SA() != sa {
+			t.Errorf("%s: SA=%q err=%v, 期望 %s", token.Path, claims.SA(), err, sa)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package tokensource
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/request"
+	"path"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Exec 使用当前配置的凭据，通过 pods/exec 在Pod的容器中读取挂载的Token
+// 不需要登录节点，Pod可以运行在任意节点上，但需要 pods/exec 权限，且容器中需要有 cat 命令
+type Exec struct{}
+
+// Name 读取方式的名称
+func (source Exec) Name() string {
+	return TypeExec
+}
+
+// Token 在挂载了Token的容器中执行 cat 读取Token
+func (source Exec) Token(ctx context.Context, pod models.Pod) (string, error) {
+	clientset, err := request.GetClientSet("")
+	if err != nil {
+		return "", err
+	}
+	config, err := request.GetRestConfig("")
+	if err != nil {
+		return "", err
+	}
+	obj, err := clientset.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metaV1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("获取Pod %s/%s 失败: %w", pod.Namespace, pod.Name, err)
+	}
+	container, file, err := podTokenPath(obj)
+	if err != nil {
+		return "", err
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&coreV1.PodExecOptions{
+			Container: container,
+			Command:   []string{"cat", file},
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	// 优先使用WebSocket，API服务器或代理不支持时回退到SPDY，与kubectl一致
+	websocket, err := remotecommand.NewWebSocketExecutor(config, "GET", req.URL().String())
+	if err != nil {
+		return "", fmt.Errorf("创建exec连接失败: %w", err)
+	}
+	spdy, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return "", fmt.Errorf("创建exec连接失败: %w", err)
+	}
+	executor, err := remotecommand.NewFallbackExecutor(websocket, spdy, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+	if err != nil {
+		return "", fmt.Errorf("创建exec连接失败: %w", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("在容器 %s 中读取Token失败: %w: %s", container, err, message)
+		}
+		return "", fmt.Errorf("在容器 %s 中读取Token失败: %w", container, err)
+	}
+	return tokenOutput(stdout.String(), pod)
+}
+
+// podTokenPath 查找挂载了SA Token的容器及Token在容器中的路径
+// 投射卷中的 serviceAccountToken 和旧版本的Token Secret卷都视为Token，使用子路径挂载的卷不处理
+//
+// 返回:
+//   - string: 容器名称
+//   - string: Token在容器中的路径
+//   - error: 没有容器挂载Token时返回错误
+func podTokenPath(pod *coreV1.Pod) (string, string, error) {
+	files := map[string]string{} // 卷名称 -> Token在卷中的文件名
+	for _, volume := range pod.Spec.Volumes {
+		switch {
+		case volume.Projected != nil:
+			for _, projection := range volume.Projected.Sources {
+				if projection.ServiceAccountToken != nil {
+					files[volume.Name] = projection.ServiceAccountToken.Path
+				}
+			}
+		case volume.Secret != nil && strings.Contains(volume.Secret.SecretName, "-token-"):
+			files[volume.Name] = "token"
+		}
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, mount := range container.VolumeMounts {
+			if file, exists := files[mount.Name]; exists && mount.SubPath == "" {
+				return container.Name, path.Join(mount.MountPath, file), nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("Pod %s/%s 的容器中没有挂载Token", pod.Namespace, pod.Name)
+}
@@ -0,0 +1,72 @@
+package tokensource
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Local 在受控节点上运行时，直接读取kubelet目录中Pod挂载的Token
+// kubelet目录挂载在其他位置时(如容器中挂载了宿主机的 /var/lib/kubelet)通过 Root 指定
+type Local struct {
+	Root string // kubelet数据目录，为空时使用 DefaultKubeletRoot
+}
+
+// Name 读取方式的名称
+func (source Local) Name() string {
+	return TypeLocal
+}
+
+// Token 读取Pod挂载的Token，优先使用投射卷(kubernetes.io~projected)中的Token
+func (source Local) Token(ctx context.Context, pod models.Pod) (string, error) {
+	pattern, err := kubeletTokenPattern(source.Root, pod)
+	if err != nil {
+		return "", err
+	}
+	files, err := filepath.Glob(filepath.FromSlash(pattern))
+	if err != nil {
+		return "", fmt.Errorf("查找Token文件失败: %w", err)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("未找到Pod %s/%s 的Token文件: %s", pod.Namespace, pod.Name, pattern)
+	}
+	// kubernetes.io~projected 排在 kubernetes.io~secret 之前
+	sort.Strings(files)
+	var lastErr error
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if token, err := tokenOutput(string(content), pod); err == nil {
+			return token, nil
+		}
+	}
+	if lastErr != nil {
+		return "", fmt.Errorf("读取Token文件失败: %w", lastErr)
+	}
+	return tokenOutput("", pod)
+}
+
+// Harvest 读取kubelet目录中全部Pod的投射卷和Secret卷，保留其中的ServiceAccount Token
+func (source Local) Harvest(ctx context.Context) ([]NodeToken, error) {
+	tokens := []NodeToken{}
+	for _, pattern := range kubeletHarvestPatterns(source.Root) {
+		files, err := filepath.Glob(filepath.FromSlash(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("查找Token文件失败: %w", err)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if token, keep := harvestToken(file, string(content), err); keep {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens, nil
+}
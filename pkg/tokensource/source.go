@@ -0,0 +1,137 @@
+package tokensource
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 读取Token的方式，与配置项 ssh.tokenSource 的取值一致
+const (
+	TypeSSH   = "ssh"
+	TypeLocal = "local"
+	TypeExec  = "exec"
+	TypeCRI   = "cri"
+)
+
+const (
+	// DefaultKubeletRoot kubelet默认的数据目录
+	DefaultKubeletRoot = "/var/lib/kubelet"
+	// DefaultCRISocket containerd默认的CRI socket
+	DefaultCRISocket = "/run/containerd/containerd.sock"
+	// containerTokenDir 容器内默认挂载SA Token的目录
+	containerTokenDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// uidPattern Pod UID的格式，拼接路径和命令前检查，避免注入
+var uidPattern = regexp.MustCompile(`^[0-9a-fA-F-]+$`)
+
+// Source 读取Pod挂载的ServiceAccount Token的方式
+type Source interface {
+	// Name 读取方式的名称
+	Name() string
+	// Token 读取Pod挂载的ServiceAccount Token
+	Token(ctx context.Context, pod models.Pod) (string, error)
+}
+
+// NodeToken 在节点上找到的一个Token
+type NodeToken struct {
+	Path   string // Token文件在节点上的路径，cri方式为 容器ID:容器内路径
+	PodUID string // 挂载该Token的Pod的UID，取自路径或容器标签
+	Token  string
+	Err    error // 读取失败的原因
+}
+
+// Harvester 能够枚举节点上全部Pod挂载的Token的读取方式
+type Harvester interface {
+	Source
+	// Harvest 读取节点上全部Pod挂载的Token，包括API列表中没有的Pod
+	Harvest(ctx context.Context) ([]NodeToken, error)
+}
+
+// For 根据受控节点的配置选择读取Token的方式，未配置时使用SSH
+// 参数:
+//   - node: 受控节点的SSH及Token读取配置
+func For(node models.SSHConfig) (Source, error) {
+	switch strings.ToLower(node.TokenSource) {
+	case "", TypeSSH:
+		return SSH{Config: node}, nil
+	case TypeLocal:
+		return Local{Root: node.KubeletRoot}, nil
+	case TypeExec:
+		return Exec{}, nil
+	case TypeCRI:
+		return CRI{Socket: node.CriSocket}, nil
+	}
+	return nil, fmt.Errorf("不支持的Token读取方式: %s", node.TokenSource)
+}
+
+// kubeletTokenPattern Pod在节点上挂载的Token文件的匹配路径
+// 参数:
+//   - root: kubelet数据目录，为空时使用 DefaultKubeletRoot
+//   - pod: Pod信息，UID不合法时返回错误
+func kubeletTokenPattern(root string, pod models.Pod) (string, error) {
+	if !uidPattern.MatchString(pod.Uid) {
+		return "", fmt.Errorf("Pod %s/%s 的UID无效: %q", pod.Namespace, pod.Name, pod.Uid)
+	}
+	if root == "" {
+		root = DefaultKubeletRoot
+	}
+	return path.Join(root, "pods", pod.Uid, "volumes", "kubernetes.io*", "*", "token"), nil
+}
+
+// kubeletHarvestPatterns 节点上全部Pod的投射卷和Secret卷中文件的匹配路径
+// 投射卷中的Token可以挂载为任意文件名(如 istio-token)，因此匹配卷中的全部文件，
+// 不匹配以 . 开头的文件(投射卷和Secret卷中的 ..data 等原子更新目录)
+func kubeletHarvestPatterns(root string) []string {
+	if root == "" {
+		root = DefaultKubeletRoot
+	}
+	return []string{
+		path.Join(root, "pods", "*", "volumes", "kubernetes.io~projected", "*", "[^.]*"),
+		path.Join(root, "pods", "*", "volumes", "kubernetes.io~secret", "*", "[^.]*"),
+	}
+}
+
+// harvestToken 将卷中读取到的文件整理为Token，返回是否保留
+// 默认的 token 文件总是保留(读取失败时记录原因)，其他文件只在内容能解码为ServiceAccount Token时保留，
+// 跳过CA证书、命名空间等文件
+// 参数:
+//   - file: 文件在节点上的路径
+//   - content: 文件内容
+//   - err: 读取文件的错误
+func harvestToken(file string, content string, err error) (NodeToken, bool) {
+	token := NodeToken{Path: file, PodUID: podUIDFromPath(file), Token: strings.TrimSpace(content), Err: err}
+	if path.Base(filepath.ToSlash(file)) == "token" {
+		return token, true
+	}
+	if err != nil {
+		return token, false
+	}
+	_, decodeErr := DecodeClaims(token.Token)
+	return token, decodeErr == nil
+}
+
+// podUIDFromPath 从kubelet目录中的Token路径(.../pods/<uid>/volumes/...)中取出Pod UID
+func podUIDFromPath(file string) string {
+	parts := strings.Split(filepath.ToSlash(file), "/")
+	for i := len(parts) - 2; i >= 2; i-- {
+		if parts[i] == "volumes" && parts[i-1] != "" && parts[i-2] == "pods" {
+			return parts[i-1]
+		}
+	}
+	return ""
+}
+
+// tokenOutput 整理读取到的Token，去掉首尾空白，内容为空时返回错误
+func tokenOutput(output string, pod models.Pod) (string, error) {
+	token := strings.TrimSpace(output)
+	if token == "" {
+		return "", fmt.Errorf("Pod %s/%s 中没有读取到Token", pod.Namespace, pod.Name)
+	}
+	return token, nil
+}
@@ -0,0 +1,47 @@
+package tokensource
+
+import (
+	"context"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/sshclient"
+)
+
+// SSH 通过SSH(SFTP)登录受控节点，读取kubelet目录中Pod挂载的Token
+type SSH struct {
+	Config models.SSHConfig // 受控节点的SSH配置，KubeletRoot 为kubelet数据目录
+}
+
+// Name 读取方式的名称
+func (source SSH) Name() string {
+	return TypeSSH
+}
+
+// Token 读取Pod挂载的Token，Pod需要运行在受控节点上
+func (source SSH) Token(ctx context.Context, pod models.Pod) (string, error) {
+	pattern, err := kubeletTokenPattern(source.Config.KubeletRoot, pod)
+	if err != nil {
+		return "", err
+	}
+	output, err := sshclient.ReadFile(source.Config, pattern)
+	if err != nil {
+		return "", err
+	}
+	return tokenOutput(output, pod)
+}
+
+// Harvest 通过SFTP读取kubelet目录中全部Pod的投射卷和Secret卷，保留其中的ServiceAccount Token
+func (source SSH) Harvest(ctx context.Context) ([]NodeToken, error) {
+	tokens := []NodeToken{}
+	for _, pattern := range kubeletHarvestPatterns(source.Config.KubeletRoot) {
+		files, err := sshclient.ReadFiles(source.Config, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if token, keep := harvestToken(file.Path, file.Content, file.Err); keep {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens, nil
+}
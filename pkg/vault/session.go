@@ -0,0 +1,133 @@
+package vault
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// 环境变量
+const (
+	EnvFile       = "K8SEPDS_VAULT"            // 凭据库文件路径
+	EnvPassphrase = "K8SEPDS_VAULT_PASSPHRASE" // 凭据库口令，用于无法交互输入的场景
+)
+
+var (
+	sessionMu sync.Mutex
+	session   *Store
+)
+
+// DefaultFile 默认的凭据库文件路径: 环境变量 K8SEPDS_VAULT，否则为 conf/credentials.vault
+func DefaultFile() string {
+	if file := os.Getenv(EnvFile); file != "" {
+		return file
+	}
+	return filepath.Join("conf", "credentials.vault")
+}
+
+// Unlock 解锁默认凭据库，每次运行只需输入一次口令
+// 口令依次取自环境变量 K8SEPDS_VAULT_PASSPHRASE 和终端输入
+func Unlock() (*Store, error) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if session != nil {
+		return session, nil
+	}
+	passphrase, err := Passphrase("[输入] 凭据库口令: ")
+	if err != nil {
+		return nil, err
+	}
+	store, err := Open(DefaultFile(), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	session = store
+	return session, nil
+}
+
+// SetSession 将已打开的凭据库作为本次运行使用的凭据库，用于新建凭据库或更换口令之后
+func SetSession(store *Store) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	session = store
+}
+
+// Passphrase 读取凭据库口令，设置了环境变量 K8SEPDS_VAULT_PASSPHRASE 时直接使用，否则从终端读取且不回显
+// 参数:
+//   - prompt: 提示信息
+func Passphrase(prompt string) (string, error) {
+	if passphrase, ok := os.LookupEnv(EnvPassphrase); ok {
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("无法读取凭据库口令: 标准输入不是终端，请设置环境变量 %s", EnvPassphrase)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("读取凭据库口令失败: %w", err)
+	}
+	return string(passphrase), nil
+}
+
+// ReadSecret 从终端读取凭据且不回显，标准输入不是终端时读取全部输入(便于通过管道导入)
+// 参数:
+//   - prompt: 提示信息
+func ReadSecret(prompt string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		reader := bufio.NewReader(os.Stdin)
+		var builder strings.Builder
+		if _, err := reader.WriteTo(&builder); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(builder.String(), "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("读取凭据失败: %w", err)
+	}
+	return string(secret), nil
+}
+
+// IsRef 配置项的值是否为凭据库引用
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, RefPrefix)
+}
+
+// Ref 凭据名称对应的配置项引用
+func Ref(name string) string {
+	return RefPrefix + name
+}
+
+// Resolve 解析配置项的值，凭据库引用时解锁凭据库并返回凭据，否则原样返回
+// 参数:
+//   - value: 配置项的值
+func Resolve(value string) (string, error) {
+	if !IsRef(value) {
+		return value, nil
+	}
+	store, err := Unlock()
+	if err != nil {
+		return "", err
+	}
+	return store.Get(strings.TrimPrefix(value, RefPrefix))
+}
+
+// ReadFile 读取文件类配置项(Token文件、私钥文件)的内容，凭据库引用时返回凭据
+// 参数:
+//   - value: 配置项的值，文件路径或凭据库引用
+func ReadFile(value string) ([]byte, error) {
+	if IsRef(value) {
+		secret, err := Resolve(value)
+		return []byte(secret), err
+	}
+	return os.ReadFile(value)
+}
@@ -0,0 +1,255 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// RefPrefix 配置项中引用凭据库凭据的前缀，如 password: "vault:prod-ssh"
+const RefPrefix = "vault:"
+
+// formatVersion 凭据库文件格式版本，同时作为加密的附加数据
+const formatVersion = "k8sepds.vault/v1"
+
+// ErrWrongPassphrase 口令错误或凭据库文件被篡改
+var ErrWrongPassphrase = errors.New("凭据库口令错误或文件已损坏")
+
+// scrypt 参数上限，防止被篡改的文件使派生密钥耗尽内存或CPU(N=2^20、R=32 时约需 4GiB 内存)
+const (
+	maxScryptN = 1 << 20
+	maxScryptR = 32
+	maxScryptP = 16
+)
+
+// Secret 凭据库中的一项凭据
+type Secret struct {
+	Value   string    `json:"value"`
+	Updated time.Time `json:"updated"`
+}
+
+// kdf 由口令派生密钥的参数
+type kdf struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// envelope 凭据库文件的内容，凭据以 AES-256-GCM 加密
+type envelope struct {
+	Version    string `json:"version"`
+	KDF        kdf    `json:"kdf"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Store 已解锁的凭据库
+type Store struct {
+	path    string
+	kdf     kdf
+	key     []byte
+	secrets map[string]Secret
+}
+
+// Create 新建凭据库，文件已存在时返回错误
+// 参数:
+//   - path: 凭据库文件路径
+//   - passphrase: 凭据库口令
+func Create(path string, passphrase string) (*Store, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("凭据库已存在: %s", path)
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("凭据库口令不能为空")
+	}
+	store := &Store{path: path, secrets: map[string]Secret{}}
+	if err := store.derive(passphrase); err != nil {
+		return nil, err
+	}
+	return store, store.Save()
+}
+
+// Open 使用口令打开凭据库
+// 参数:
+//   - path: 凭据库文件路径
+//   - passphrase: 凭据库口令
+func Open(path string, passphrase string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("凭据库不存在: %s，请先执行 k8sEPDS vault init", path)
+		}
+		return nil, fmt.Errorf("读取凭据库失败: %w", err)
+	}
+	var file envelope
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析凭据库失败: %w", err)
+	}
+	if file.Version != formatVersion || file.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("不支持的凭据库格式: %s", file.Version)
+	}
+	if err := file.KDF.validate(); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), file.KDF.Salt, file.KDF.N, file.KDF.R, file.KDF.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, []byte(formatVersion))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	store := &Store{path: path, kdf: file.KDF, key: key, secrets: map[string]Secret{}}
+	if err := json.Unmarshal(plaintext, &store.secrets); err != nil {
+		return nil, fmt.Errorf("解析凭据失败: %w", err)
+	}
+	return store, nil
+}
+
+// Path 凭据库文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get 按名称读取凭据
+func (s *Store) Get(name string) (string, error) {
+	secret, exists := s.secrets[name]
+	if !exists {
+		return "", fmt.Errorf("凭据库中没有凭据: %s", name)
+	}
+	return secret.Value, nil
+}
+
+// Set 添加或更新凭据，调用 Save 后写入文件
+func (s *Store) Set(name string, value string) error {
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("无效的凭据名称: %q", name)
+	}
+	s.secrets[name] = Secret{Value: value, Updated: time.Now()}
+	return nil
+}
+
+// Delete 删除凭据，调用 Save 后写入文件
+func (s *Store) Delete(name string) error {
+	if _, exists := s.secrets[name]; !exists {
+		return fmt.Errorf("凭据库中没有凭据: %s", name)
+	}
+	delete(s.secrets, name)
+	return nil
+}
+
+// Names 按名称排序的全部凭据名称，不包含凭据的值
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Updated 凭据的更新时间
+func (s *Store) Updated(name string) time.Time {
+	return s.secrets[name].Updated
+}
+
+// ChangePassphrase 更换凭据库口令，重新生成盐并写入文件
+func (s *Store) ChangePassphrase(passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("凭据库口令不能为空")
+	}
+	if err := s.derive(passphrase); err != nil {
+		return err
+	}
+	return s.Save()
+}
+
+// Save 加密凭据并写入文件，先写临时文件再替换，文件权限为0600
+func (s *Store) Save() error {
+	plaintext, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(envelope{
+		Version:    formatVersion,
+		KDF:        s.kdf,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, []byte(formatVersion)),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("创建凭据库目录失败: %w", err)
+		}
+	}
+	temp := s.path + ".tmp"
+	if err := os.WriteFile(temp, data, 0o600); err != nil {
+		return fmt.Errorf("写入凭据库失败: %w", err)
+	}
+	if err := os.Rename(temp, s.path); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("写入凭据库失败: %w", err)
+	}
+	return nil
+}
+
+// derive 生成新的盐并由口令派生密钥
+func (s *Store) derive(passphrase string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	params := kdf{Name: "scrypt", Salt: salt, N: 1 << 15, R: 8, P: 1}
+	key, err := scrypt.Key([]byte(passphrase), params.Salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return fmt.Errorf("派生密钥失败: %w", err)
+	}
+	s.kdf, s.key = params, key
+	return nil
+}
+
+// validate 检查从文件中读取的 scrypt 参数，N 必须是大于1的2的幂
+func (k kdf) validate() error {
+	if k.N <= 1 || k.N > maxScryptN || k.N&(k.N-1) != 0 || k.R < 1 || k.R > maxScryptR || k.P < 1 || k.P > maxScryptP || len(k.Salt) == 0 {
+		return fmt.Errorf("不支持的凭据库密钥派生参数: N=%d R=%d P=%d", k.N, k.R, k.P)
+	}
+	return nil
+}
+
+// newAEAD 创建 AES-256-GCM 加密器
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
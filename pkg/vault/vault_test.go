@@ -0,0 +1,90 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newFixture 创建包含一项凭据的凭据库，返回文件路径和文件内容
+func newFixture(t *testing.T) (string, envelope) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vault.json")
+	store, err := Create(path, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("prod-ssh", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file envelope
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	return path, file
+}
+
+func TestOpen(t *testing.T) {
+	path, _ := newFixture(t)
+	store, err := Open(path, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := store.Get("prod-ssh"); err != nil || value != "secret" {
+		t.Errorf("Get = %q, %v", value, err)
+	}
+	if _, err := Open(path, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("口令错误时 Open = %v, 期望 ErrWrongPassphrase", err)
+	}
+}
+
+// TestOpenTampered 被篡改的凭据库文件返回错误而不是panic或耗尽资源
+func TestOpenTampered(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(*envelope)
+		wrongKey bool // 是否期望 ErrWrongPassphrase
+	}{
+		{name: "nonce为空", tamper: func(f *envelope) { f.Nonce = nil }, wrongKey: true},
+		{name: "nonce过短", tamper: func(f *envelope) { f.Nonce = f.Nonce[:4] }, wrongKey: true},
+		{name: "nonce过长", tamper: func(f *envelope) { f.Nonce = append(f.Nonce, 0) }, wrongKey: true},
+		{name: "密文被修改", tamper: func(f *envelope) { f.Ciphertext[0] ^= 0xff }, wrongKey: true},
+		{name: "N过大", tamper: func(f *envelope) { f.KDF.N = 1 << 30 }},
+		{name: "N不是2的幂", tamper: func(f *envelope) { f.KDF.N = 3 }},
+		{name: "N为1", tamper: func(f *envelope) { f.KDF.N = 1 }},
+		{name: "R为0", tamper: func(f *envelope) { f.KDF.R = 0 }},
+		{name: "R过大", tamper: func(f *envelope) { f.KDF.R = 1 << 20 }},
+		{name: "P过大", tamper: func(f *envelope) { f.KDF.P = 1 << 20 }},
+		{name: "P为负数", tamper: func(f *envelope) { f.KDF.P = -1 }},
+		{name: "缺少盐", tamper: func(f *envelope) { f.KDF.Salt = nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, file := newFixture(t)
+			tt.tamper(&file)
+			data, err := json.Marshal(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err = Open(path, "passphrase")
+			if err == nil {
+				t.Fatal("被篡改的凭据库不应能打开")
+			}
+			if errors.Is(err, ErrWrongPassphrase) != tt.wrongKey {
+				t.Errorf("Open = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,463 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"k8sEPDS/models"
+	"k8sEPDS/pkg/diff"
+	"k8sEPDS/pkg/scan"
+	"k8sEPDS/pkg/scan/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	rbacListers "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 事件类型
+const (
+	EventNewCriticalSA              = "NewCriticalSA"              // 出现新的关键SA
+	EventNewPermission              = "NewPermission"              // 已有关键SA新增高危权限类型
+	EventScopeGained                = "ScopeGained"                // 关键SA的权限范围从namespace扩大到cluster
+	EventCriticalPodOnSensitiveNode = "CriticalPodOnSensitiveNode" // 挂载关键SA的Pod被调度到敏感节点
+)
+
+// controlPlaneLabels 带有这些标签的节点视为敏感节点
+var controlPlaneLabels = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+}
+
+// Event 持续监控过程中产生的事件
+type Event struct {
+	Time  time.Time
+	Type  string   // 事件类型
+	SA    string   // ServiceAccount完整名称(格式:namespace/name)
+	Types []string // 相关的高危权限类型
+	Level string   // 当前权限范围
+	Pod   string   // 相关Pod(格式:namespace/name)
+	Node  string   // 相关节点
+	Cause []string // 导致变化的RoleBinding/ClusterRoleBinding
+}
+
+// Watcher 基于informer维护SA/角色/绑定/Pod模型，并在变化时增量重新评估关键权限
+type Watcher struct {
+	clientset      kubernetes.Interface
+	controlledNode string
+	sensitiveNodes map[string]bool
+	handler        func(Event)
+	onUpdate       func([]models.CriticalSA)
+	interval       time.Duration
+
+	roles               rbacListers.RoleLister
+	clusterRoles        rbacListers.ClusterRoleLister
+	roleBindings        rbacListers.RoleBindingLister
+	clusterRoleBindings rbacListers.ClusterRoleBindingLister
+	pods                coreListers.PodLister
+	serviceAccounts     coreListers.ServiceAccountLister
+	nodes               coreListers.NodeLister
+
+	mu       sync.Mutex
+	dirty    map[string]bool              // 待重新评估的SA
+	critical map[string]models.CriticalSA // 当前的关键SA
+}
+
+// NewWatcher 创建监控实例
+// 参数:
+//   - clientset: Kubernetes客户端
+//   - controlledNode: 受控节点名称
+//   - sensitiveNodes: 额外的敏感节点名称，控制平面节点始终视为敏感节点
+//   - handler: 事件处理函数
+func NewWatcher(clientset kubernetes.Interface, controlledNode string, sensitiveNodes []string, handler func(Event)) *Watcher {
+	w := &Watcher{
+		clientset:      clientset,
+		controlledNode: controlledNode,
+		sensitiveNodes: map[string]bool{},
+		handler:        handler,
+		interval:       2 * time.Second,
+		dirty:          map[string]bool{},
+		critical:       map[string]models.CriticalSA{},
+	}
+	for _, node := range sensitiveNodes {
+		w.sensitiveNodes[node] = true
+	}
+	return w
+}
+
+// SetUpdateHandler 设置关键SA集合变化(包括初始基线)时的回调函数
+func (w *Watcher) SetUpdateHandler(onUpdate func([]models.CriticalSA)) {
+	w.onUpdate = onUpdate
+}
+
+// Run 启动informer并持续监控，直到ctx被取消
+func (w *Watcher) Run(ctx context.Context) error {
+	factory := informers.NewSharedInformerFactory(w.clientset, 0)
+	rbac := factory.Rbac().V1()
+	core := factory.Core().V1()
+	w.roles = rbac.Roles().Lister()
+	w.clusterRoles = rbac.ClusterRoles().Lister()
+	w.roleBindings = rbac.RoleBindings().Lister()
+	w.clusterRoleBindings = rbac.ClusterRoleBindings().Lister()
+	w.pods = core.Pods().Lister()
+	w.serviceAccounts = core.ServiceAccounts().Lister()
+	w.nodes = core.Nodes().Lister()
+
+	handlers := []struct {
+		informer cache.SharedIndexInformer
+		onChange func(oldObj, newObj interface{})
+	}{
+		{rbac.Roles().Informer(), w.onRoleChange},
+		{rbac.ClusterRoles().Informer(), w.onRoleChange},
+		{rbac.RoleBindings().Informer(), w.onBindingChange},
+		{rbac.ClusterRoleBindings().Informer(), w.onBindingChange},
+		{core.Pods().Informer(), w.onPodChange},
+		{core.ServiceAccounts().Informer(), w.onServiceAccountChange},
+		{core.Nodes().Informer(), func(_, _ interface{}) {}},
+	}
+	synced := []cache.InformerSynced{}
+	for _, h := range handlers {
+		onChange := h.onChange
+		_, err := h.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { onChange(nil, obj) },
+			UpdateFunc: onChange,
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				onChange(obj, nil)
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("注册事件处理函数失败: %w", err)
+		}
+		synced = append(synced, h.informer.HasSynced)
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return fmt.Errorf("informer缓存同步失败")
+	}
+
+	// 初始同步完成后进行一次完整评估作为基线，不产生事件
+	w.mu.Lock()
+	w.dirty = map[string]bool{}
+	w.mu.Unlock()
+	baseline := w.evaluate(nil)
+	w.mu.Lock()
+	w.critical = baseline
+	w.mu.Unlock()
+	w.notifyUpdate()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.reevaluate()
+		}
+	}
+}
+
+// CriticalSAs 返回当前的关键SA
+func (w *Watcher) CriticalSAs() []models.CriticalSA {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	result := make([]models.CriticalSA, 0, len(w.critical))
+	for _, criticalSA := range w.critical {
+		result = append(result, criticalSA)
+	}
+	return result
+}
+
+// notifyUpdate 将当前的关键SA集合通知给更新回调
+func (w *Watcher) notifyUpdate() {
+	if w.onUpdate != nil {
+		w.onUpdate(w.CriticalSAs())
+	}
+}
+
+// reevaluate 重新评估所有被标记的SA并产生事件
+func (w *Watcher) reevaluate() {
+	w.mu.Lock()
+	dirty := w.dirty
+	w.dirty = map[string]bool{}
+	w.mu.Unlock()
+	if len(dirty) == 0 {
+		return
+	}
+
+	updated := w.evaluate(dirty)
+	oldSAs := []models.CriticalSA{}
+	newSAs := []models.CriticalSA{}
+	becameCritical := []models.CriticalSA{} // 本次才成为关键SA，需要检查已经运行的Pod所在的节点
+	w.mu.Lock()
+	for name := range dirty {
+		criticalSA, wasCritical := w.critical[name]
+		if wasCritical {
+			oldSAs = append(oldSAs, criticalSA)
+		}
+		if criticalSA, exists := updated[name]; exists {
+			newSAs = append(newSAs, criticalSA)
+			w.critical[name] = criticalSA
+			if !wasCritical {
+				becameCritical = append(becameCritical, criticalSA)
+			}
+		} else {
+			delete(w.critical, name)
+		}
+	}
+	w.mu.Unlock()
+
+	now := time.Now()
+	result := diff.Compare(oldSAs, newSAs)
+	if len(oldSAs) != 0 || len(newSAs) != 0 {
+		w.notifyUpdate()
+	}
+	for _, change := range result.NewSAs {
+		w.handler(Event{Time: now, Type: EventNewCriticalSA, SA: change.SA, Types: change.Types, Level: change.ToLevel, Cause: change.NewBindings})
+	}
+	for _, change := range result.NewTypes {
+		w.handler(Event{Time: now, Type: EventNewPermission, SA: change.SA, Types: change.Types, Level: change.ToLevel, Cause: change.NewBindings})
+	}
+	for _, change := range result.ScopeChanges {
+		w.handler(Event{Time: now, Type: EventScopeGained, SA: change.SA, Level: change.ToLevel, Cause: change.NewBindings})
+	}
+	for _, criticalSA := range becameCritical {
+		for _, pod := range w.podsOnSensitiveNodes(criticalSA.SA0.Name) {
+			w.handler(sensitivePodEvent(now, criticalSA, pod))
+		}
+	}
+}
+
+// podsOnSensitiveNodes 使用SA且已调度到敏感节点的Pod
+// 参数:
+//   - name: SA完整名称(格式:namespace/name)
+func (w *Watcher) podsOnSensitiveNodes(name string) []*coreV1.Pod {
+	namespace, saName, _ := strings.Cut(name, "/")
+	objs, err := w.pods.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+	result := []*coreV1.Pod{}
+	for _, pod := range objs {
+		if pod.Spec.ServiceAccountName == saName && pod.Spec.NodeName != "" && w.isSensitiveNode(pod.Spec.NodeName) {
+			result = append(result, pod)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// sensitivePodEvent 挂载关键SA的Pod位于敏感节点的事件
+func sensitivePodEvent(now time.Time, criticalSA models.CriticalSA, pod *coreV1.Pod) Event {
+	return Event{
+		Time:  now,
+		Type:  EventCriticalPodOnSensitiveNode,
+		SA:    criticalSA.SA0.Name,
+		Types: criticalSA.Type,
+		Level: criticalSA.Level,
+		Pod:   pod.Namespace + "/" + pod.Name,
+		Node:  pod.Spec.NodeName,
+	}
+}
+
+// evaluate 基于informer缓存评估SA的关键权限
+// 参数:
+//   - only: 只评估这些SA，为nil时评估全部SA
+func (w *Watcher) evaluate(only map[string]bool) map[string]models.CriticalSA {
+	clusterRoleBindings := []models.RoleBinding{}
+	if objs, err := w.clusterRoleBindings.List(labels.Everything()); err == nil {
+		for _, obj := range objs {
+			binding := utils.ConvertClusterRoleBinding(obj)
+			if bindsAny(binding, only) {
+				clusterRoleBindings = append(clusterRoleBindings, binding)
+			}
+		}
+	}
+	roleBindings := []models.RoleBinding{}
+	if objs, err := w.roleBindings.List(labels.Everything()); err == nil {
+		for _, obj := range objs {
+			binding := utils.ConvertRoleBinding(obj)
+			if bindsAny(binding, only) {
+				roleBindings = append(roleBindings, binding)
+			}
+		}
+	}
+	sas := scan.BuildSaBinding(clusterRoleBindings, roleBindings, w.getRules)
+	for name := range sas {
+		if only != nil && !only[name] {
+			delete(sas, name)
+		}
+	}
+
+	pods := []models.Pod{}
+	if objs, err := w.pods.List(labels.Everything()); err == nil {
+		for _, obj := range objs {
+			key := obj.Namespace + "/" + obj.Spec.ServiceAccountName
+			if _, exists := sas[key]; exists {
+				pods = append(pods, utils.ConvertPod(obj, w.saAutomount(obj.Namespace, obj.Spec.ServiceAccountName)))
+			}
+		}
+	}
+	scan.MarkMounted(sas, pods)
+
+	result := map[string]models.CriticalSA{}
+	for _, criticalSA := range scan.GetCriticalSA(sas, w.controlledNode) {
+		result[criticalSA.SA0.Name] = criticalSA
+	}
+	return result
+}
+
+// getRules 从informer缓存中获取角色规则，参数格式与 utils.GetRulesFromRole 一致
+func (w *Watcher) getRules(role string) []models.Rule {
+	namespace, name, namespaced := strings.Cut(role, "/")
+	if namespaced {
+		obj, err := w.roles.Roles(namespace).Get(name)
+		if err != nil {
+			return nil
+		}
+		return utils.ConvertRules(obj.Rules)
+	}
+	obj, err := w.clusterRoles.Get(role)
+	if err != nil {
+		return nil
+	}
+	return utils.ConvertRules(obj.Rules)
+}
+
+// saAutomount 获取SA的 automountServiceAccountToken 设置
+func (w *Watcher) saAutomount(namespace string, name string) *bool {
+	sa, err := w.serviceAccounts.ServiceAccounts(namespace).Get(name)
+	if err != nil {
+		return nil
+	}
+	return sa.AutomountServiceAccountToken
+}
+
+// markDirty 标记需要重新评估的SA
+func (w *Watcher) markDirty(names ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, name := range names {
+		w.dirty[name] = true
+	}
+}
+
+// onRoleChange 角色变化时，标记所有绑定了该角色的SA
+func (w *Watcher) onRoleChange(oldObj, newObj interface{}) {
+	role := ""
+	for _, obj := range []interface{}{oldObj, newObj} {
+		switch r := obj.(type) {
+		case *rbacV1.Role:
+			role = r.Namespace + "/" + r.Name
+		case *rbacV1.ClusterRole:
+			role = r.Name
+		}
+	}
+	if role == "" {
+		return
+	}
+	if objs, err := w.clusterRoleBindings.List(labels.Everything()); err == nil {
+		for _, obj := range objs {
+			if binding := utils.ConvertClusterRoleBinding(obj); binding.RoleRef == role {
+				w.markDirty(binding.Subject...)
+			}
+		}
+	}
+	if objs, err := w.roleBindings.List(labels.Everything()); err == nil {
+		for _, obj := range objs {
+			if binding := utils.ConvertRoleBinding(obj); binding.RoleRef == role {
+				w.markDirty(binding.Subject...)
+			}
+		}
+	}
+}
+
+// onBindingChange 绑定变化时，标记新旧绑定的所有主体
+func (w *Watcher) onBindingChange(oldObj, newObj interface{}) {
+	for _, obj := range []interface{}{oldObj, newObj} {
+		switch b := obj.(type) {
+		case *rbacV1.RoleBinding:
+			w.markDirty(utils.ConvertRoleBinding(b).Subject...)
+		case *rbacV1.ClusterRoleBinding:
+			w.markDirty(utils.ConvertClusterRoleBinding(b).Subject...)
+		}
+	}
+}
+
+// onServiceAccountChange SA变化时重新评估该SA
+func (w *Watcher) onServiceAccountChange(oldObj, newObj interface{}) {
+	for _, obj := range []interface{}{oldObj, newObj} {
+		if sa, ok := obj.(*coreV1.ServiceAccount); ok {
+			w.markDirty(sa.Namespace + "/" + sa.Name)
+		}
+	}
+}
+
+// onPodChange Pod变化时重新评估其SA，并检查挂载关键SA的Pod是否被调度到敏感节点
+// SA在Pod调度之后才成为关键SA时，由 reevaluate 检查已经运行的Pod
+func (w *Watcher) onPodChange(oldObj, newObj interface{}) {
+	oldPod, _ := oldObj.(*coreV1.Pod)
+	newPod, _ := newObj.(*coreV1.Pod)
+	for _, pod := range []*coreV1.Pod{oldPod, newPod} {
+		if pod != nil {
+			w.markDirty(pod.Namespace + "/" + pod.Spec.ServiceAccountName)
+		}
+	}
+	if newPod == nil || newPod.Spec.NodeName == "" {
+		return
+	}
+	if oldPod != nil && oldPod.Spec.NodeName == newPod.Spec.NodeName {
+		return
+	}
+	name := newPod.Namespace + "/" + newPod.Spec.ServiceAccountName
+	w.mu.Lock()
+	criticalSA, critical := w.critical[name]
+	w.mu.Unlock()
+	if critical && w.isSensitiveNode(newPod.Spec.NodeName) {
+		w.handler(sensitivePodEvent(time.Now(), criticalSA, newPod))
+	}
+}
+
+// isSensitiveNode 判断节点是否为敏感节点(配置的敏感节点或控制平面节点)
+func (w *Watcher) isSensitiveNode(nodeName string) bool {
+	if w.sensitiveNodes[nodeName] {
+		return true
+	}
+	if w.nodes == nil {
+		return false
+	}
+	node, err := w.nodes.Get(nodeName)
+	if err != nil {
+		return false
+	}
+	for _, label := range controlPlaneLabels {
+		if _, exists := node.Labels[label]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// bindsAny 判断绑定是否包含指定SA中的任意一个，only为nil时始终返回true
+func bindsAny(binding models.RoleBinding, only map[string]bool) bool {
+	if only == nil {
+		return true
+	}
+	for _, subject := range binding.Subject {
+		if only[subject] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package watch
+
+import (
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fixtureWatcher 使用informer缓存(不启动informer)的监控实例，返回实例、收到的事件和各资源的缓存
+func fixtureWatcher(t *testing.T) (*Watcher, *[]Event, map[string]cache.Indexer) {
+	t.Helper()
+	events := []Event{}
+	w := NewWatcher(fake.NewSimpleClientset(), "worker-1", []string{"ingress-1"}, func(event Event) {
+		events = append(events, event)
+	})
+	factory := informers.NewSharedInformerFactory(w.clientset, 0)
+	rbac, core := factory.Rbac().V1(), factory.Core().V1()
+	w.roles, w.clusterRoles = rbac.Roles().Lister(), rbac.ClusterRoles().Lister()
+	w.roleBindings, w.clusterRoleBindings = rbac.RoleBindings().Lister(), rbac.ClusterRoleBindings().Lister()
+	w.pods, w.serviceAccounts, w.nodes = core.Pods().Lister(), core.ServiceAccounts().Lister(), core.Nodes().Lister()
+	indexers := map[string]cache.Indexer{
+		"clusterroles":        rbac.ClusterRoles().Informer().GetIndexer(),
+		"clusterrolebindings": rbac.ClusterRoleBindings().Informer().GetIndexer(),
+		"pods":                core.Pods().Informer().GetIndexer(),
+		"nodes":               core.Nodes().Informer().GetIndexer(),
+	}
+	add := func(resource string, obj interface{}) {
+		if err := indexers[resource].Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("nodes", &coreV1.Node{ObjectMeta: metaV1.ObjectMeta{Name: "master-1", Labels: map[string]string{"node-role.kubernetes.io/control-plane": ""}}})
+	add("nodes", &coreV1.Node{ObjectMeta: metaV1.ObjectMeta{Name: "worker-1"}})
+	add("clusterroles", &rbacV1.ClusterRole{
+		ObjectMeta: metaV1.ObjectMeta{Name: "secret-reader"},
+		Rules:      []rbacV1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}},
+	})
+	pod := func(name string, node string) *coreV1.Pod {
+		return &coreV1.Pod{
+			ObjectMeta: metaV1.ObjectMeta{Namespace: "app", Name: name, UID: types.UID("uid-" + name)},
+			Spec:       coreV1.PodSpec{ServiceAccountName: "ci", NodeName: node},
+		}
+	}
+	add("pods", pod("ci-master", "master-1"))
+	add("pods", pod("ci-ingress", "ingress-1"))
+	add("pods", pod("ci-worker", "worker-1"))
+	add("pods", pod("ci-pending", ""))
+	return w, &events, indexers
+}
+
+// TestBecameCriticalOnSensitiveNode Pod已经运行在敏感节点上，之后SA才成为关键SA时同样产生事件
+func TestBecameCriticalOnSensitiveNode(t *testing.T) {
+	w, events, indexers := fixtureWatcher(t)
+	w.critical = w.evaluate(nil)
+	if len(w.critical) != 0 {
+		t.Fatalf("基线中不应有关键SA: %v", w.critical)
+	}
+
+	binding := &rbacV1.ClusterRoleBinding{
+		ObjectMeta: metaV1.ObjectMeta{Name: "ci-secrets"},
+		RoleRef:    rbacV1.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+		Subjects:   []rbacV1.Subject{{Kind: "ServiceAccount", Namespace: "app", Name: "ci"}},
+	}
+	if err := indexers["clusterrolebindings"].Add(binding); err != nil {
+		t.Fatal(err)
+	}
+	w.onBindingChange(nil, binding)
+	w.reevaluate()
+
+	placements := map[string]string{}
+	for _, event := range *events {
+		if event.Type != EventCriticalPodOnSensitiveNode {
+			continue
+		}
+		if event.SA != "app/ci" || len(event.Types) == 0 {
+			t.Errorf("事件 %+v", event)
+		}
+		placements[event.Pod] = event.Node
+	}
+	want := map[string]string{"app/ci-master": "master-1", "app/ci-ingress": "ingress-1"}
+	if len(placements) != len(want) {
+		t.Fatalf("敏感节点事件 %v, 期望 %v (全部事件: %+v)", placements, want, *events)
+	}
+	for pod, node := range want {
+		if placements[pod] != node {
+			t.Errorf("Pod %s 的节点 %q, 期望 %q", pod, placements[pod], node)
+		}
+	}
+
+	// 已经是关键SA时，重新评估不再重复产生敏感节点事件
+	*events = nil
+	w.markDirty("app/ci")
+	w.reevaluate()
+	for _, event := range *events {
+		if event.Type == EventCriticalPodOnSensitiveNode {
+			t.Errorf("重复的敏感节点事件: %+v", event)
+		}
+	}
+}